@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"bittrail-agent/internal/collector"
+	"bittrail-agent/internal/config"
+	"bittrail-agent/internal/report"
+	"bittrail-agent/internal/template"
+)
+
+// exitNonCompliant e codul de iesire al unui audit local NON_COMPLIANT,
+// distinct de 1 (eroare de rulare) pentru pipeline-urile CI
+const exitNonCompliant = 2
+
+// reportWriters sunt formatele de raport (audit, journal export)
+var reportWriters = map[string]func(io.Writer, *report.Report) error{
+	"json":  report.WriteJSON,
+	"junit": report.WriteJUnit,
+	"html":  report.WriteHTML,
+	"oscal": report.WriteOSCAL,
+	"sarif": report.WriteSARIF,
+}
+
+// runLocalAudit executa sablonul pe gazda curenta, fara backend, si scrie
+// raportul in formatul cerut (stdout daca output e gol)
+func runLocalAudit(templatePath, format, output string) error {
+	write, ok := reportWriters[format]
+	if !ok {
+		return fmt.Errorf("format necunoscut: %s (json, junit, html, oscal, sarif)", format)
+	}
+	tpl, err := template.Load(templatePath)
+	if err != nil {
+		return fmt.Errorf("sablon invalid: %w", err)
+	}
+	cfg, err := config.LoadLocal(cfgFile)
+	if err != nil {
+		return fmt.Errorf("configurare invalida: %w", err)
+	}
+
+	started := time.Now()
+	results := collector.NewLocalAuditRunner(cfg).RunTemplate(tpl)
+	hostname, _ := os.Hostname()
+	r := report.Build(tpl, results, hostname, started, time.Now())
+	r.ServerID, r.AuditRunID = cfg.ServerID, collector.LocalRunID
+	if err := writeReport(write, r, output); err != nil {
+		return err
+	}
+
+	s := r.Summary
+	fmt.Fprintf(os.Stderr, "%s: %s (%.2f%%, %d PASS, %d FAIL, %d erori, %d N/A, %d exceptii, %d manuale neevaluate)\n",
+		tpl.Metadata.Name, s.Status, s.CompliancePercent, s.Passed, s.Failed, s.Errored, s.NotApplicable, s.Waived, s.Manual)
+	if s.Status == report.NonCompliant {
+		os.Exit(exitNonCompliant)
+	}
+	return nil
+}
+
+// writeReport scrie raportul in fisierul output (stdout daca e gol)
+func writeReport(write func(io.Writer, *report.Report) error, r *report.Report, output string) error {
+	if output == "" {
+		if err := write(os.Stdout, r); err != nil {
+			return fmt.Errorf("scriere raport: %w", err)
+		}
+		return nil
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("fisier raport: %w", err)
+	}
+	if err := write(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("scriere raport: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("scriere raport: %w", err)
+	}
+	return nil
+}
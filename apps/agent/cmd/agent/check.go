@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/collector"
+	"bittrail-agent/internal/config"
+	"bittrail-agent/internal/template"
+)
+
+// runSingleCheck executa o verificare (din fisier sau dintr-un sablon) si
+// afiseaza fiecare etapa a evaluarii, pentru reproducerea unui rezultat
+func runSingleCheck(file, templatePath, checkID string, unredacted bool) error {
+	check, err := loadSingleCheck(file, templatePath, checkID)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadLocal(cfgFile)
+	if err != nil {
+		return fmt.Errorf("configurare invalida: %w", err)
+	}
+
+	fmt.Printf("=== Verificare %s ===\n", check.CheckID)
+	fmt.Printf("Tip:         %s\n", check.CheckType)
+	if check.CheckType == "SCRIPT" {
+		fmt.Printf("Interpretor: %s\n", valueOr(check.Interpreter, "sh"))
+		printBlock("Script", check.Script)
+	} else {
+		printBlock("Comanda", check.Command)
+	}
+	for _, err := range collector.ValidateCheck(check) {
+		fmt.Printf("ATENTIE:     %v\n", err)
+	}
+
+	t := collector.NewLocalAuditRunner(cfg).TraceCheck(check)
+	res := t.Result
+
+	fmt.Println("\n=== Executie ===")
+	if !t.Executed {
+		fmt.Println("Neexecutata")
+	} else {
+		fmt.Printf("Utilizator:  %s\n", res.ExecUser)
+		fmt.Printf("Exit code:   %d\n", t.ExitCode)
+		if t.ExecError != nil {
+			fmt.Printf("Eroare:      %v\n", t.ExecError)
+		}
+		if unredacted {
+			printBlock("stdout brut", t.RawStdout)
+			printBlock("stderr brut", t.RawStderr)
+		}
+		printBlock("stdout", res.Output)
+		printBlock("stderr", res.Stderr)
+
+		fmt.Println("\n=== Redactare ===")
+		fmt.Printf("stdout:      %s\n", redactionState(t.StdoutRedacted))
+		fmt.Printf("stderr:      %s\n", redactionState(t.StderrRedacted))
+		fmt.Printf("Hash iesire: %s\n", res.OutputHash)
+		if res.OutputTruncated {
+			fmt.Println("Trunchiere:  stdout depaseste max_output_bytes")
+		}
+	}
+
+	if len(t.Normalize) > 0 {
+		fmt.Println("\n=== Normalizare ===")
+		for _, step := range t.Normalize {
+			fmt.Printf("%-12s %q\n", step.Rule+":", step.Output)
+		}
+		fmt.Printf("\n=== Parser %s ===\n", valueOr(check.Parser, "RAW"))
+		fmt.Printf("Valoare:     %q\n", t.Parsed)
+	}
+	if len(t.Comparisons) > 0 {
+		fmt.Println("\n=== Comparatie ===")
+		for _, c := range t.Comparisons {
+			fmt.Printf("%-15s %s %q vs %q -> %t\n", c.Field+":", c.Operator, c.Actual, c.Expected, c.Matched)
+			if c.Note != "" {
+				fmt.Printf("%-15s %s\n", "", c.Note)
+			}
+		}
+	}
+
+	fmt.Println("\n=== Rezultat ===")
+	fmt.Printf("Status:      %s\n", res.Status)
+	if res.ReasonCode != "" {
+		fmt.Printf("Motiv:       %s\n", res.ReasonCode)
+	}
+	if res.ErrorMessage != "" {
+		fmt.Printf("Mesaj:       %s\n", res.ErrorMessage)
+	}
+	if w := res.Waiver; w != nil {
+		state := "activa, status evaluat " + w.EvaluatedStatus
+		if w.Expired {
+			state = "EXPIRATA, nu se aplica"
+		}
+		fmt.Printf("Exceptie:    %s (%s)\n", w.ID, state)
+		fmt.Printf("Aprobator:   %s, expira %s\n", w.Approver, w.ExpiresAt)
+		fmt.Printf("Justificare: %s\n", w.Justification)
+	}
+
+	fmt.Println("\n=== Semnatura ===")
+	switch {
+	case res.Signature == "":
+		fmt.Println("Nesemnat (agent neinrolat sau cheie privata indisponibila)")
+	case t.SignatureError != nil:
+		fmt.Printf("%s v%d: INVALIDA: %v\n", res.SignatureAlg, res.SignatureVersion, t.SignatureError)
+	default:
+		fmt.Printf("%s v%d: valida (cheia agentului)\n", res.SignatureAlg, res.SignatureVersion)
+	}
+	if t.SignedData != "" {
+		fmt.Printf("Date semnate: %s\n", t.SignedData)
+	}
+
+	switch res.Status {
+	case api.StatusPass, api.StatusWarn, api.StatusNotApplicable, api.StatusWaived:
+		return nil
+	}
+	os.Exit(exitNonCompliant)
+	return nil
+}
+
+// loadSingleCheck citeste verificarea dintr-un fisier JSON (o intrare
+// automatedChecks) sau o cauta dupa checkId in sablon
+func loadSingleCheck(file, templatePath, checkID string) (api.PendingCheck, error) {
+	switch {
+	case file != "" && templatePath != "":
+		return api.PendingCheck{}, fmt.Errorf("--file si --template se exclud")
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return api.PendingCheck{}, err
+		}
+		var c template.AutomatedCheck
+		if err := json.Unmarshal(data, &c); err != nil {
+			return api.PendingCheck{}, fmt.Errorf("%s: JSON invalid: %w", file, err)
+		}
+		if c.CheckID == "" {
+			c.CheckID = "ad-hoc"
+		}
+		if c.Command == "" && c.Script == "" {
+			return api.PendingCheck{}, fmt.Errorf("%s: command sau script obligatoriu", file)
+		}
+		return c.PendingCheck(collector.LocalRunID), nil
+	case templatePath != "":
+		if checkID == "" {
+			return api.PendingCheck{}, fmt.Errorf("--check obligatoriu cu --template")
+		}
+		tpl, err := template.Load(templatePath)
+		if err != nil {
+			return api.PendingCheck{}, fmt.Errorf("sablon invalid: %w", err)
+		}
+		for _, control := range tpl.Controls {
+			for _, c := range control.AutomatedChecks {
+				if c.CheckID == checkID {
+					check := c.PendingCheck(collector.LocalRunID)
+					check.ControlID = control.ControlID
+					return check, nil
+				}
+			}
+		}
+		return api.PendingCheck{}, fmt.Errorf("verificarea %s nu exista in %s", checkID, templatePath)
+	}
+	return api.PendingCheck{}, fmt.Errorf("--file sau --template obligatoriu")
+}
+
+// printBlock afiseaza un text pe mai multe linii, indentat
+func printBlock(title, text string) {
+	if text == "" {
+		fmt.Printf("%-12s (gol)\n", title+":")
+		return
+	}
+	fmt.Printf("%s:\n", title)
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		fmt.Printf("  | %s\n", line)
+	}
+}
+
+func redactionState(redacted bool) string {
+	if redacted {
+		return "secrete redactate"
+	}
+	return "nemodificat"
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"bittrail-agent/internal/collector"
+	"bittrail-agent/internal/config"
+	"bittrail-agent/internal/crypto"
+	"bittrail-agent/internal/journal"
+	"bittrail-agent/internal/report"
+	"bittrail-agent/internal/template"
+)
+
+// verifyJournal valideaza jurnalul si afiseaza capul lantului, de comparat
+// cu ultima ancora inregistrata de backend
+func verifyJournal(path string, skipSignatures bool) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("agent neconfigurat: %w", err)
+	}
+	if path == "" {
+		path = collector.JournalPath(cfg)
+	}
+
+	var verifyResult func(journal.Entry) error
+	if !skipSignatures {
+		key, err := crypto.LoadPrivateKey(cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("cheie agent indisponibila (--skip-signatures pentru doar lant): %w", err)
+		}
+		verifyResult = func(e journal.Entry) error {
+			return collector.VerifyResultSignature(&key.PublicKey, e.ServerID, e.AuditRunID, *e.Result)
+		}
+	}
+
+	summary, err := journal.Verify(path, verifyResult)
+	if err != nil {
+		return fmt.Errorf("jurnal invalid (%s): %w", path, err)
+	}
+	fmt.Println("=== Jurnal executii: valid ===")
+	fmt.Printf("Fisier:     %s\n", path)
+	fmt.Printf("Intrari:    %d (%d rezultate, %d ancore)\n", summary.Entries, summary.Results, summary.Anchors)
+	fmt.Printf("Cap lant:   #%d %s\n", summary.HeadSeq, summary.HeadHash)
+	if skipSignatures {
+		fmt.Println("Semnaturi:  neverificate")
+	}
+	return nil
+}
+
+// exportJournal exporta rezultatele unei rulari din jurnal (implicit ultima)
+// intr-un format de raport; exportul porneste doar dintr-un lant valid
+func exportJournal(path, templatePath, auditRunID, format, output string) error {
+	write, ok := reportWriters[format]
+	if !ok {
+		return fmt.Errorf("format necunoscut: %s (oscal, json, junit, html, sarif)", format)
+	}
+	tpl, err := template.Load(templatePath)
+	if err != nil {
+		return fmt.Errorf("sablon invalid: %w", err)
+	}
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("agent neconfigurat: %w", err)
+	}
+	if path == "" {
+		path = collector.JournalPath(cfg)
+	}
+
+	var entries []journal.Entry
+	if _, err := journal.Verify(path, func(e journal.Entry) error {
+		entries = append(entries, e)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("jurnal invalid (%s): %w", path, err)
+	}
+
+	r, unknown, err := report.FromJournal(tpl, entries, auditRunID)
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		fmt.Fprintf(os.Stderr, "ATENTIE: %d rezultate ale rularii nu apar in sablon: %s\n", len(unknown), strings.Join(unknown, ", "))
+	}
+	return writeReport(write, r, output)
+}
@@ -7,6 +7,7 @@ import (
 	"bittrail-agent/internal/config"
 	"bittrail-agent/internal/enrollment"
 	"bittrail-agent/internal/runner"
+	"bittrail-agent/internal/sandbox"
 
 	"github.com/spf13/cobra"
 )
@@ -21,6 +22,12 @@ var (
 var agentVersion = "dev"
 
 func main() {
+	// Proces ajutator sandbox, re-executat de agent pentru fiecare verificare
+	if len(os.Args) > 1 && os.Args[1] == sandbox.HelperCommand {
+		sandbox.RunHelper(os.Args[2:])
+		return
+	}
+
 	rootCmd := &cobra.Command{
 		Use:   "bittrail-agent",
 		Short: "Agent pentru platforma BitTrail",
@@ -119,6 +126,215 @@ Pentru prod, utilizare serviciu systemd:
 	}
 	rootCmd.AddCommand(statusCmd)
 
+	// Comanda jurnal executii
+	journalCmd := &cobra.Command{
+		Use:   "journal",
+		Short: "Jurnalul local al executiilor",
+	}
+	var journalFile string
+	var skipSignatures bool
+	journalVerifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verifica lantul de hash-uri si semnaturile jurnalului",
+		Long: `Verifica jurnalul local append-only al executiilor.
+
+Fiecare intrare contine hash-ul intrarii anterioare; orice modificare,
+stergere sau reordonare rupe lantul. Semnaturile rezultatelor sunt verificate
+cu cheia agentului (key_file), iar ancorele trebuie sa indice intrari existente.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return verifyJournal(journalFile, skipSignatures)
+		},
+	}
+	journalVerifyCmd.Flags().StringVar(&journalFile, "file", "", "fisier jurnal (implicit state_dir/journal.log)")
+	journalVerifyCmd.Flags().BoolVar(&skipSignatures, "skip-signatures", false, "verifica doar lantul de hash-uri")
+	journalCmd.AddCommand(journalVerifyCmd)
+	var exportTemplate, exportRun, exportFormat, exportOutput string
+	journalExportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Exporta rezultatele unei rulari din jurnal (OSCAL assessment-results)",
+		Long: `Exporta rezultatele unei rulari de audit din jurnalul local, implicit
+ultima rulare, ca OSCAL assessment-results sau ca raport json, junit, html, sarif.
+
+Sablonul leaga verificarile de controale (findings per control). Lantul de
+hash-uri e verificat inainte de export; semnaturile agentului sunt incluse ca
+dovezi, de verificat de consumator.
+
+Exemplu:
+  sudo ./bittrail-agent journal export --template templates/nist_800_53_moderate_server.json --output ar.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportJournal(journalFile, exportTemplate, exportRun, exportFormat, exportOutput)
+		},
+	}
+	journalExportCmd.Flags().StringVar(&journalFile, "file", "", "fisier jurnal (implicit state_dir/journal.log)")
+	journalExportCmd.Flags().StringVar(&exportTemplate, "template", "", "sablonul rularii (bittrail-template@1.0)")
+	journalExportCmd.Flags().StringVar(&exportRun, "run", "", "id rulare audit (implicit ultima din jurnal)")
+	journalExportCmd.Flags().StringVar(&exportFormat, "format", "oscal", "format: oscal, json, junit, html, sarif")
+	journalExportCmd.Flags().StringVar(&exportOutput, "output", "", "fisier export (implicit stdout)")
+	journalExportCmd.MarkFlagRequired("template")
+	journalCmd.AddCommand(journalExportCmd)
+	rootCmd.AddCommand(journalCmd)
+
+	// Comanda audit local (offline)
+	var templatePath, reportFormat, reportOutput string
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Ruleaza local un sablon de audit, fara backend",
+		Long: `Ruleaza toate verificarile automate ale unui sablon bittrail-template@1.0
+pe serverul curent, cu aceeasi executie, normalizare si comparatie ca auditurile
+din backend, si calculeaza scorul per control si general.
+
+Verificarile manuale sunt numarate, dar nu sunt evaluate. Sandbox-ul si
+politica de executie din configurare se aplica; fara configurare (agent
+neinrolat) se folosesc valorile implicite.
+
+Cod iesire: 0 conform sau partial conform, 2 neconform, 1 eroare.
+
+Exemplu:
+  sudo ./bittrail-agent audit --template templates/cis_ubuntu_2204_l1_server.json
+  sudo ./bittrail-agent audit --template nis2.json --format junit --output nis2.xml
+  sudo ./bittrail-agent audit --template nis2.json --format sarif --output nis2.sarif`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Geteuid() != 0 {
+				fmt.Fprintln(os.Stderr, "ATENTIE: Rulare ca non-root. Unele verificari pot esua.")
+			}
+			return runLocalAudit(templatePath, reportFormat, reportOutput)
+		},
+	}
+	auditCmd.Flags().StringVar(&templatePath, "template", "", "fisier sablon (bittrail-template@1.0)")
+	auditCmd.Flags().StringVar(&reportFormat, "format", "json", "format raport: json, junit, html, oscal, sarif")
+	auditCmd.Flags().StringVar(&reportOutput, "output", "", "fisier raport (implicit stdout)")
+	auditCmd.MarkFlagRequired("template")
+	rootCmd.AddCommand(auditCmd)
+
+	// Comenzi verificari
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Depanare verificari individuale",
+	}
+
+	var checkFile, checkTemplate, checkID string
+	var checkUnredacted bool
+	checkRunCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Executa o verificare si afiseaza fiecare etapa",
+		Long: `Executa o singura verificare pe acelasi drum ca auditul (aplicabilitate,
+politica, sandbox, redactare, semnare) si afiseaza fiecare etapa: stdout si
+stderr, exit code, iesirea dupa fiecare regula Normalize, valoarea extrasa
+de parser, comparatiile efectuate, statusul final si semnatura rezultatului.
+
+Verificarea vine dintr-un fisier JSON (o intrare automatedChecks a unui
+sablon) sau din sablon, dupa checkId. Iesirea afisata e cea redactata;
+--unredacted afiseaza si iesirea bruta, inainte de redactarea secretelor.
+
+Cod iesire: 0 PASS, WARN, NOT_APPLICABLE sau WAIVED, 2 alt status, 1 eroare.
+
+Exemplu:
+  sudo ./bittrail-agent check run --file check.json
+  sudo ./bittrail-agent check run --template templates/nis2_baseline_server.json --check NIS2-1.2.a`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSingleCheck(checkFile, checkTemplate, checkID, checkUnredacted)
+		},
+	}
+	checkRunCmd.Flags().StringVar(&checkFile, "file", "", "fisier JSON cu verificarea")
+	checkRunCmd.Flags().StringVar(&checkTemplate, "template", "", "sablon (bittrail-template@1.0)")
+	checkRunCmd.Flags().StringVar(&checkID, "check", "", "checkId din sablon")
+	checkRunCmd.Flags().BoolVar(&checkUnredacted, "unredacted", false, "afiseaza si iesirea neredactata (poate contine secrete)")
+	checkCmd.AddCommand(checkRunCmd)
+	rootCmd.AddCommand(checkCmd)
+
+	// Comenzi sabloane
+	templateCmd := &cobra.Command{
+		Use:   "template",
+		Short: "Operatii pe sabloanele de audit",
+	}
+
+	var lintPolicy string
+	var lintStrict bool
+	templateLintCmd := &cobra.Command{
+		Use:   "lint <fisier>...",
+		Short: "Verifica sabloane fara a executa verificarile",
+		Long: `Verifica sabloanele bittrail-template@1.0 inainte de publicare: structura,
+checkId si controlId unice, checkType, comparison, parser si normalize
+suportate de agent, expresiile REGEX si pragurile numerice, specificatiile
+verificarilor native si platformScope. Comenzile si scripturile trec prin
+politica de executie a agentului (--policy sau cea din configurare).
+
+Avertismentele semnaleaza campuri necunoscute si unelte care nu exista pe
+toate distributiile (netstat, ifconfig, service, apt/rpm fara platformScope).
+
+Cod iesire: 0 fara erori, 2 erori (sau avertismente, cu --strict), 1 fisier
+ilizibil sau politica invalida.
+
+Exemplu:
+  ./bittrail-agent template lint templates/*.json
+  ./bittrail-agent template lint --strict --policy exec-policy.yaml nis2.json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return lintTemplates(args, lintPolicy, lintStrict)
+		},
+	}
+	templateLintCmd.Flags().StringVar(&lintPolicy, "policy", "", "politica executie (implicit cea din configurare)")
+	templateLintCmd.Flags().BoolVar(&lintStrict, "strict", false, "avertismentele esueaza si ele")
+	templateCmd.AddCommand(templateLintCmd)
+	rootCmd.AddCommand(templateCmd)
+
+	// Comenzi exceptii (waivers)
+	waiverCmd := &cobra.Command{
+		Use:   "waiver",
+		Short: "Exceptii aprobate pentru verificarile gazdei",
+	}
+
+	var waiverKey, waiverIn, waiverOut string
+	waiverSignCmd := &cobra.Command{
+		Use:   "sign",
+		Short: "Semneaza fisierul de exceptii cu cheia aprobatorului",
+		Long: `Semneaza un set de exceptii pentru waiver_file. Fiecare exceptie acopera
+verificari (checkIds) sau controale (controlIds) si are justificare, aprobator
+si expirare (RFC3339 sau AAAA-LL-ZZ, valabila inclusiv in ziua respectiva).
+
+Verificarile acoperite sunt executate in continuare si raportate WAIVED, cu
+statusul evaluat in metadatele exceptiei. Dupa expirare, statusul evaluat e
+raportat din nou, iar exceptia e semnalata ca expirata.
+
+Agentul verifica semnatura cu waiver_key_file (cheia publica a aprobatorului);
+cheia privata nu trebuie sa fie pe serverul auditat.
+
+Exemplu fisier --in:
+  {"serverId": "...", "waivers": [{"id": "W-2026-01", "checkIds": ["CIS-5.2.4"],
+   "justification": "...", "approver": "ion.popescu", "expiresAt": "2026-12-31"}]}
+
+Exemplu:
+  ./bittrail-agent waiver sign --key approver.key --in waivers.draft.json --out waivers.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return signWaivers(waiverKey, waiverIn, waiverOut)
+		},
+	}
+	waiverSignCmd.Flags().StringVar(&waiverKey, "key", "", "cheie privata aprobator (PEM)")
+	waiverSignCmd.Flags().StringVar(&waiverIn, "in", "", "set exceptii (JSON nesemnat)")
+	waiverSignCmd.Flags().StringVar(&waiverOut, "out", "", "fisier semnat (implicit stdout)")
+	waiverSignCmd.MarkFlagRequired("key")
+	waiverSignCmd.MarkFlagRequired("in")
+	waiverCmd.AddCommand(waiverSignCmd)
+
+	var waiverFile, waiverPubKey string
+	waiverListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Verifica si afiseaza exceptiile gazdei",
+		Long: `Verifica fisierul de exceptii ca agentul (semnatura aprobatorului, server)
+si afiseaza fiecare exceptie cu starea ei; exceptiile care expira in mai
+putin de 7 zile sunt semnalate.
+
+Cod iesire: 0 exceptii valide, 2 cel putin o exceptie expirata, 1 fisier
+respins.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listWaivers(waiverFile, waiverPubKey)
+		},
+	}
+	waiverListCmd.Flags().StringVar(&waiverFile, "file", "", "fisier exceptii (implicit waiver_file)")
+	waiverListCmd.Flags().StringVar(&waiverPubKey, "key", "", "cheie publica aprobator (implicit waiver_key_file)")
+	waiverCmd.AddCommand(waiverListCmd)
+	rootCmd.AddCommand(waiverCmd)
+
 	// Comanda versiune
 	versionCmd := &cobra.Command{
 		Use:   "version",
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"bittrail-agent/internal/config"
+	"bittrail-agent/internal/lint"
+	"bittrail-agent/internal/policy"
+)
+
+// exitLintFailed e codul de iesire cand un sablon are erori (sau avertismente,
+// cu --strict); fisierele ilizibile si politica invalida intorc 1 prin RunE
+const exitLintFailed = 2
+
+// lintTemplates verifica sabloanele si afiseaza constatarile, cate una pe linie
+// (fisier: locatie (checkId): severitate: mesaj), pentru CI
+func lintTemplates(files []string, policyFile string, strict bool) error {
+	if policyFile == "" {
+		cfg, err := config.LoadLocal(cfgFile)
+		if err != nil {
+			return fmt.Errorf("configurare invalida: %w", err)
+		}
+		policyFile = cfg.ExecPolicyFile
+	}
+	pol, err := policy.Load(policyFile)
+	if err != nil {
+		return fmt.Errorf("politica executie %s: %w", policyFile, err)
+	}
+
+	failed := false
+	var errorCount, warningCount int
+	for _, file := range files {
+		findings, err := lint.File(file, pol)
+		if err != nil {
+			return err
+		}
+		for _, f := range findings {
+			fmt.Printf("%s: %s\n", file, f)
+			if f.Severity == lint.SeverityError {
+				errorCount++
+			} else {
+				warningCount++
+			}
+		}
+		if lint.HasErrors(findings, strict) {
+			failed = true
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%d sabloane, %d erori, %d avertismente\n", len(files), errorCount, warningCount)
+	if failed {
+		os.Exit(exitLintFailed)
+	}
+	return nil
+}
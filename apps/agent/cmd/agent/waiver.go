@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"bittrail-agent/internal/config"
+	"bittrail-agent/internal/crypto"
+	"bittrail-agent/internal/waiver"
+)
+
+// exitWaiversExpired e codul de iesire al listarii cand cel putin o exceptie
+// a expirat, pentru monitorizare
+const exitWaiversExpired = 2
+
+// signWaivers semneaza setul de exceptii (JSON necriptat) cu cheia privata a
+// aprobatorului si scrie fisierul pentru waiver_file
+func signWaivers(keyFile, input, output string) error {
+	key, err := crypto.LoadPrivateKey(keyFile)
+	if err != nil {
+		return fmt.Errorf("cheie aprobator: %w", err)
+	}
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	var set waiver.Set
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("%s: JSON invalid: %w", input, err)
+	}
+	if set.ServerID == "" {
+		return fmt.Errorf("%s: serverId obligatoriu", input)
+	}
+	if set.IssuedAt == 0 {
+		set.IssuedAt = time.Now().Unix()
+	}
+
+	f, err := waiver.Sign(key, &set)
+	if err != nil {
+		return fmt.Errorf("%s: %w", input, err)
+	}
+	out, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	out = append(out, '\n')
+	if output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	if err := os.WriteFile(output, out, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d exceptii semnate pentru serverul %s: %s\n", len(set.Waivers), set.ServerID, output)
+	return nil
+}
+
+// listWaivers verifica fisierul de exceptii ca agentul (semnatura, server) si
+// afiseaza fiecare exceptie cu starea ei
+func listWaivers(file, keyFile string) error {
+	cfg, err := config.LoadLocal(cfgFile)
+	if err != nil {
+		return fmt.Errorf("configurare invalida: %w", err)
+	}
+	if file == "" {
+		file = cfg.WaiverFile
+	}
+	if keyFile == "" {
+		keyFile = cfg.WaiverKeyFile
+	}
+	if keyFile == "" {
+		return fmt.Errorf("waiver_key_file nu e configurat (sau --key)")
+	}
+	pub, err := os.ReadFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("cheie aprobator: %w", err)
+	}
+	set, err := waiver.Load(file, pub, cfg.ServerID)
+	if err != nil {
+		return fmt.Errorf("exceptii respinse de agent: %w", err)
+	}
+
+	now := time.Now()
+	fmt.Printf("=== Exceptii %s ===\n", file)
+	fmt.Printf("Server ID:   %s\n", set.ServerID)
+	if set.IssuedAt != 0 {
+		fmt.Printf("Emise:       %s\n", time.Unix(set.IssuedAt, 0).Format(time.RFC3339))
+	}
+	fmt.Printf("Semnatura:   valida (%s)\n", keyFile)
+
+	expired := 0
+	for _, w := range set.Waivers {
+		state := "ACTIVA"
+		if w.Expired(now) {
+			state = "EXPIRATA"
+			expired++
+		} else if expiry, _ := w.Expiry(); expiry.Sub(now) < 7*24*time.Hour {
+			state = fmt.Sprintf("ACTIVA, expira in %s", expiry.Sub(now).Round(time.Hour))
+		}
+		fmt.Printf("\n%s: %s\n", w.ID, state)
+		if len(w.CheckIDs) > 0 {
+			fmt.Printf("  Verificari:  %s\n", strings.Join(w.CheckIDs, ", "))
+		}
+		if len(w.ControlIDs) > 0 {
+			fmt.Printf("  Controale:   %s\n", strings.Join(w.ControlIDs, ", "))
+		}
+		fmt.Printf("  Aprobator:   %s\n", w.Approver)
+		fmt.Printf("  Expira:      %s\n", w.ExpiresAt)
+		fmt.Printf("  Justificare: %s\n", w.Justification)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d exceptii, %d expirate\n", len(set.Waivers), expired)
+	if expired > 0 {
+		os.Exit(exitWaiversExpired)
+	}
+	return nil
+}
@@ -109,6 +109,7 @@ ExecStart=$INSTALL_DIR/$BINARY_NAME run
 Restart=always
 User=root
 Group=root
+Delegate=yes
 Environment="PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
 
 [Install]
@@ -40,20 +40,41 @@ func (c *Client) SendInventory(inventory interface{}) error {
 }
 
 type PendingCheck struct {
-	AuditRunID       string   `json:"auditRunId"`
-	AutomatedCheckID string   `json:"automatedCheckId"`
-	CheckID          string   `json:"checkId"`
-	Title            string   `json:"title"`
-	Command          string   `json:"command"`
-	Script           string   `json:"script"`
-	ExpectedResult   string   `json:"expectedResult"`
-	CheckType        string   `json:"checkType"`
-	Comparison       string   `json:"comparison"`
-	Parser           string   `json:"parser"`
-	Normalize        []string `json:"normalize"`
-	OnFailMessage    string   `json:"onFailMessage"`
-	PlatformScope    []string `json:"platformScope"`
-	Signature        string   `json:"signature"` // Semnatura verificare comanda
+	AuditRunID       string            `json:"auditRunId"`
+	AutomatedCheckID string            `json:"automatedCheckId"`
+	CheckID          string            `json:"checkId"`
+	ControlID        string            `json:"controlId"` // controlul sablonului (exceptii pe control)
+	Title            string            `json:"title"`
+	Command          string            `json:"command"`
+	Script           string            `json:"script"`
+	Interpreter      string            `json:"interpreter"`  // SCRIPT: sh (implicit), bash, python3, perl
+	ScriptSHA256     string            `json:"scriptSha256"` // SCRIPT: amprenta fixata, inclusa in semnatura
+	Args             []string          `json:"args"`         // SCRIPT: argumente transmise ca argv
+	ExpectedResult   string            `json:"expectedResult"`
+	WarnResult       string            `json:"warnResult"` // prag soft: WARN in loc de FAIL daca iesirea il respecta
+	CheckType        string            `json:"checkType"`
+	Comparison       string            `json:"comparison"`
+	Parser           string            `json:"parser"`
+	Normalize        []string          `json:"normalize"`
+	OnFailMessage    string            `json:"onFailMessage"`
+	PlatformScope    []string          `json:"platformScope"`  // ex: ubuntu>=22.04, rhel:8,9, debian@arm64
+	Requires         []string          `json:"requires"`       // preconditii: bin:auditctl, pkg:auditd
+	TimeoutSeconds   int               `json:"timeoutSeconds"` // 0 = implicit agent
+	Serial           bool              `json:"serial"`         // nu ruleaza in paralel cu alte verificari
+	RunAs            string            `json:"runAs"`          // utilizator executie; gol = implicit agent, "root" doar la nevoie
+	Env              map[string]string `json:"env"`            // variabile verificare; {{hostname}}, {{platform}} ... din inventar
+
+	// Legare payload semnat: server destinatar, fereastra de valabilitate
+	// (secunde unix) si nonce unic, refuzat la a doua folosire
+	ServerID  string `json:"serverId"`
+	IssuedAt  int64  `json:"issuedAt"`
+	ExpiresAt int64  `json:"expiresAt"`
+	Nonce     string `json:"nonce"`
+
+	// Payload = JSON canonic (base64) al tuturor campurilor de mai sus, semnat
+	// de backend; agentul executa doar campurile din payload
+	Payload   string `json:"payload,omitempty"`
+	Signature string `json:"signature"`
 }
 
 func (c *Client) GetPendingChecks() ([]PendingCheck, error) {
@@ -81,10 +102,50 @@ func (c *Client) GetPendingChecks() ([]PendingCheck, error) {
 	return checks, nil
 }
 
+// Statusuri rezultat verificare
+const (
+	StatusPass          = "PASS"
+	StatusFail          = "FAIL"
+	StatusWarn          = "WARN"           // prag soft depasit (warnResult), nu esec
+	StatusError         = "ERROR"          // verificarea nu a putut fi evaluata
+	StatusBlocked       = "BLOCKED"        // refuzata inainte de executie (politica, semnatura)
+	StatusSkipped       = "SKIPPED"        // neexecutata (tip nesuportat de agent)
+	StatusNotApplicable = "NOT_APPLICABLE" // in afara platformScope sau requires neindeplinite
+	StatusWaived        = "WAIVED"         // executata, acoperita de o exceptie aprobata (vezi Waiver)
+)
+
+// Coduri motiv (reasonCode) pentru statusurile diferite de PASS/FAIL
+const (
+	ReasonBlockedByPolicy    = "BLOCKED_BY_POLICY"
+	ReasonSignatureInvalid   = "SIGNATURE_INVALID"
+	ReasonSignatureMissing   = "SIGNATURE_MISSING"
+	ReasonSignatureExpired   = "SIGNATURE_EXPIRED"
+	ReasonReplayed           = "REPLAYED"
+	ReasonSandboxUnavailable = "SANDBOX_UNAVAILABLE"
+	ReasonTimeout            = "TIMEOUT"
+	ReasonCommandNotFound    = "COMMAND_NOT_FOUND"
+	ReasonNotApplicable      = "NOT_APPLICABLE"
+	ReasonRequirementNotMet  = "REQUIREMENT_NOT_MET"
+	ReasonSoftThreshold      = "SOFT_THRESHOLD"
+	ReasonUnsupportedType    = "UNSUPPORTED_CHECK_TYPE"
+	ReasonInvalidSpec        = "INVALID_SPEC"
+	ReasonExecutionError     = "EXECUTION_ERROR"
+	ReasonCancelled          = "CANCELLED"           // rulare anulata din backend in timpul executiei
+	ReasonAgentRestart       = "AGENT_RESTART"       // agent repornit in timpul executiei
+	ReasonJournalUnavailable = "JOURNAL_UNAVAILABLE" // jurnal local invalid, rezultatele n-ar fi inregistrate
+
+	// Singurul cod posibil si pe PASS/FAIL: comanda a rulat fara sandbox
+	// (sandbox_mode best-effort fara izolare disponibila, sau off)
+	ReasonUnsandboxed = "UNSANDBOXED"
+)
+
 type CheckResult struct {
 	AutomatedCheckID string `json:"automatedCheckId"`
 	Status           string `json:"status"`
-	Output           string `json:"output"`
+	ReasonCode       string `json:"reasonCode,omitempty"` // motivul statusurilor diferite de PASS/FAIL (sau UNSANDBOXED)
+	Output           string `json:"output"`               // stdout (redactat, eventual trunchiat)
+	Stderr           string `json:"stderr,omitempty"`
+	OutputTruncated  bool   `json:"outputTruncated,omitempty"`
 	ErrorMessage     string `json:"errorMessage,omitempty"`
 
 	// Campuri Lant Custodie
@@ -93,17 +154,181 @@ type CheckResult struct {
 	ExecHostname  string `json:"execHostname"`
 	ExecUser      string `json:"execUser"`
 	ExitCode      int    `json:"exitCode"`
-	Signature     string `json:"signature"` // Semnatura agent pe rezultat
+
+	// Semnatura agent pe structura canonica a rezultatului (vezi collector.signResult)
+	CheckID          string `json:"checkId"`
+	SignatureAlg     string `json:"signatureAlg,omitempty"` // ex: RSA-PSS-SHA256
+	SignatureVersion int    `json:"signatureVersion,omitempty"`
+	Signature        string `json:"signature"`
+
+	// Exceptia care acopera verificarea; cu Expired statusul ramane cel evaluat
+	Waiver *WaiverInfo `json:"waiver,omitempty"`
 }
 
-func (c *Client) SendCheckResults(auditRunID string, results []CheckResult) error {
+// WaiverInfo descrie exceptia aplicata unui rezultat (fisierul waiver_file)
+type WaiverInfo struct {
+	ID              string `json:"id"`
+	Justification   string `json:"justification"`
+	Approver        string `json:"approver"`
+	ExpiresAt       string `json:"expiresAt"`
+	EvaluatedStatus string `json:"evaluatedStatus"` // statusul verificarii, fara exceptie
+	Expired         bool   `json:"expired,omitempty"`
+}
+
+// ResultsAck e confirmarea backend-ului: rezultatele salvate efectiv.
+// Accepted nil = backend vechi, fara confirmare per rezultat (totul salvat).
+type ResultsAck struct {
+	Accepted []string `json:"accepted"`
+}
+
+func (c *Client) SendCheckResults(auditRunID string, results []CheckResult) (ResultsAck, error) {
 	payload := map[string]interface{}{
 		"results": results,
 	}
-	return c.post(fmt.Sprintf("/api/agent/%s/audit/%s/results", c.serverID, auditRunID), payload)
+	var ack ResultsAck
+	err := c.postJSON(fmt.Sprintf("/api/agent/%s/audit/%s/results", c.serverID, auditRunID), payload, &ack)
+	return ack, err
+}
+
+// Stari raportate in heartbeat-ul unei rulari de audit
+const (
+	ProgressStarted  = "started"
+	ProgressRunning  = "running"
+	ProgressFinished = "finished"
+)
+
+// RunProgress e heartbeat-ul agentului pentru o rulare de audit
+type RunProgress struct {
+	State     string   `json:"state"`
+	Total     int      `json:"total"`     // verificari primite in job
+	Completed int      `json:"completed"` // verificari cu rezultat
+	Running   []string `json:"running"`   // checkId-uri in executie
+	Time      string   `json:"time"`
+}
+
+// ProgressAck e raspunsul backend-ului; Cancel = rularea a fost anulata
+type ProgressAck struct {
+	Cancel bool   `json:"cancel"`
+	Status string `json:"status"`
+}
+
+func (c *Client) SendRunProgress(auditRunID string, progress RunProgress) (ProgressAck, error) {
+	var ack ProgressAck
+	err := c.postJSON(fmt.Sprintf("/api/agent/%s/audit/%s/progress", c.serverID, auditRunID), progress, &ack)
+	return ack, err
+}
+
+// JournalAnchor e capul jurnalului local de executii, semnat de agent
+type JournalAnchor struct {
+	Seq          uint64 `json:"seq"`
+	Hash         string `json:"hash"`
+	Time         string `json:"time"`
+	SignatureAlg string `json:"signatureAlg"`
+	Signature    string `json:"signature"`
+}
+
+func (c *Client) SendJournalAnchor(anchor JournalAnchor) error {
+	return c.post(fmt.Sprintf("/api/agent/%s/journal/anchor", c.serverID), anchor)
+}
+
+// JournalAlert semnaleaza un jurnal local de executii care nu poate fi
+// deschis (lant invalid, fisier ilizibil); verificarile sunt refuzate
+type JournalAlert struct {
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+	Time   string `json:"time"`
+}
+
+func (c *Client) SendJournalAlert(alert JournalAlert) error {
+	return c.post(fmt.Sprintf("/api/agent/%s/journal/alert", c.serverID), alert)
+}
+
+// Assignment e un set de verificari atribuit gazdei pentru evaluare
+// continua. Payload = JSON canonic (base64) al AssignmentSet, semnat de
+// backend; agentul il pastreaza local si il reevalueaza fara backend.
+type Assignment struct {
+	AssignmentID string `json:"assignmentId"`
+	Payload      string `json:"payload"`
+	Signature    string `json:"signature"`
+}
+
+// AssignmentSet e continutul semnat al unei atribuiri
+type AssignmentSet struct {
+	AssignmentID string         `json:"assignmentId"`
+	ServerID     string         `json:"serverId"`
+	Template     string         `json:"template"` // nume si versiune sablon, informativ
+	Schedule     string         `json:"schedule"` // expresie cron (5 campuri sau @daily ...)
+	IssuedAt     int64          `json:"issuedAt"` // secunde unix; un set mai vechi decat cel din cache e refuzat
+	Checks       []PendingCheck `json:"checks"`   // fara legare nonce/expiresAt: setul e reevaluat repetat
+}
+
+func (c *Client) GetAssignments() ([]Assignment, error) {
+	var assignments []Assignment
+	err := c.getJSON(fmt.Sprintf("/api/agent/%s/assignments", c.serverID), &assignments)
+	return assignments, err
+}
+
+// DriftEvent e o verificare al carei status s-a schimbat fata de ultima
+// evaluare raportata; PreviousStatus gol = prima evaluare
+type DriftEvent struct {
+	PreviousStatus string      `json:"previousStatus,omitempty"`
+	Result         CheckResult `json:"result"`
+}
+
+// DriftReport e rezultatul unei evaluari continue: doar schimbarile,
+// plus numarul total de verificari evaluate
+type DriftReport struct {
+	IssuedAt    int64        `json:"issuedAt"` // versiunea setului evaluat
+	EvaluatedAt string       `json:"evaluatedAt"`
+	Evaluated   int          `json:"evaluated"`
+	Events      []DriftEvent `json:"events"`
+}
+
+func (c *Client) SendDrift(assignmentID string, report DriftReport) error {
+	return c.post(fmt.Sprintf("/api/agent/%s/assignments/%s/drift", c.serverID, assignmentID), report)
+}
+
+// HTTPError e un raspuns HTTP de eroare al backend-ului
+type HTTPError struct {
+	Code int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("request failed with status: %d", e.Code)
+}
+
+// Rejected e adevarat daca backend-ul a respins definitiv cererea
+// (ex: rulare de audit inexistenta); retrimiterea nu o poate schimba
+func (e *HTTPError) Rejected() bool {
+	return e.Code == http.StatusBadRequest || e.Code == http.StatusNotFound
+}
+
+// getJSON decodeaza raspunsul unei cereri GET in out
+func (c *Client) getJSON(path string, out interface{}) error {
+	req, err := http.NewRequest("GET", c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Agent-Token", c.agentToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &HTTPError{Code: resp.StatusCode}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
 }
 
 func (c *Client) post(path string, data interface{}) error {
+	return c.postJSON(path, data, nil)
+}
+
+// postJSON trimite data si decodeaza raspunsul in out (daca nu e nil)
+func (c *Client) postJSON(path string, data interface{}, out interface{}) error {
 	body, err := json.Marshal(data)
 	if err != nil {
 		return err
@@ -123,8 +348,11 @@ func (c *Client) post(path string, data interface{}) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("request failed with status: %d", resp.StatusCode)
+		return &HTTPError{Code: resp.StatusCode}
 	}
 
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
 	return nil
 }
@@ -3,32 +3,103 @@ package collector
 import (
 	"context"
 	"crypto/rsa"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
-	"os/user"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/config"
 	"bittrail-agent/internal/crypto"
+	"bittrail-agent/internal/journal"
+	"bittrail-agent/internal/policy"
+	"bittrail-agent/internal/sandbox"
 )
 
 type AuditRunner struct {
 	client     *api.Client
 	privateKey *rsa.PrivateKey
-	backendKey []byte
+	serverID   string
+
+	// Payload-uri semnate: backendKeyErr e setat daca cheia e obligatorie
+	// (mod strict) sau configurata, dar nu poate fi citita
+	backendKey    []byte
+	backendKeyErr error
+	clockSkew     time.Duration
+	maxValidity   time.Duration
+	nonces        *nonceStore
+
+	// Jurnal local hash-chained al rezultatelor; nil daca nu poate fi deschis.
+	// journalErr e setat daca jurnalul exista, dar e invalid: verificarile
+	// sunt refuzate si backend-ul e alertat (journalAlerted dupa confirmare)
+	journal        *journal.Journal
+	journalErr     error
+	journalAlerted atomic.Bool
+
+	// Registru rezultate: executie o singura data, retrimitere pana la confirmare
+	ledger *ledger
+
+	// Progresul jobului curent, pentru raportarea verificarilor intrerupte de o repornire
+	checkpoint *checkpoint
+
+	// Atribuiri de evaluare continua pastrate local (RunContinuous)
+	continuous *continuousCache
+
+	defaultTimeout time.Duration
+	maxTimeout     time.Duration
+	killGrace      time.Duration
+	maxOutput      int // octeti pastrati din stdout/stderr
+
+	// Izolare executie; sandboxErr e setat daca modul strict nu poate fi satisfacut
+	sandbox    *sandbox.Sandbox
+	sandboxErr error
+
+	// Utilizator implicit pentru verificari (runAs gol)
+	checkUser string
+
+	// Mediu executie: variabile preluate din mediul agentului si faptele gazdei
+	envAllowlist []string
+	envNames     map[string]bool // variabile declarate pe care template-urile le pot seta
+	facts        map[string]string
+
+	// Director pentru fisierele temporare ale verificarilor SCRIPT
+	scriptDir string
+
+	// Allowlist executie; policyErr e setat daca politica operatorului e invalida
+	policy    *policy.Policy
+	policyErr error
+
+	// Exceptii aprobate pentru gazda (waiver_file); nil = fara exceptii
+	waivers *waiverStore
+
+	// Pool executie
+	workers           int
+	runConcurrency    int
+	heartbeatInterval time.Duration
+	serialMu          sync.RWMutex // verificarile serial ruleaza exclusiv
+	running           atomic.Bool  // un singur job activ (audit sau evaluare continua)
+
+	// Captura pentru depanarea unei verificari (TraceCheck); nil in audituri
+	trace *execTrace
 }
 
-func NewAuditRunner(client *api.Client, keyPath, backendKeyPath string) *AuditRunner {
+func NewAuditRunner(client *api.Client, cfg *config.Config) *AuditRunner {
 	var privKey *rsa.PrivateKey
 	var backendKey []byte
+	var backendKeyErr error
 
-	if keyPath != "" {
-		pk, err := crypto.LoadPrivateKey(keyPath)
+	if cfg.KeyFile != "" {
+		pk, err := crypto.LoadPrivateKey(cfg.KeyFile)
 		if err != nil {
 			log.Printf("WARNING: Failed to load agent private key: %v. Signing disabled.", err)
 		} else {
@@ -36,210 +107,652 @@ func NewAuditRunner(client *api.Client, keyPath, backendKeyPath string) *AuditRu
 		}
 	}
 
-	if backendKeyPath != "" {
-		bk, err := os.ReadFile(backendKeyPath)
+	if cfg.BackendKeyFile != "" {
+		bk, err := os.ReadFile(cfg.BackendKeyFile)
 		if err != nil {
-			log.Printf("WARNING: Failed to load backend public key: %v. Signature verification disabled.", err)
+			backendKeyErr = fmt.Errorf("cheie publica backend indisponibila: %w", err)
 		} else {
 			backendKey = bk
 		}
+	} else if cfg.CheckSignatureMode == "strict" {
+		backendKeyErr = errors.New("mod strict: backend_key_file nu e configurat")
+	}
+	if backendKeyErr != nil {
+		log.Printf("WARNING: %v. Checks will be refused.", backendKeyErr)
+	} else if len(backendKey) == 0 {
+		log.Printf("WARNING: No backend public key configured. Checks run without signature verification.")
+	}
+
+	jrnl, journalErr := journal.Open(JournalPath(cfg))
+	if journalErr != nil {
+		journalErr = fmt.Errorf("jurnal executii invalid: %w", journalErr)
+		log.Printf("SECURITY ALERT: Execution journal %s unusable: %v. Checks will be refused.", JournalPath(cfg), journalErr)
+	} else if torn := jrnl.TornBytes(); torn > 0 {
+		log.Printf("WARNING: Execution journal %s ended with an incomplete entry (%d bytes), moved to %s.torn", JournalPath(cfg), torn, JournalPath(cfg))
+	}
+
+	ar := newExecRunner(cfg)
+	ar.client = client
+	ar.privateKey = privKey
+	ar.serverID = cfg.ServerID
+	ar.backendKey = backendKey
+	ar.backendKeyErr = backendKeyErr
+	ar.clockSkew = time.Duration(cfg.SignatureClockSkew) * time.Second
+	ar.maxValidity = time.Duration(cfg.MaxCheckValidity) * time.Second
+	ar.nonces = newNonceStore(filepath.Join(cfg.StateDir, "nonces.json"), time.Now())
+	ar.journal = jrnl
+	ar.journalErr = journalErr
+	ar.ledger = newLedger(filepath.Join(cfg.StateDir, "ledger.json"), time.Duration(cfg.LedgerRetention)*time.Second, time.Now())
+	ar.checkpoint = loadCheckpoint(filepath.Join(cfg.StateDir, "checkpoint.json"))
+	ar.continuous = loadContinuousCache(filepath.Join(cfg.StateDir, "continuous.json"))
+	ar.heartbeatInterval = time.Duration(cfg.AuditHeartbeatInterval) * time.Second
+	ar.recoverInterrupted()
+	return ar
+}
+
+// newExecRunner construieste partea de executie (timeout-uri, sandbox,
+// politica, mediu, pool), comuna cu auditul local
+func newExecRunner(cfg *config.Config) *AuditRunner {
+	workers := cfg.AuditWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	runConcurrency := cfg.AuditRunConcurrency
+	if runConcurrency < 1 || runConcurrency > workers {
+		runConcurrency = workers
+	}
+
+	sb, sbErr := sandbox.New(sandbox.Config{
+		Mode:       cfg.SandboxMode,
+		CgroupRoot: cfg.SandboxCgroupRoot,
+		CPUPercent: cfg.SandboxCPUPercent,
+		MemoryMB:   cfg.SandboxMemoryMB,
+		PidsMax:    cfg.SandboxPidsMax,
+
+		IsolateNetwork: cfg.SandboxIsolateNetwork,
+		IsolatePID:     cfg.SandboxIsolatePID,
+		IsolateRun:     cfg.SandboxIsolateRun,
+	})
+	if sbErr != nil {
+		log.Printf("WARNING: %v. Command checks will be refused.", sbErr)
+	}
+
+	pol, polErr := policy.Load(cfg.ExecPolicyFile)
+	if polErr != nil {
+		log.Printf("WARNING: Failed to load exec policy %s: %v. Command checks will be refused.", cfg.ExecPolicyFile, polErr)
+	}
+
+	maxOutput := cfg.MaxOutputBytes
+	if maxOutput <= 0 {
+		maxOutput = 64 * 1024
+	}
+
+	envNames := make(map[string]bool)
+	for _, name := range cfg.CheckEnvNames {
+		envNames[name] = true
 	}
 
 	return &AuditRunner{
-		client:     client,
-		privateKey: privKey,
-		backendKey: backendKey,
+		defaultTimeout: time.Duration(cfg.CheckTimeout) * time.Second,
+		maxTimeout:     time.Duration(cfg.MaxCheckTimeout) * time.Second,
+		killGrace:      time.Duration(cfg.KillGracePeriod) * time.Second,
+		maxOutput:      maxOutput,
+		sandbox:        sb,
+		sandboxErr:     sbErr,
+		policy:         pol,
+		policyErr:      polErr,
+		checkUser:      cfg.CheckUser,
+		envAllowlist:   cfg.CheckEnvAllowlist,
+		envNames:       envNames,
+		facts:          hostFacts(),
+		scriptDir:      cfg.ScriptDir,
+		waivers:        newWaiverStore(cfg),
+		workers:        workers,
+		runConcurrency: runConcurrency,
+	}
+}
+
+// checkTimeout intoarce timeout-ul verificarii, plafonat de configurare
+func (ar *AuditRunner) checkTimeout(check api.PendingCheck) time.Duration {
+	timeout := ar.defaultTimeout
+	if check.TimeoutSeconds > 0 {
+		timeout = time.Duration(check.TimeoutSeconds) * time.Second
+	}
+	if ar.maxTimeout > 0 && timeout > ar.maxTimeout {
+		timeout = ar.maxTimeout
 	}
+	return timeout
 }
 
+// CheckAndRun preia verificarile in asteptare si le executa pe pool-ul de
+// workeri. Daca un job anterior inca ruleaza, apelul iese imediat.
 func (ar *AuditRunner) CheckAndRun() error {
+	if !ar.running.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer ar.running.Store(false)
+
+	if ar.journalErr != nil {
+		if err := ar.alertJournal(); err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+	}
+	ar.ledger.prune(time.Now())
+
 	checks, err := ar.client.GetPendingChecks()
 	if err != nil {
 		return err
 	}
 
-	if len(checks) == 0 {
-		return nil
+	// Verificarile cu rezultat in registru nu sunt re-executate: rezultatul
+	// stocat e retrimis mai jos
+	var fresh []api.PendingCheck
+	for _, check := range checks {
+		if check.AuditRunID != adhocRunID && ar.ledger.resend(check.AuditRunID, check.AutomatedCheckID) {
+			continue
+		}
+		fresh = append(fresh, check)
 	}
 
-	log.Printf("Received %d pending checks", len(checks))
+	if len(fresh) > 0 {
+		log.Printf("Received %d pending checks (%d already executed)", len(fresh), len(checks)-len(fresh))
 
-	// Grupare dupa AuditRunID pentru trimitere in loturi
-	resultsByRun := make(map[string][]api.CheckResult)
+		// Semnatura, expirarea si nonce-ul sunt verificate la primire
+		now := time.Now()
+		opened := make([]openedCheck, len(fresh))
+		for i, check := range fresh {
+			opened[i] = ar.openCheck(check, now)
+		}
 
-	for _, check := range checks {
-		// 1. Verificare semnatura (daca exista cheie backend)
-		if len(ar.backendKey) > 0 && check.Signature != "" {
-			verifyData := check.Command + check.CheckID
-			if err := crypto.VerifySignature(ar.backendKey, []byte(verifyData), check.Signature); err != nil {
-				log.Printf("SECURITY ALERT: Signature verification failed for check %s: %v", check.CheckID, err)
-				resultsByRun[check.AuditRunID] = append(resultsByRun[check.AuditRunID], api.CheckResult{
-					AutomatedCheckID: check.AutomatedCheckID,
-					Status:           "ERROR",
-					ErrorMessage:     "Security Error: Invalid Signature",
-				})
-				continue
+		if err := ar.checkpoint.begin(opened); err != nil {
+			log.Printf("WARNING: Failed to save run checkpoint: %v", err)
+		}
+
+		// "started" inainte de executie: o rulare deja anulata nu porneste
+		runs := ar.newRunStates(opened)
+		for runID, run := range runs {
+			if runID != adhocRunID {
+				ar.reportProgress(runID, run, api.ProgressStarted)
+			}
+		}
+		done := make(chan struct{})
+		go ar.heartbeat(runs, done)
+
+		// Rezultatele sunt inregistrate si trimise pe masura ce verificarile se termina
+		completed := make(chan completedCheck, len(opened))
+		sent := make(chan struct{})
+		go func() {
+			ar.reportResults(completed)
+			close(sent)
+		}()
+		ar.runPool(opened, runs, func(check openedCheck, result api.CheckResult) {
+			completed <- completedCheck{check, result}
+		})
+		close(completed)
+		<-sent
+		close(done)
+
+		for runID, run := range runs {
+			run.cancel(nil)
+			if runID != adhocRunID {
+				ar.reportProgress(runID, run, api.ProgressFinished)
 			}
 		}
+		if err := ar.checkpoint.clear(); err != nil {
+			log.Printf("WARNING: Failed to clear run checkpoint: %v", err)
+		}
+	}
+
+	ar.sendUnacked()
+	return nil
+}
 
-		// 2. Executare
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		output, exitCode, err := ar.executeCheck(ctx, check)
-		cancel()
-
-		// 3. Metadate Chain of Custody
-		hostname, _ := os.Hostname()
-		currentUser, _ := user.Current()
-		username := "unknown"
-		if currentUser != nil {
-			username = currentUser.Username
+// completedCheck e o verificare terminata, cu rezultatul ei semnat
+type completedCheck struct {
+	check  openedCheck
+	result api.CheckResult
+}
+
+// reportResults jurnalizeaza, inregistreaza si trimite rezultatele pe masura
+// ce sosesc; cele sosite intre doua trimiteri pleaca in acelasi lot
+func (ar *AuditRunner) reportResults(completed <-chan completedCheck) {
+	for first := range completed {
+		batch := []completedCheck{first}
+	drain:
+		for {
+			select {
+			case c, ok := <-completed:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, c)
+			default:
+				break drain
+			}
 		}
-		timestamp := time.Now().Format(time.RFC3339)
-
-		// 4. Redactare
-		redactedOutput := crypto.RedactSecrets(output)
-		outputHash := crypto.CalculateHash(redactedOutput)
-
-		result := api.CheckResult{
-			AutomatedCheckID: check.AutomatedCheckID,
-			Status:           "FAIL",
-			Output:           redactedOutput,
-			// Campuri CoC
-			OutputHash:    outputHash,
-			ExecTimestamp: timestamp,
-			ExecHostname:  hostname,
-			ExecUser:      username,
-			ExitCode:      exitCode,
+
+		checks := make([]openedCheck, len(batch))
+		results := make([]api.CheckResult, len(batch))
+		resultsByRun := make(map[string][]api.CheckResult)
+		for i, c := range batch {
+			checks[i], results[i] = c.check, c.result
+			resultsByRun[c.check.AuditRunID] = append(resultsByRun[c.check.AuditRunID], c.result)
 		}
+		ar.journalResults(checks, results)
 
-		if err != nil {
-			result.Status = "ERROR"
-			result.ErrorMessage = err.Error()
-			if ctx.Err() == context.DeadlineExceeded {
-				result.ErrorMessage = "Timeout (30s)"
+		// Ad-hoc: trimis direct; restul trece prin registru inainte de trimitere
+		for runID, results := range resultsByRun {
+			if runID == adhocRunID {
+				if _, err := ar.client.SendCheckResults(runID, results); err != nil {
+					log.Printf("Failed to send adhoc results: %v", err)
+				}
+				continue
 			}
+			if err := ar.ledger.record(runID, results, time.Now()); err != nil {
+				log.Printf("WARNING: Failed to record results for run %s: %v", runID, err)
+				continue
+			}
+			if err := ar.checkpoint.done(runID, results); err != nil {
+				log.Printf("WARNING: Failed to save run checkpoint: %v", err)
+			}
+		}
+		ar.sendUnacked()
+	}
+}
+
+// sendUnacked trimite rezultatele din registru neconfirmate inca de backend
+func (ar *AuditRunner) sendUnacked() {
+	for runID, results := range ar.ledger.unacked() {
+		ack, err := ar.client.SendCheckResults(runID, results)
+		var httpErr *api.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Rejected() {
+			log.Printf("WARNING: Backend rejected results for run %s (%v). Dropping them.", runID, err)
+			err = ar.ledger.drop(runID)
+		} else if err != nil {
+			log.Printf("Failed to send results for run %s: %v. Will retry.", runID, err)
+			continue
 		} else {
-			// Succes (exit code 0 sau gestionat)
-			if check.ExpectedResult != "" {
-				if matchesExpected(redactedOutput, check) {
-					result.Status = "PASS"
-				}
-			} else {
-				// Fara asteptari, PASS daca exit code 0
-				if exitCode == 0 {
-					result.Status = "PASS"
+			accepted := ack.Accepted
+			if accepted == nil {
+				// Backend fara confirmare per rezultat: cererea reusita le acopera pe toate
+				for _, result := range results {
+					accepted = append(accepted, result.AutomatedCheckID)
 				}
 			}
+			log.Printf("Sent %d results for run %s (%d acknowledged)", len(results), runID, len(accepted))
+			err = ar.ledger.ack(runID, accepted)
+		}
+		if err != nil {
+			log.Printf("WARNING: Failed to save result ledger: %v", err)
 		}
+	}
+}
 
-		// 5. Semnare rezultat
-		if ar.privateKey != nil {
-			// Semnare: OutputHash + Status + Timestamp
-			signData := result.OutputHash + result.Status + result.ExecTimestamp
-			sig, err := crypto.SignData(ar.privateKey, []byte(signData))
-			if err == nil {
-				result.Signature = sig
-			} else {
-				log.Printf("Error signing result: %v", err)
+// runPool executa verificarile pe cel mult ar.workers goroutine, cu limita
+// per rulare de audit. Verificarile marcate serial ruleaza exclusiv.
+// onResult e apelat din workeri la terminarea fiecarei verificari; verificarile
+// unei rulari anulate nu mai pornesc si nu au rezultat.
+func (ar *AuditRunner) runPool(checks []openedCheck, runs map[string]*runState, onResult func(openedCheck, api.CheckResult)) {
+	snap := &SystemSnapshot{} // baza de date pachete citita o data per job
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < ar.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				check := checks[i]
+				run := runs[check.AuditRunID]
+				run.slots <- struct{}{}
+				if run.ctx.Err() != nil {
+					<-run.slots
+					continue
+				}
+				if check.Serial {
+					ar.serialMu.Lock()
+				} else {
+					ar.serialMu.RLock()
+				}
+
+				run.start(check.CheckID)
+				if err := ar.checkpoint.start(check.PendingCheck, time.Now()); err != nil {
+					log.Printf("WARNING: Failed to save run checkpoint: %v", err)
+				}
+				result := ar.runCheck(run.ctx, check, snap)
+				run.finish(check.CheckID)
+
+				if check.Serial {
+					ar.serialMu.Unlock()
+				} else {
+					ar.serialMu.RUnlock()
+				}
+				<-run.slots
+				onResult(check, result)
 			}
-		}
+		}()
+	}
 
-		resultsByRun[check.AuditRunID] = append(resultsByRun[check.AuditRunID], result)
+	for i := range checks {
+		jobs <- i
 	}
+	close(jobs)
+	wg.Wait()
+}
+
+// runCheck executa verificarea deschisa de openCheck si construieste rezultatul
+// semnat; anularea ctx (rulare anulata) opreste executia in curs
+func (ar *AuditRunner) runCheck(runCtx context.Context, opened openedCheck, snap *SystemSnapshot) api.CheckResult {
+	check, signed := opened.PendingCheck, opened.signed
 
-	// Trimitere rezultate
-	for runID, results := range resultsByRun {
-		if err := ar.client.SendCheckResults(runID, results); err != nil {
-			log.Printf("Failed to send results for run %s: %v", runID, err)
+	// 1. Payload refuzat (semnatura lipsa sau invalida, expirat, reluat)
+	if opened.err != nil {
+		log.Printf("SECURITY ALERT: Check %s refused: %v", check.CheckID, opened.err)
+		status, reason := classifyError(context.Background(), opened.err)
+		return ar.unexecutedResult(check, status, reason, "Security Error: "+opened.err.Error())
+	}
+	if ar.journalErr != nil {
+		return ar.unexecutedResult(check, api.StatusBlocked, api.ReasonJournalUnavailable, "Security Error: "+ar.journalErr.Error())
+	}
+
+	// 2. Aplicabilitate: tip suportat, platformScope si requires, fara executie
+	if !supportedCheckType(check.CheckType) {
+		return ar.unexecutedResult(check, api.StatusSkipped, api.ReasonUnsupportedType,
+			fmt.Sprintf("tip verificare nesuportat de agent: %s", check.CheckType))
+	}
+	scopeCtx, scopeCancel := context.WithTimeout(runCtx, ar.checkTimeout(check))
+	code, message, scopeErr := ar.notApplicableReason(scopeCtx, check, snap)
+	scopeCancel()
+	if scopeErr != nil {
+		status, reason := classifyError(scopeCtx, scopeErr)
+		return ar.unexecutedResult(check, status, reason, scopeErr.Error())
+	}
+	if code != "" {
+		return ar.unexecutedResult(check, api.StatusNotApplicable, code, message)
+	}
+
+	// 3. Executare (verificarile native ruleaza in procesul agentului)
+	execAs := agentUser()
+	var execErr error
+	if !isNativeCheck(check.CheckType) {
+		execAs, execErr = ar.resolveExecUser(check, signed)
+	}
+
+	timeout := ar.checkTimeout(check)
+	ctx, cancel := context.WithTimeout(runCtx, timeout)
+	var out *checkOutput
+	var err error
+	if execErr != nil {
+		out, err = newCheckOutput(newCappedBuffer(0), newCappedBuffer(0), -1), execErr
+		execAs = agentUser()
+	} else {
+		out, err = ar.executeCheck(ctx, check, execAs, signed, snap)
+	}
+	cancel()
+	if ar.trace != nil {
+		ar.trace.output, ar.trace.err = out, err
+	}
+
+	// 4. Metadate Chain of Custody
+	hostname, _ := os.Hostname()
+	timestamp := time.Now().Format(time.RFC3339)
+
+	// 5. Iesirea e deja redactata la captura (comparatia se face doar pe stdout)
+	result := api.CheckResult{
+		AutomatedCheckID: check.AutomatedCheckID,
+		Status:           api.StatusFail,
+		Output:           out.Stdout,
+		Stderr:           out.Stderr,
+		// Campuri CoC: hash pe stdout redactat complet, chiar daca e trunchiat
+		OutputHash:    out.StdoutHash,
+		ExecTimestamp: timestamp,
+		ExecHostname:  hostname,
+		ExecUser:      execAs.Name,
+		ExitCode:      out.ExitCode,
+	}
+	if out.stdoutTruncated() {
+		result.Output += truncationMarker(len(out.Stdout), out.StdoutSize)
+		result.OutputTruncated = true
+	}
+	if out.stderrTruncated() {
+		result.Stderr += truncationMarker(len(out.Stderr), out.StderrSize)
+	}
+
+	if err == nil && out.ExitCode == exitCommandNotFound && !isNativeCheck(check.CheckType) {
+		err = errCommandNotFound(strings.TrimSpace(out.Stderr))
+	}
+
+	if err != nil {
+		result.Status, result.ReasonCode = classifyError(ctx, err)
+		result.ErrorMessage = err.Error()
+		if result.ReasonCode == api.ReasonTimeout {
+			result.ErrorMessage = fmt.Sprintf("Timeout (%s)", timeout)
+		}
+	} else {
+		// Succes (exit code 0 sau gestionat)
+		if check.ExpectedResult != "" && !isNativeCheck(check.CheckType) {
+			if matchesExpected(out.Stdout, check) {
+				result.Status = api.StatusPass
+			} else if check.WarnResult != "" && matchesWarn(out.Stdout, check) {
+				result.Status, result.ReasonCode = api.StatusWarn, api.ReasonSoftThreshold
+			}
 		} else {
-			log.Printf("Sent %d results for run %s", len(results), runID)
+			// Fara asteptari, PASS daca exit code 0
+			if out.ExitCode == 0 {
+				result.Status = api.StatusPass
+			}
 		}
 	}
 
-	return nil
+	// Comanda executata fara izolare: backend-ul trebuie sa poata distinge rezultatul
+	if result.ReasonCode == "" && execErr == nil && ar.sandbox == nil && !isNativeCheck(check.CheckType) {
+		result.ReasonCode = api.ReasonUnsandboxed
+	}
+
+	// 6. Exceptii aprobate: rezultatul evaluat ramane in metadatele exceptiei
+	ar.applyWaiver(check, &result, time.Now())
+
+	// 7. Semnare rezultat
+	ar.signResult(check, &result)
+	return result
 }
 
-func (ar *AuditRunner) executeCheck(ctx context.Context, check api.PendingCheck) (string, int, error) {
-	// Verificare siguranta comanda (ultima linie de aparare)
-	cmdToCheck := check.Command
-	if check.CheckType == "SCRIPT" {
-		cmdToCheck = check.Script
+// unexecutedResult construieste rezultatul semnat al unei verificari care nu
+// a fost executata (semnatura invalida, tip nesuportat, neaplicabila)
+func (ar *AuditRunner) unexecutedResult(check api.PendingCheck, status, reason, message string) api.CheckResult {
+	hostname, _ := os.Hostname()
+	result := api.CheckResult{
+		AutomatedCheckID: check.AutomatedCheckID,
+		Status:           status,
+		ReasonCode:       reason,
+		ErrorMessage:     message,
+		OutputHash:       newCappedBuffer(0).Sum(), // fara executie, iesire goala
+		ExecTimestamp:    time.Now().Format(time.RFC3339),
+		ExecHostname:     hostname,
+		ExecUser:         agentUser().Name,
+	}
+	ar.signResult(check, &result)
+	return result
+}
+
+func (ar *AuditRunner) executeCheck(ctx context.Context, check api.PendingCheck, as *execUser, signed bool, snap *SystemSnapshot) (*checkOutput, error) {
+	stdout := newCappedBuffer(ar.maxOutput)
+	stderr := newCappedBuffer(ar.maxOutput)
+
+	// Verificari native (fara shell)
+	if isNativeCheck(check.CheckType) {
+		return executeNativeCheck(ctx, check, stdout, stderr, snap)
+	}
+
+	if ar.policyErr != nil {
+		return newCheckOutput(stdout, stderr, -1), blocked(api.ReasonBlockedByPolicy, fmt.Errorf("politica executie: %w", ar.policyErr))
 	}
-	if safe, reason := isCommandSafe(cmdToCheck); !safe {
-		log.Printf("[SECURITY] Comanda BLOCATA pe agent: %s - motiv: %s", cmdToCheck, reason)
-		return "", -1, fmt.Errorf("comanda blocata de agent: %s", reason)
+	if ar.sandboxErr != nil {
+		return newCheckOutput(stdout, stderr, -1), blocked(api.ReasonSandboxUnavailable, ar.sandboxErr)
 	}
 
-	var cmd *exec.Cmd
+	env, err := ar.checkEnv(check, as)
+	if err != nil {
+		return newCheckOutput(stdout, stderr, -1), err
+	}
 
+	var argv []string
 	if check.CheckType == "SCRIPT" {
-		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", check.Script)
+		// Script in fisier privat, rulat de interpretorul ales, argumente ca argv
+		script, err := ar.prepareScript(check, as, signed)
+		if err != nil {
+			return newCheckOutput(stdout, stderr, -1), err
+		}
+		defer script.remove()
+		argv = withUmask(script.argv...)
 	} else {
-		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", check.Command)
+		// Verificare allowlist pe AST-ul comenzii (ultima linie de aparare)
+		if err := ar.policy.Check(check.Command); err != nil {
+			log.Printf("[SECURITY] Comanda BLOCATA pe agent (%s): %v", check.CheckID, err)
+			return newCheckOutput(stdout, stderr, -1), blocked(api.ReasonBlockedByPolicy, fmt.Errorf("comanda blocata de politica: %w", err))
+		}
+		argv = withUmask("/bin/sh", "-c", check.Command)
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = env
+	cmd.Dir = "/"
+
+	if ar.sandbox != nil {
+		// Schimbarea utilizatorului se face in procesul ajutator, dupa montari
+		cleanup, err := ar.sandbox.Wrap(cmd, sandbox.Options{Credential: as.Credential})
+		defer cleanup()
+		if err != nil {
+			return newCheckOutput(stdout, stderr, -1), failedWith(api.ReasonSandboxUnavailable, fmt.Errorf("sandbox: %w", err))
+		}
+	} else if as.Credential != nil {
+		setCredential(cmd, as.Credential)
 	}
 
-	output, err := cmd.CombinedOutput()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if ar.trace != nil {
+		cmd.Stdout = io.MultiWriter(stdout, ar.trace.stdout)
+		cmd.Stderr = io.MultiWriter(stderr, ar.trace.stderr)
+	}
+	err = runProcessGroup(ctx, cmd, ar.killGrace)
 
 	exitCode := 0
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			exitCode = exitErr.ExitCode()
+			if ar.sandbox != nil && sandbox.IsHelperFailure(exitCode) {
+				err = failedWith(api.ReasonSandboxUnavailable, fmt.Errorf("sandbox: %s", strings.TrimSpace(stderr.String())))
+			}
 		} else {
 			exitCode = -1
 		}
 	}
 
-	return string(output), exitCode, err
+	return newCheckOutput(stdout, stderr, exitCode), err
 }
 
-// Blacklist minimal pe agent
-var dangerousPatterns = []*regexp.Regexp{
-	regexp.MustCompile(`\brm\s+(-[a-zA-Z]*[rf][a-zA-Z]*\s+)*\/`),
-	regexp.MustCompile(`\bdd\s+`),
-	regexp.MustCompile(`\bmkfs\b`),
-	regexp.MustCompile(`\bshutdown\b`),
-	regexp.MustCompile(`(^|[;&|]\s*)reboot(\s|$)`),
-	regexp.MustCompile(`\bpoweroff\b`),
-	regexp.MustCompile(`\bhalt\b`),
-	regexp.MustCompile(`\buseradd\b`),
-	regexp.MustCompile(`\buserdel\b`),
-	regexp.MustCompile(`\bpasswd\b`),
-	regexp.MustCompile(`\bsystemctl\s+(start|stop|restart|enable|disable)\b`),
-	regexp.MustCompile(`\biptables\s+-(F|X|D)\b`),
-	regexp.MustCompile(`\beval\s+`),
-	regexp.MustCompile(`\bexec\s+`),
+// isNativeCheck indica verificarile evaluate direct in Go
+func isNativeCheck(checkType string) bool {
+	switch strings.ToUpper(checkType) {
+	case "FILE_CHECK", "SYSCTL", "SERVICE_STATE", "PACKAGE", "PORT_LISTENING":
+		return true
+	}
+	return false
 }
 
-// isCommandSafe verifica daca comanda e sigura pentru executie
-func isCommandSafe(command string) (bool, string) {
-	cmd := strings.TrimSpace(command)
-	if cmd == "" {
-		return true, ""
+// executeNativeCheck ruleaza o verificare nativa; PASS -> exit 0, FAIL -> exit 1.
+// Evaluarea ruleaza separat, astfel incat timeout-ul verificarii elibereaza
+// worker-ul chiar daca un apel de sistem ramane blocat.
+func executeNativeCheck(ctx context.Context, check api.PendingCheck, stdout, stderr *cappedBuffer, snap *SystemSnapshot) (*checkOutput, error) {
+	type nativeResult struct {
+		pass   bool
+		result interface{}
+		err    error
 	}
-	for _, p := range dangerousPatterns {
-		if p.MatchString(cmd) {
-			return false, p.String()
+	done := make(chan nativeResult, 1)
+	go func() {
+		var r nativeResult
+		switch strings.ToUpper(check.CheckType) {
+		case "FILE_CHECK":
+			spec, err := ParseFileCheckSpec(check.Command)
+			if err != nil {
+				r.err = err
+				break
+			}
+			res := RunFileCheck(spec)
+			r.pass, r.result = res.Pass, res
+		case "SYSCTL", "SERVICE_STATE", "PACKAGE", "PORT_LISTENING":
+			res, err := runSystemCheck(ctx, strings.ToUpper(check.CheckType), check.Command, snap)
+			if err != nil {
+				r.err = err
+				break
+			}
+			r.pass, r.result = res.Pass, res
+		default:
+			r.err = fmt.Errorf("tip verificare necunoscut: %s", check.CheckType)
 		}
+		done <- r
+	}()
+
+	var r nativeResult
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		return newCheckOutput(stdout, stderr, -1), ctx.Err()
 	}
-	return true, ""
+	if r.err != nil {
+		return newCheckOutput(stdout, stderr, -1), r.err
+	}
+	if ctx.Err() != nil {
+		return newCheckOutput(stdout, stderr, -1), ctx.Err()
+	}
+
+	out, err := json.Marshal(r.result)
+	if err != nil {
+		return newCheckOutput(stdout, stderr, -1), err
+	}
+	stdout.Write(out)
+	if r.pass {
+		return newCheckOutput(stdout, stderr, 0), nil
+	}
+	return newCheckOutput(stdout, stderr, 1), nil
 }
 
 // matchesExpected verifica output conform criteriu
 func matchesExpected(output string, check api.PendingCheck) bool {
+	return matchesResult(output, check.ExpectedResult, check)
+}
+
+// matchesWarn compara iesirea cu pragul soft warnResult (acelasi parser si operator)
+func matchesWarn(output string, check api.PendingCheck) bool {
+	return matchesResult(output, check.WarnResult, check)
+}
+
+func matchesResult(output, expected string, check api.PendingCheck) bool {
 	// 1. Normalizare
 	output = normalizeOutput(output, check.Normalize)
-	expected := check.ExpectedResult
 
 	// 2. Parsare (basic)
-	if check.Parser == "FIRST_LINE" {
+	output = parseOutput(output, check.Parser)
+
+	// 3. Comparatie
+	return compareValues(output, expected, check.Comparison)
+}
+
+// parseOutput extrage valoarea comparata din iesirea normalizata
+func parseOutput(output, parser string) string {
+	if parser == "FIRST_LINE" {
 		lines := strings.Split(output, "\n")
 		if len(lines) > 0 {
 			output = lines[0]
 		}
 	}
+	return output
+}
 
-	// 3. Comparatie
-	comparison := strings.ToUpper(check.Comparison)
+// compareValues aplica operatorul de comparatie (implicit EQUALS)
+func compareValues(output, expected, comparison string) bool {
+	comparison = strings.ToUpper(comparison)
 	if comparison == "" {
 		comparison = "EQUALS"
 	}
@@ -265,13 +778,19 @@ func matchesExpected(output string, check api.PendingCheck) bool {
 func normalizeOutput(val string, rules []string) string {
 	val = strings.TrimSpace(val)
 	for _, rule := range rules {
-		switch strings.ToUpper(rule) {
-		case "LOWER":
-			val = strings.ToLower(val)
-		case "SQUASH_WS":
-			fields := strings.Fields(val)
-			val = strings.Join(fields, " ")
-		}
+		val = normalizeRule(val, rule)
+	}
+	return val
+}
+
+// normalizeRule aplica o regula Normalize; regulile necunoscute sunt ignorate
+func normalizeRule(val, rule string) string {
+	switch strings.ToUpper(rule) {
+	case "LOWER":
+		return strings.ToLower(val)
+	case "SQUASH_WS":
+		fields := strings.Fields(val)
+		return strings.Join(fields, " ")
 	}
 	return val
 }
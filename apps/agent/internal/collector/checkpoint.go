@@ -0,0 +1,185 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"bittrail-agent/internal/api"
+)
+
+// Stari verificare in checkpoint
+const (
+	checkpointPending = "pending" // primita, inca nepornita
+	checkpointRunning = "running" // in executie
+)
+
+// checkpointEntry e o verificare din jobul curent, fara rezultat inregistrat
+type checkpointEntry struct {
+	Check     api.PendingCheck `json:"check"`
+	State     string           `json:"state"`
+	StartedAt int64            `json:"startedAt,omitempty"` // secunde unix
+}
+
+// checkpoint persista progresul jobului curent: verificarile primite si cele
+// in executie. Rezultatele terminate trec in registru (ledger) si sunt scoase
+// de aici. La pornire, ce a ramas arata ce a intrerupt o repornire a agentului.
+type checkpoint struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]*checkpointEntry
+}
+
+// loadCheckpoint citeste checkpoint-ul lasat de procesul anterior
+func loadCheckpoint(path string) *checkpoint {
+	c := &checkpoint{path: path, entries: make(map[string]*checkpointEntry)}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &c.entries)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		c.entries = make(map[string]*checkpointEntry)
+		log.Printf("WARNING: Run checkpoint %s unreadable (%v). Interrupted checks cannot be reported.", path, err)
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*checkpointEntry)
+	}
+	return c
+}
+
+// begin inregistreaza verificarile jobului ca nepornite
+func (c *checkpoint) begin(checks []openedCheck) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, check := range checks {
+		if check.AuditRunID == adhocRunID {
+			continue
+		}
+		check.Payload, check.Signature = "", ""
+		c.entries[ledgerKey(check.AuditRunID, check.AutomatedCheckID)] = &checkpointEntry{
+			Check: check.PendingCheck,
+			State: checkpointPending,
+		}
+	}
+	return c.save()
+}
+
+// start marcheaza verificarea in executie
+func (c *checkpoint) start(check api.PendingCheck, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[ledgerKey(check.AuditRunID, check.AutomatedCheckID)]
+	if !ok {
+		return nil
+	}
+	entry.State, entry.StartedAt = checkpointRunning, now.Unix()
+	return c.save()
+}
+
+// done scoate verificarile cu rezultat inregistrat in registru
+func (c *checkpoint) done(auditRunID string, results []api.CheckResult) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, result := range results {
+		delete(c.entries, ledgerKey(auditRunID, result.AutomatedCheckID))
+	}
+	return c.save()
+}
+
+// clear goleste checkpoint-ul la sfarsitul jobului (verificarile unei rulari
+// anulate raman fara rezultat)
+func (c *checkpoint) clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) == 0 {
+		return nil
+	}
+	c.entries = make(map[string]*checkpointEntry)
+	return c.save()
+}
+
+// interrupted intoarce verificarile lasate de procesul anterior, in ordine
+func (c *checkpoint) interrupted() []checkpointEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]checkpointEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = *c.entries[key]
+	}
+	return entries
+}
+
+// save scrie atomic fisierul (temporar + rename), accesibil doar agentului
+func (c *checkpoint) save() error {
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("director stare: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("salvare checkpoint: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// recoverInterrupted trateaza jobul intrerupt de o repornire: verificarile
+// aflate in executie primesc ERROR/AGENT_RESTART, inregistrat in registru si
+// trimis la urmatorul ciclu (nu sunt re-executate: pot fi chiar cauza
+// repornirii); cele nepornite sunt reluate cand backend-ul le serveste din nou.
+func (ar *AuditRunner) recoverInterrupted() {
+	entries := ar.checkpoint.interrupted()
+	if len(entries) == 0 {
+		return
+	}
+
+	var opened []openedCheck
+	var results []api.CheckResult
+	resumed := 0
+	for _, entry := range entries {
+		check := entry.Check
+		if ar.ledger.has(check.AuditRunID, check.AutomatedCheckID) {
+			continue // rezultat inregistrat chiar inainte de oprire
+		}
+		if entry.State != checkpointRunning {
+			resumed++
+			continue
+		}
+		started := time.Unix(entry.StartedAt, 0).UTC().Format(time.RFC3339)
+		log.Printf("WARNING: Check %s of run %s was interrupted by an agent restart (started %s).", check.CheckID, check.AuditRunID, started)
+		opened = append(opened, openedCheck{PendingCheck: check})
+		results = append(results, ar.unexecutedResult(check, api.StatusError, api.ReasonAgentRestart,
+			fmt.Sprintf("agent repornit in timpul executiei (pornita la %s)", started)))
+	}
+	if resumed > 0 {
+		log.Printf("Resuming %d checks not started before the agent restart.", resumed)
+	}
+
+	for i, check := range opened {
+		if err := ar.ledger.record(check.AuditRunID, results[i:i+1], time.Now()); err != nil {
+			log.Printf("WARNING: Failed to record interrupted check %s: %v", check.CheckID, err)
+			return // checkpoint-ul ramane pentru urmatoarea pornire
+		}
+	}
+	ar.journalResults(opened, results)
+	if err := ar.checkpoint.clear(); err != nil {
+		log.Printf("WARNING: Failed to clear run checkpoint: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/crypto"
+)
+
+func TestRecoverInterrupted(t *testing.T) {
+	dir := t.TempDir()
+	checks := []openedCheck{
+		{PendingCheck: api.PendingCheck{AuditRunID: "run-1", AutomatedCheckID: "ac-1", CheckID: "c1", CheckType: "MANUAL", Payload: "eyJ9", Signature: "sig"}},
+		{PendingCheck: api.PendingCheck{AuditRunID: "run-1", AutomatedCheckID: "ac-2", CheckID: "c2", CheckType: "MANUAL"}},
+		{PendingCheck: api.PendingCheck{AuditRunID: "run-1", AutomatedCheckID: "ac-3", CheckID: "c3", CheckType: "MANUAL"}},
+		{PendingCheck: api.PendingCheck{AuditRunID: adhocRunID, AutomatedCheckID: "x", CheckID: "x"}},
+	}
+
+	// Procesul anterior: c1 in executie, c2 nepornita, c3 terminata si
+	// inregistrata chiar inainte de oprire
+	previous := loadCheckpoint(filepath.Join(dir, "checkpoint.json"))
+	if err := previous.begin(checks); err != nil {
+		t.Fatal(err)
+	}
+	previous.start(checks[0].PendingCheck, time.Now())
+	previous.start(checks[2].PendingCheck, time.Now())
+	l := newLedger(filepath.Join(dir, "ledger.json"), time.Hour, time.Now())
+	l.record("run-1", []api.CheckResult{{AutomatedCheckID: "ac-3", Status: api.StatusPass}}, time.Now())
+
+	key, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &fakeBackend{
+		checks: []api.PendingCheck{checks[0].PendingCheck, checks[1].PendingCheck, checks[2].PendingCheck},
+		stored: make(map[string]api.CheckResult),
+	}
+	ar, jrnl := progressTestRunner(t, backend)
+	ar.privateKey = key
+	ar.ledger = newLedger(filepath.Join(dir, "ledger.json"), time.Hour, time.Now())
+	ar.checkpoint = loadCheckpoint(filepath.Join(dir, "checkpoint.json"))
+	if n := len(ar.checkpoint.interrupted()); n != 3 {
+		t.Fatalf("checkpoint: %d intrari (payload-ul si ad-hoc nu se pastreaza)", n)
+	}
+	for _, entry := range ar.checkpoint.interrupted() {
+		if entry.Check.Payload != "" {
+			t.Errorf("payload pastrat in checkpoint: %+v", entry)
+		}
+	}
+
+	ar.recoverInterrupted()
+	if len(ar.checkpoint.interrupted()) != 0 {
+		t.Error("checkpoint nu a fost golit")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "checkpoint.json")); string(data) != "{}" {
+		t.Errorf("checkpoint pe disc: %s", data)
+	}
+	if head := jrnl.Head(); head.Seq != 1 {
+		t.Errorf("intrari jurnal: %d, asteptat 1 (c1)", head.Seq)
+	}
+
+	// c1 raportata ERROR/AGENT_RESTART fara re-executie, c2 reluata, c3 din registru
+	if err := ar.CheckAndRun(); err != nil {
+		t.Fatal(err)
+	}
+	interrupted := backend.stored["ac-1"]
+	if interrupted.Status != api.StatusError || interrupted.ReasonCode != api.ReasonAgentRestart || interrupted.Signature == "" {
+		t.Errorf("c1: %+v", interrupted)
+	}
+	if err := VerifyResultSignature(&key.PublicKey, "srv-1", "run-1", interrupted); err != nil {
+		t.Errorf("c1: %v", err)
+	}
+	if resumed := backend.stored["ac-2"]; resumed.Status != api.StatusSkipped {
+		t.Errorf("c2 nereluata: %+v", resumed)
+	}
+	if done := backend.stored["ac-3"]; done.Status != api.StatusPass {
+		t.Errorf("c3: %+v", done)
+	}
+	if head := jrnl.Head(); head.Seq != 2 {
+		t.Errorf("executii dupa reluare: %d, asteptat 2 (c1 raportata, c2 executata)", head.Seq)
+	}
+}
@@ -2,10 +2,14 @@ package collector
 
 import (
 	"bufio"
+	"context"
+	"fmt"
 	"net"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
+	"syscall"
 
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
@@ -163,15 +167,14 @@ func (ic *InventoryCollector) Collect() (*Inventory, error) {
 	}
 
 	// Porturi deschise
-	conns, err := psnet.Connections("inet")
-	if err == nil {
+	if listeners, err := getListeningSockets(); err == nil {
 		seen := make(map[uint32]bool)
-		for _, conn := range conns {
-			if conn.Status == "LISTEN" && !seen[conn.Laddr.Port] {
-				seen[conn.Laddr.Port] = true
+		for _, l := range listeners {
+			if l.Protocol == "tcp" && !seen[l.Port] {
+				seen[l.Port] = true
 				inv.Ports = append(inv.Ports, map[string]interface{}{
-					"port":    conn.Laddr.Port,
-					"address": conn.Laddr.IP,
+					"port":    l.Port,
+					"address": l.Address,
 					"type":    "tcp",
 				})
 			}
@@ -190,6 +193,49 @@ func (ic *InventoryCollector) Collect() (*Inventory, error) {
 	return inv, nil
 }
 
+// hostFacts intoarce faptele gazdei din inventar, folosite in variabilele
+// de mediu ale verificarilor ({{hostname}}, {{platform}}, ...)
+func hostFacts() map[string]string {
+	facts := make(map[string]string)
+	hostInfo, err := host.Info()
+	if err != nil {
+		return facts
+	}
+	facts["hostname"] = hostInfo.Hostname
+	facts["os"] = hostInfo.OS
+	facts["platform"] = hostInfo.Platform
+	facts["platformFamily"] = hostInfo.PlatformFamily
+	facts["platformVersion"] = hostInfo.PlatformVersion
+	facts["kernelVersion"] = hostInfo.KernelVersion
+	facts["kernelArch"] = hostInfo.KernelArch
+	return facts
+}
+
+// ListeningSocket descrie un socket in ascultare (TCP LISTEN sau UDP legat)
+type ListeningSocket struct {
+	Protocol string
+	Address  string
+	Port     uint32
+}
+
+func getListeningSockets() ([]ListeningSocket, error) {
+	conns, err := psnet.Connections("inet")
+	if err != nil {
+		return nil, err
+	}
+
+	var sockets []ListeningSocket
+	for _, conn := range conns {
+		switch {
+		case conn.Type == syscall.SOCK_STREAM && conn.Status == "LISTEN":
+			sockets = append(sockets, ListeningSocket{Protocol: "tcp", Address: conn.Laddr.IP, Port: conn.Laddr.Port})
+		case conn.Type == syscall.SOCK_DGRAM && conn.Raddr.Port == 0:
+			sockets = append(sockets, ListeningSocket{Protocol: "udp", Address: conn.Laddr.IP, Port: conn.Laddr.Port})
+		}
+	}
+	return sockets, nil
+}
+
 func getSystemUsers() []string {
 	var users []string
 	file, err := os.Open("/etc/passwd")
@@ -209,34 +255,84 @@ func getSystemUsers() []string {
 	return users
 }
 
+// InstalledPackage e un pachet instalat; un nume poate avea mai multe
+// versiuni instalate simultan (rpm: kernel, gpg-pubkey, multilib)
+type InstalledPackage struct {
+	Name     string
+	Versions []string // versiunile complete (epoch inclus), crescator, fara duplicate
+}
+
+// Newest intoarce cea mai noua versiune instalata
+func (p InstalledPackage) Newest() string {
+	return p.Versions[len(p.Versions)-1]
+}
+
+// getInstalledPackages intoarce numele pachetelor instalate, pentru inventar
 func getInstalledPackages() []string {
-	var packages []string
+	packages := []string{}
+	_, installed, err := queryPackages(context.Background())
+	if err != nil {
+		return packages
+	}
+	for name := range installed {
+		packages = append(packages, name)
+	}
+	sort.Strings(packages)
+	return packages
+}
 
-	// Incearca dpkg (Debian/Ubuntu)
-	cmd := exec.Command("dpkg-query", "-W", "-f=${Package}\n")
+// queryPackages intoarce managerul de pachete ("dpkg"/"rpm") si pachetele
+// instalate; sursa unica pentru inventar si verificarile PACKAGE
+func queryPackages(ctx context.Context) (string, map[string]InstalledPackage, error) {
+	// Incearca dpkg (Debian/Ubuntu); doar pachetele in starea "ii"
+	cmd := exec.CommandContext(ctx, "dpkg-query", "-W", "-f=${Package}\t${Version}\t${db:Status-Abbrev}\n")
+	cmd.Env = nativeEnv()
 	output, err := cmd.Output()
 	if err == nil {
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-		for _, line := range lines {
-			if line != "" {
-				packages = append(packages, line)
-			}
-		}
-		return packages
+		return "dpkg", parsePackageList("dpkg", string(output)), nil
 	}
 
 	// Incearca rpm (RHEL/CentOS)
-	cmd = exec.Command("rpm", "-qa", "--qf", "%{NAME}\n")
+	cmd = exec.CommandContext(ctx, "rpm", "-qa", "--qf", "%{NAME}\t%{EPOCHNUM}:%{VERSION}-%{RELEASE}\n")
+	cmd.Env = nativeEnv()
 	output, err = cmd.Output()
 	if err == nil {
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-		for _, line := range lines {
-			if line != "" {
-				packages = append(packages, line)
-			}
+		return "rpm", parsePackageList("rpm", string(output)), nil
+	}
+
+	return "", make(map[string]InstalledPackage), fmt.Errorf("niciun manager de pachete suportat (dpkg/rpm)")
+}
+
+// parsePackageList decodeaza iesirea dpkg-query/rpm (nume, versiune si, la
+// dpkg, starea) si pastreaza toate versiunile instalate ale fiecarui nume,
+// ordonate, independent de ordinea liniilor
+func parsePackageList(manager, output string) map[string]InstalledPackage {
+	packages := make(map[string]InstalledPackage)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 || (manager == "dpkg" && (len(parts) < 3 || !strings.HasPrefix(parts[2], "ii"))) {
+			continue
 		}
+		pkg := packages[parts[0]]
+		pkg.Name = parts[0]
+		pkg.Versions = append(pkg.Versions, parts[1])
+		packages[parts[0]] = pkg
 	}
 
+	for name, pkg := range packages {
+		sort.SliceStable(pkg.Versions, func(i, j int) bool {
+			return CompareVersions(manager, pkg.Versions[i], pkg.Versions[j]) < 0
+		})
+		// Multilib: aceeasi versiune pe mai multe arhitecturi
+		versions := pkg.Versions[:1]
+		for _, v := range pkg.Versions[1:] {
+			if v != versions[len(versions)-1] {
+				versions = append(versions, v)
+			}
+		}
+		pkg.Versions = versions
+		packages[name] = pkg
+	}
 	return packages
 }
 
@@ -0,0 +1,315 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/crypto"
+	"bittrail-agent/internal/schedule"
+)
+
+// continuousRunPrefix: verificarile evaluate continuu au AuditRunID
+// "continuous:<assignmentId>"; semnatura rezultatelor e legata de el
+const continuousRunPrefix = "continuous:"
+
+// maxPendingDrift e numarul de rapoarte de drift pastrate cat timp
+// backend-ul e indisponibil; peste el, cele mai vechi sunt pierdute
+const maxPendingDrift = 100
+
+// ContinuousRunID intoarce rularea sub care sunt semnate rezultatele atribuirii
+func ContinuousRunID(assignmentID string) string {
+	return continuousRunPrefix + assignmentID
+}
+
+// continuousEntry e o atribuire pastrata local: setul semnat, statusurile
+// ultimei evaluari (baza pentru drift) si rapoartele inca netrimise
+type continuousEntry struct {
+	Assignment      api.Assignment    `json:"assignment"`
+	IssuedAt        int64             `json:"issuedAt"`
+	Statuses        map[string]string `json:"statuses"`        // automatedCheckId -> status
+	LastEvaluatedAt int64             `json:"lastEvaluatedAt"` // secunde unix; 0 = de evaluat imediat
+	Pending         []api.DriftReport `json:"pending,omitempty"`
+}
+
+// continuousCache persista atribuirile: evaluarea continua si fara backend,
+// iar o repornire nu retrimite ca drift statusurile deja raportate
+type continuousCache struct {
+	path    string
+	entries map[string]*continuousEntry
+}
+
+func loadContinuousCache(path string) *continuousCache {
+	c := &continuousCache{path: path, entries: make(map[string]*continuousEntry)}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &c.entries)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		// Atribuirile sunt preluate din nou de la backend, cu o noua evaluare de baza
+		c.entries = make(map[string]*continuousEntry)
+		log.Printf("WARNING: Continuous assignment cache %s unreadable (%v). Assignments will be fetched again.", path, err)
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*continuousEntry)
+	}
+	return c
+}
+
+// save scrie atomic fisierul (temporar + rename), accesibil doar agentului
+func (c *continuousCache) save() error {
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("director stare: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("salvare atribuiri: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// RunContinuous sincronizeaza atribuirile cu backend-ul, evalueaza setele
+// ajunse la termen dupa programul lor cron si trimite schimbarile de status
+// ca evenimente de drift. Fara backend, evalueaza setele din cache, iar
+// rapoartele asteapta urmatoarea trimitere reusita. Nu ruleaza in paralel
+// cu un job de audit.
+func (ar *AuditRunner) RunContinuous() error {
+	if !ar.running.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer ar.running.Store(false)
+
+	now := time.Now()
+	ar.syncAssignments(now)
+
+	ids := make([]string, 0, len(ar.continuous.entries))
+	for id := range ar.continuous.entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		entry := ar.continuous.entries[id]
+		if ar.evaluateAssignment(entry, now) {
+			// Salvat inainte de trimitere: o repornire nu pierde raportul
+			if err := ar.continuous.save(); err != nil {
+				log.Printf("WARNING: Failed to save continuous assignments: %v", err)
+			}
+		}
+		ar.sendDrift(id, entry)
+	}
+	return ar.continuous.save()
+}
+
+// syncAssignments actualizeaza cache-ul cu atribuirile primite. Un set
+// refuzat (semnatura, server) sau mai vechi decat cel pastrat nu inlocuieste
+// cache-ul; atribuirile pe care backend-ul nu le mai serveste sunt sterse.
+func (ar *AuditRunner) syncAssignments(now time.Time) {
+	assignments, err := ar.client.GetAssignments()
+	if err != nil {
+		log.Printf("Failed to fetch continuous assignments: %v. Using cached sets.", err)
+		return
+	}
+
+	received := make(map[string]bool, len(assignments))
+	for _, a := range assignments {
+		received[a.AssignmentID] = true
+		set, _, err := ar.openAssignment(a, now)
+		if err != nil {
+			log.Printf("SECURITY ALERT: Continuous assignment %s refused: %v", a.AssignmentID, err)
+			continue
+		}
+
+		entry, ok := ar.continuous.entries[a.AssignmentID]
+		switch {
+		case !ok:
+			entry = &continuousEntry{Statuses: make(map[string]string)}
+			ar.continuous.entries[a.AssignmentID] = entry
+			log.Printf("Continuous assignment %s received (%s, schedule %q, %d checks)", a.AssignmentID, set.Template, set.Schedule, len(set.Checks))
+		case set.IssuedAt < entry.IssuedAt:
+			log.Printf("SECURITY ALERT: Continuous assignment %s refused: set issued at %d is older than the cached one (%d)", a.AssignmentID, set.IssuedAt, entry.IssuedAt)
+			continue
+		case set.IssuedAt == entry.IssuedAt:
+			continue
+		default:
+			log.Printf("Continuous assignment %s updated (%s, schedule %q, %d checks)", a.AssignmentID, set.Template, set.Schedule, len(set.Checks))
+		}
+		entry.Assignment, entry.IssuedAt = a, set.IssuedAt
+		entry.LastEvaluatedAt = 0 // set nou: evaluat imediat, nu la urmatorul termen
+	}
+
+	for id := range ar.continuous.entries {
+		if !received[id] {
+			log.Printf("Continuous assignment %s removed by backend", id)
+			delete(ar.continuous.entries, id)
+		}
+	}
+}
+
+// openAssignment verifica semnatura setului (cheia backend-ului, ca la
+// openCheck) si intoarce setul decodat; signed e fals fara cheie backend
+func (ar *AuditRunner) openAssignment(a api.Assignment, now time.Time) (set api.AssignmentSet, signed bool, err error) {
+	if ar.backendKeyErr != nil {
+		return set, false, ar.backendKeyErr
+	}
+	if a.Payload == "" {
+		return set, false, errors.New("atribuire fara payload")
+	}
+	payload, err := base64.StdEncoding.DecodeString(a.Payload)
+	if err != nil {
+		return set, false, fmt.Errorf("payload invalid: %w", err)
+	}
+	if len(ar.backendKey) > 0 {
+		if a.Signature == "" {
+			return set, false, errors.New("atribuire nesemnata")
+		}
+		if err := crypto.VerifySignature(ar.backendKey, payload, a.Signature); err != nil {
+			return set, false, fmt.Errorf("semnatura invalida: %w", err)
+		}
+		signed = true
+	}
+
+	if err := json.NewDecoder(bytes.NewReader(payload)).Decode(&set); err != nil {
+		return set, false, fmt.Errorf("payload invalid: %w", err)
+	}
+	if set.AssignmentID != a.AssignmentID {
+		return set, false, fmt.Errorf("payload emis pentru atribuirea %q", set.AssignmentID)
+	}
+	if set.ServerID != ar.serverID {
+		return set, false, fmt.Errorf("payload emis pentru serverul %q", set.ServerID)
+	}
+	if set.IssuedAt == 0 {
+		return set, false, errors.New("payload fara issuedAt")
+	}
+	if set.IssuedAt > now.Unix()+int64(ar.clockSkew/time.Second) {
+		return set, false, fmt.Errorf("payload emis in viitor (%s)", time.Unix(set.IssuedAt, 0).UTC().Format(time.RFC3339))
+	}
+	return set, signed, nil
+}
+
+// continuousDue intoarce adevarat daca setul trebuie evaluat acum: niciodata
+// evaluat sau cu un termen cron trecut de la ultima evaluare (termenele
+// ratate cat agentul a fost oprit produc o singura evaluare)
+func continuousDue(entry *continuousEntry, sched *schedule.Schedule, now time.Time) bool {
+	if entry.LastEvaluatedAt == 0 {
+		return true
+	}
+	next := sched.Next(time.Unix(entry.LastEvaluatedAt, 0))
+	return !next.IsZero() && !next.After(now)
+}
+
+// evaluateAssignment executa setul daca e la termen si adauga raportul de
+// drift la coada de trimis; intoarce adevarat daca setul a fost evaluat
+func (ar *AuditRunner) evaluateAssignment(entry *continuousEntry, now time.Time) bool {
+	id := entry.Assignment.AssignmentID
+	set, signed, err := ar.openAssignment(entry.Assignment, now)
+	if err != nil {
+		log.Printf("SECURITY ALERT: Cached continuous assignment %s refused: %v", id, err)
+		return false
+	}
+	sched, err := schedule.Parse(set.Schedule)
+	if err != nil {
+		log.Printf("WARNING: Continuous assignment %s has an invalid schedule: %v", id, err)
+		return false
+	}
+	if !continuousDue(entry, sched, now) {
+		return false
+	}
+
+	runID := ContinuousRunID(id)
+	opened := make([]openedCheck, len(set.Checks))
+	for i, check := range set.Checks {
+		check.AuditRunID = runID
+		check.ServerID = ar.serverID
+		// Jurnalul leaga fiecare rezultat de setul semnat din care provine
+		check.Payload, check.Signature = entry.Assignment.Payload, entry.Assignment.Signature
+		opened[i] = openedCheck{PendingCheck: check, signed: signed}
+	}
+
+	byID := make(map[string]api.CheckResult, len(opened))
+	var mu sync.Mutex
+	runs := ar.newRunStates(opened)
+	ar.runPool(opened, runs, func(check openedCheck, result api.CheckResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		byID[check.AutomatedCheckID] = result
+	})
+	for _, run := range runs {
+		run.cancel(nil)
+	}
+
+	results := make([]api.CheckResult, len(opened))
+	for i, check := range opened {
+		results[i] = byID[check.AutomatedCheckID]
+	}
+	ar.journalResults(opened, results)
+
+	report := continuousDrift(entry, results)
+	report.IssuedAt = set.IssuedAt
+	report.EvaluatedAt = now.UTC().Format(time.RFC3339)
+	entry.LastEvaluatedAt = now.Unix()
+	entry.Pending = append(entry.Pending, report)
+	if dropped := len(entry.Pending) - maxPendingDrift; dropped > 0 {
+		log.Printf("WARNING: Dropping %d unsent drift reports for assignment %s", dropped, id)
+		entry.Pending = entry.Pending[dropped:]
+	}
+	log.Printf("Continuous assignment %s evaluated: %d checks, %d changed", id, len(results), len(report.Events))
+	return true
+}
+
+// continuousDrift compara rezultatele cu statusurile ultimei evaluari si
+// le retine pe cele noi; prima evaluare raporteaza toate verificarile
+func continuousDrift(entry *continuousEntry, results []api.CheckResult) api.DriftReport {
+	report := api.DriftReport{Evaluated: len(results), Events: []api.DriftEvent{}}
+	statuses := make(map[string]string, len(results))
+	for _, result := range results {
+		statuses[result.AutomatedCheckID] = result.Status
+		previous, seen := entry.Statuses[result.AutomatedCheckID]
+		if seen && previous == result.Status {
+			continue
+		}
+		report.Events = append(report.Events, api.DriftEvent{PreviousStatus: previous, Result: result})
+	}
+	entry.Statuses = statuses
+	return report
+}
+
+// sendDrift trimite in ordine rapoartele netrimise ale atribuirii. Un raport
+// respins definitiv e abandonat; la o eroare de retea trimiterea se opreste,
+// iar rapoartele fara schimbari nu mai sunt pastrate (doar marcau evaluarea).
+func (ar *AuditRunner) sendDrift(id string, entry *continuousEntry) {
+	for len(entry.Pending) > 0 {
+		report := entry.Pending[0]
+		err := ar.client.SendDrift(id, report)
+		var httpErr *api.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Rejected() {
+			log.Printf("WARNING: Backend rejected drift report for assignment %s (%v). Dropping it.", id, err)
+		} else if err != nil {
+			log.Printf("Failed to send drift report for assignment %s: %v. Will retry.", id, err)
+			break
+		} else if len(report.Events) > 0 {
+			log.Printf("Sent %d drift events for assignment %s", len(report.Events), id)
+		}
+		entry.Pending = entry.Pending[1:]
+	}
+
+	kept := entry.Pending[:0]
+	for _, report := range entry.Pending {
+		if len(report.Events) > 0 {
+			kept = append(kept, report)
+		}
+	}
+	entry.Pending = kept
+}
@@ -0,0 +1,234 @@
+package collector
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/crypto"
+	"bittrail-agent/internal/journal"
+	"bittrail-agent/internal/schedule"
+)
+
+// fakeAssignments serveste atribuirile si inregistreaza rapoartele de drift;
+// cat timp failDrift e adevarat, trimiterea esueaza
+type fakeAssignments struct {
+	mu          sync.Mutex
+	assignments []api.Assignment
+	reports     []api.DriftReport
+	failDrift   bool
+}
+
+func (b *fakeAssignments) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if r.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(b.assignments)
+		return
+	}
+	if b.failDrift {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	var report api.DriftReport
+	json.NewDecoder(r.Body).Decode(&report)
+	b.reports = append(b.reports, report)
+}
+
+func signAssignment(t *testing.T, key *rsa.PrivateKey, set api.AssignmentSet) api.Assignment {
+	t.Helper()
+	payload, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := crypto.SignData(key, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api.Assignment{AssignmentID: set.AssignmentID, Payload: base64.StdEncoding.EncodeToString(payload), Signature: sig}
+}
+
+func TestRunContinuous(t *testing.T) {
+	ar, key := signedTestRunner(t)
+	backend := &fakeAssignments{}
+	srv := httptest.NewServer(backend)
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	jrnl, err := journal.Open(filepath.Join(dir, "journal.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar.client = api.NewClient(srv.URL, "srv-1", "token", nil)
+	ar.journal = jrnl
+	ar.checkpoint = loadCheckpoint(filepath.Join(dir, "checkpoint.json"))
+	ar.continuous = loadContinuousCache(filepath.Join(dir, "continuous.json"))
+	ar.workers, ar.runConcurrency = 1, 1
+
+	issued := time.Now().Add(-time.Minute).Unix()
+	set := api.AssignmentSet{
+		AssignmentID: "as-1",
+		ServerID:     "srv-1",
+		Template:     "baseline 1.0",
+		Schedule:     "@daily",
+		IssuedAt:     issued,
+		Checks: []api.PendingCheck{
+			{AutomatedCheckID: "ac-1", CheckID: "c1", CheckType: "MANUAL"},
+			{AutomatedCheckID: "ac-2", CheckID: "c2", CheckType: "MANUAL"},
+		},
+	}
+	backend.assignments = []api.Assignment{signAssignment(t, key, set)}
+
+	// Prima evaluare: toate verificarile, fara status anterior
+	if err := ar.RunContinuous(); err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.reports) != 1 || len(backend.reports[0].Events) != 2 || backend.reports[0].Events[0].PreviousStatus != "" {
+		t.Fatalf("raport de baza: %+v", backend.reports)
+	}
+	if got := backend.reports[0].Events[0].Result; got.Status != api.StatusSkipped || got.CheckID != "c1" {
+		t.Errorf("rezultat: %+v", got)
+	}
+	if head := jrnl.Head(); head.Seq != 2 {
+		t.Errorf("jurnalizate: %d", head.Seq)
+	}
+
+	// Inainte de termen: nicio evaluare
+	if err := ar.RunContinuous(); err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.reports) != 1 {
+		t.Errorf("evaluat inainte de termen: %d rapoarte", len(backend.reports))
+	}
+
+	// Termen trecut, backend indisponibil: raportul fara schimbari nu e pastrat
+	ar.continuous.entries["as-1"].LastEvaluatedAt = time.Now().Add(-48 * time.Hour).Unix()
+	backend.failDrift = true
+	if err := ar.RunContinuous(); err != nil {
+		t.Fatal(err)
+	}
+	if entry := ar.continuous.entries["as-1"]; len(entry.Pending) != 0 || entry.LastEvaluatedAt < issued {
+		t.Errorf("dupa evaluare offline: %+v", entry)
+	}
+
+	// Un set mai vechi decat cel din cache e refuzat
+	older := set
+	older.IssuedAt = issued - 60
+	older.Schedule = "* * * * *"
+	backend.assignments = []api.Assignment{signAssignment(t, key, older)}
+	if err := ar.RunContinuous(); err != nil {
+		t.Fatal(err)
+	}
+	if entry := ar.continuous.entries["as-1"]; entry.IssuedAt != issued {
+		t.Errorf("set mai vechi acceptat: %d", entry.IssuedAt)
+	}
+
+	// Cache-ul supravietuieste repornirii
+	reloaded := loadContinuousCache(filepath.Join(dir, "continuous.json"))
+	if entry := reloaded.entries["as-1"]; entry == nil || entry.Statuses["ac-2"] != api.StatusSkipped {
+		t.Errorf("cache dupa repornire: %+v", reloaded.entries)
+	}
+
+	// Atribuire stearsa din backend
+	backend.assignments = nil
+	if err := ar.RunContinuous(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ar.continuous.entries) != 0 {
+		t.Errorf("atribuire stearsa pastrata: %+v", ar.continuous.entries)
+	}
+}
+
+func TestOpenAssignment(t *testing.T) {
+	ar, key := signedTestRunner(t)
+	now := time.Now()
+	valid := api.AssignmentSet{AssignmentID: "as-1", ServerID: "srv-1", Schedule: "@hourly", IssuedAt: now.Unix()}
+
+	a := signAssignment(t, key, valid)
+	if _, signed, err := ar.openAssignment(a, now); err != nil || !signed {
+		t.Fatalf("set valid: %v (semnat %t)", err, signed)
+	}
+
+	other := valid
+	other.ServerID = "srv-2"
+	future := valid
+	future.IssuedAt = now.Add(time.Hour).Unix()
+	tampered := signAssignment(t, key, valid)
+	tampered.Payload = signAssignment(t, key, other).Payload
+	renamed := signAssignment(t, key, valid)
+	renamed.AssignmentID = "as-2"
+
+	for name, a := range map[string]api.Assignment{
+		"alt server":       signAssignment(t, key, other),
+		"emis in viitor":   signAssignment(t, key, future),
+		"payload schimbat": tampered,
+		"alta atribuire":   renamed,
+		"nesemnat":         {AssignmentID: "as-1", Payload: a.Payload},
+	} {
+		if _, _, err := ar.openAssignment(a, now); err == nil {
+			t.Errorf("%s: acceptat", name)
+		}
+	}
+
+	// Fara cheie backend: setul e decodat si executat nesemnat
+	ar.backendKey = nil
+	if _, signed, err := ar.openAssignment(a, now); err != nil || signed {
+		t.Errorf("fara cheie: %v (semnat %t)", err, signed)
+	}
+}
+
+func TestContinuousDue(t *testing.T) {
+	sched, err := schedule.Parse("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 18, 10, 30, 0, 0, time.Local)
+	tests := []struct {
+		name string
+		last time.Time
+		want bool
+	}{
+		{"neevaluat", time.Time{}, true},
+		{"in aceeasi ora", now.Add(-20 * time.Minute), false},
+		{"ora trecuta", now.Add(-40 * time.Minute), true},
+		{"termene ratate", now.Add(-72 * time.Hour), true},
+	}
+	for _, tt := range tests {
+		entry := &continuousEntry{}
+		if !tt.last.IsZero() {
+			entry.LastEvaluatedAt = tt.last.Unix()
+		}
+		if got := continuousDue(entry, sched, now); got != tt.want {
+			t.Errorf("%s: %t, asteptat %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestContinuousDrift(t *testing.T) {
+	entry := &continuousEntry{Statuses: map[string]string{"a": api.StatusPass, "b": api.StatusPass, "gone": api.StatusFail}}
+	report := continuousDrift(entry, []api.CheckResult{
+		{AutomatedCheckID: "a", Status: api.StatusPass},
+		{AutomatedCheckID: "b", Status: api.StatusFail},
+		{AutomatedCheckID: "c", Status: api.StatusWarn},
+	})
+
+	if report.Evaluated != 3 || len(report.Events) != 2 {
+		t.Fatalf("raport: %+v", report)
+	}
+	if e := report.Events[0]; e.Result.AutomatedCheckID != "b" || e.PreviousStatus != api.StatusPass {
+		t.Errorf("schimbare b: %+v", e)
+	}
+	if e := report.Events[1]; e.Result.AutomatedCheckID != "c" || e.PreviousStatus != "" {
+		t.Errorf("verificare noua c: %+v", e)
+	}
+	if _, ok := entry.Statuses["gone"]; ok || entry.Statuses["b"] != api.StatusFail {
+		t.Errorf("statusuri actualizate: %v", entry.Statuses)
+	}
+}
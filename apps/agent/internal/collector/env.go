@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"bittrail-agent/internal/api"
+)
+
+// PATH fix pentru verificari, independent de systemd sau sesiunea interactiva
+const checkPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// Variabile ce nu pot fi setate de verificari sau preluate din mediul agentului
+// (inclusiv hook-urile interpretoarelor: PERL5OPT, PYTHONPATH, RUBYOPT, ...)
+var reservedEnvPrefixes = []string{"LD_", "BASH_FUNC_", "DYLD_", "PERL5", "PERLLIB", "PYTHON", "RUBY"}
+
+var reservedEnv = map[string]bool{
+	"PATH": true, "LC_ALL": true, "LANG": true, "LANGUAGE": true, "TZ": true,
+	"HOME": true, "USER": true, "LOGNAME": true, "SHELL": true,
+	"IFS": true, "ENV": true, "BASH_ENV": true, "SHELLOPTS": true, "BASHOPTS": true,
+	"PS4": true, "PROMPT_COMMAND": true, "GCONV_PATH": true, "LOCPATH": true,
+}
+
+var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Prefixul variabilelor pe care template-urile le pot seta fara declarare in configurare
+const templateEnvPrefix = "CHECK_"
+
+// Placeholder pentru faptele gazdei: {{hostname}}
+var factRe = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_]*)\s*\}\}`)
+
+// fixedEnv intoarce variabilele fixe ale oricarui proces pornit pentru o
+// verificare (LC_ALL=C, TZ=UTC, PATH fix)
+func fixedEnv(user string) map[string]string {
+	return map[string]string{
+		"PATH":    checkPath,
+		"LC_ALL":  "C",
+		"LANG":    "C",
+		"TZ":      "UTC",
+		"HOME":    "/",
+		"USER":    user,
+		"LOGNAME": user,
+	}
+}
+
+// nativeEnv e mediul utilitarelor apelate de verificarile native
+// (systemctl, dpkg-query, rpm), fara nimic din mediul agentului
+func nativeEnv() []string {
+	return envList(fixedEnv(agentUser().Name))
+}
+
+// checkEnv construieste mediul complet al unei verificari: variabile fixe,
+// variabilele permise din mediul agentului si variabilele verificarii, cu
+// faptele gazdei inlocuite. Template-urile pot seta doar variabile CHECK_*
+// sau numele declarate de operator in check_env_names.
+func (ar *AuditRunner) checkEnv(check api.PendingCheck, as *execUser) ([]string, error) {
+	env := fixedEnv(as.Name)
+
+	for _, name := range ar.envAllowlist {
+		if isReservedEnv(name) {
+			continue
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			env[name] = value
+		}
+	}
+
+	for name, value := range check.Env {
+		if !envNameRe.MatchString(name) {
+			return nil, invalidSpec("variabila de mediu invalida: %q", name)
+		}
+		if isReservedEnv(name) {
+			return nil, blocked(api.ReasonBlockedByPolicy, fmt.Errorf("variabila de mediu rezervata: %s", name))
+		}
+		if !strings.HasPrefix(name, templateEnvPrefix) && !ar.envNames[name] {
+			return nil, blocked(api.ReasonBlockedByPolicy, fmt.Errorf("variabila de mediu %s nepermisa (doar %s* sau check_env_names)", name, templateEnvPrefix))
+		}
+		expanded, err := expandFacts(value, ar.facts)
+		if err != nil {
+			return nil, invalidSpec("variabila %s: %w", name, err)
+		}
+		env[name] = expanded
+	}
+
+	return envList(env), nil
+}
+
+func envList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for name, value := range env {
+		list = append(list, name+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func isReservedEnv(name string) bool {
+	upper := strings.ToUpper(name)
+	if reservedEnv[upper] {
+		return true
+	}
+	for _, prefix := range reservedEnvPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandFacts inlocuieste {{fapt}} cu valoarea din inventar; un fapt
+// necunoscut e eroare, ca sa nu ruleze verificarea cu o valoare goala
+func expandFacts(value string, facts map[string]string) (string, error) {
+	var missing string
+	out := factRe.ReplaceAllStringFunc(value, func(m string) string {
+		name := factRe.FindStringSubmatch(m)[1]
+		fact, ok := facts[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return fact
+	})
+	if missing != "" {
+		return "", fmt.Errorf("fapt gazda necunoscut: %s", missing)
+	}
+	return out, nil
+}
+
+// withUmask ruleaza argv printr-un shell minim care seteaza umask 077
+// (umask-ul nu poate fi setat prin SysProcAttr)
+func withUmask(argv ...string) []string {
+	return append([]string{"/bin/sh", "-c", `umask 077 && exec "$@"`, "sh"}, argv...)
+}
@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"bittrail-agent/internal/api"
+)
+
+func TestCheckEnv(t *testing.T) {
+	ar := &AuditRunner{
+		envNames: map[string]bool{"SSHD_CONFIG": true},
+		facts:    map[string]string{"hostname": "web01"},
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		ok   bool
+	}{
+		{"prefix CHECK_", map[string]string{"CHECK_HOST": "{{ hostname }}"}, true},
+		{"nume declarat", map[string]string{"SSHD_CONFIG": "/etc/ssh/sshd_config"}, true},
+		{"nedeclarat", map[string]string{"GIT_CONFIG_COUNT": "1"}, false},
+		{"openssl", map[string]string{"OPENSSL_CONF": "/tmp/x"}, false},
+		{"perl", map[string]string{"PERL5OPT": "-Mx"}, false},
+		{"python", map[string]string{"PYTHONPATH": "/tmp"}, false},
+		{"ruby", map[string]string{"RUBYOPT": "-rx"}, false},
+		{"loader", map[string]string{"LD_PRELOAD": "/tmp/x.so"}, false},
+		{"path", map[string]string{"PATH": "/tmp"}, false},
+		{"nume invalid", map[string]string{"CHECK-X": "1"}, false},
+		{"fapt necunoscut", map[string]string{"CHECK_X": "{{nope}}"}, false},
+	}
+	for _, tt := range tests {
+		env, err := ar.checkEnv(api.PendingCheck{Env: tt.env}, &execUser{Name: "nobody"})
+		if tt.ok != (err == nil) {
+			t.Errorf("%s: eroare %v", tt.name, err)
+		}
+		if err == nil && tt.name == "prefix CHECK_" && !contains(env, "CHECK_HOST=web01") {
+			t.Errorf("fapt neinlocuit: %q", env)
+		}
+	}
+
+	env, _ := ar.checkEnv(api.PendingCheck{}, &execUser{Name: "nobody"})
+	for _, want := range []string{"LC_ALL=C", "TZ=UTC", "PATH=" + checkPath, "USER=nobody", "HOME=/"} {
+		if !contains(env, want) {
+			t.Errorf("lipseste %s din %q", want, env)
+		}
+	}
+}
+
+func TestCheckEnvAgentAllowlist(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://proxy:3128")
+	t.Setenv("LD_PRELOAD", "/tmp/x.so")
+	t.Setenv("PYTHONPATH", "/tmp")
+	t.Setenv("SECRET_TOKEN", "s3cret")
+
+	ar := &AuditRunner{envAllowlist: []string{"HTTP_PROXY", "LD_PRELOAD", "PYTHONPATH", "PATH"}}
+	env, err := ar.checkEnv(api.PendingCheck{}, &execUser{Name: "nobody"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(env, "HTTP_PROXY=http://proxy:3128") {
+		t.Errorf("variabila permisa lipsa: %q", env)
+	}
+	for _, v := range env {
+		for _, denied := range []string{"LD_PRELOAD=", "PYTHONPATH=", "SECRET_TOKEN="} {
+			if strings.HasPrefix(v, denied) {
+				t.Errorf("%s preluata din mediul agentului", v)
+			}
+		}
+	}
+	if !contains(env, "PATH="+checkPath) {
+		t.Errorf("PATH-ul fix suprascris din mediul agentului: %q", env)
+	}
+}
+
+func TestNativeEnv(t *testing.T) {
+	t.Setenv("LANG", "ro_RO.UTF-8")
+	t.Setenv("SECRET_TOKEN", "s3cret")
+
+	env := nativeEnv()
+	for _, want := range []string{"LC_ALL=C", "LANG=C", "TZ=UTC", "PATH=" + checkPath} {
+		if !contains(env, want) {
+			t.Errorf("lipseste %s din %q", want, env)
+		}
+	}
+	for _, v := range env {
+		if strings.HasPrefix(v, "SECRET_TOKEN=") {
+			t.Errorf("mediul agentului transmis utilitarelor native: %s", v)
+		}
+	}
+}
+
+func contains(list []string, item string) bool {
+	for _, v := range list {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
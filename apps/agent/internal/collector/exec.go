@@ -0,0 +1,25 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// Cat asteptam dupa iesirea procesului principal ca pipe-urile stdout/stderr
+// sa se inchida; un proces lasat in fundal (daemon &) le poate tine deschise
+const pipeDrainDelay = 500 * time.Millisecond
+
+// processResult intoarce eroarea executiei: expirarea contextului are
+// prioritate fata de codul de iesire al procesului oprit
+func processResult(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// Iesire cu succes, dar un proces din fundal tinea pipe-urile deschise
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+//go:build unix
+
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// processGone indica daca procesul a iesit (inexistent sau zombie in asteptarea reaper-ului)
+func processGone(pid int) bool {
+	if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+		return true
+	}
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	// pid (comm) S ...: starea e primul camp dupa ultima paranteza
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	return len(fields) > 0 && fields[0] == "Z"
+}
+
+// waitGone asteapta iesirea procesului, cel mult timeout
+func waitGone(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if processGone(pid) {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return processGone(pid)
+}
+
+// grandchildPID citeste pid-ul procesului nepot afisat de script ($!)
+func grandchildPID(t *testing.T, out *bytes.Buffer) int {
+	t.Helper()
+	line, _, _ := strings.Cut(out.String(), "\n")
+	pid, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		t.Fatalf("pid nepot %q: %v", out.String(), err)
+	}
+	return pid
+}
+
+func TestRunProcessGroupTimeoutKillsGrandchild(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.Command("/bin/sh", "-c", "sleep 60 & echo $!; wait")
+	cmd.Stdout = &out
+	start := time.Now()
+	err := runProcessGroup(ctx, cmd, 100*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, asteptat DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("timeout-ul nu a oprit grupul (%s)", elapsed)
+	}
+	if pid := grandchildPID(t, &out); !waitGone(pid, 2*time.Second) {
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Errorf("procesul nepot %d a supravietuit timeout-ului", pid)
+	}
+}
+
+func TestRunProcessGroupEscalatesToSIGKILL(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	// SIGTERM ignorat de shell si mostenit de nepot: doar SIGKILL ii opreste
+	var out bytes.Buffer
+	cmd := exec.Command("/bin/sh", "-c", "trap '' TERM; sleep 60 & echo $!; wait")
+	cmd.Stdout = &out
+	start := time.Now()
+	runProcessGroup(ctx, cmd, 200*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("SIGKILL nu a fost trimis dupa perioada de gratie (%s)", elapsed)
+	}
+	if pid := grandchildPID(t, &out); !waitGone(pid, 2*time.Second) {
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Errorf("procesul nepot %d a supravietuit SIGKILL", pid)
+	}
+}
+
+func TestRunProcessGroupReapsBackground(t *testing.T) {
+	// Procesul principal iese imediat; cel din fundal tine pipe-ul deschis
+	var out bytes.Buffer
+	cmd := exec.Command("/bin/sh", "-c", "sleep 60 & echo $!")
+	cmd.Stdout = &out
+	start := time.Now()
+	if err := runProcessGroup(context.Background(), cmd, 100*time.Millisecond); err != nil {
+		t.Errorf("err = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("asteptare blocata de procesul din fundal (%s)", elapsed)
+	}
+	if pid := grandchildPID(t, &out); !waitGone(pid, 2*time.Second) {
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Errorf("procesul din fundal %d nu a fost oprit", pid)
+	}
+}
@@ -0,0 +1,63 @@
+//go:build unix
+
+package collector
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"bittrail-agent/internal/sandbox"
+)
+
+// runProcessGroup porneste comanda in propriul grup de procese si, la
+// expirarea contextului, trimite SIGTERM intregului grup, apoi SIGKILL
+// dupa perioada de gratie. Astfel nu raman procese nepot (ex: find /)
+// dupa timeout. Iesirea e capturata prin cmd.Stdout/cmd.Stderr.
+func runProcessGroup(ctx context.Context, cmd *exec.Cmd, grace time.Duration) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.WaitDelay = pipeDrainDelay
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	pgid := cmd.Process.Pid
+
+	done := make(chan struct{})
+	killed := make(chan struct{})
+	go func() {
+		defer close(killed)
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+		syscall.Kill(-pgid, syscall.SIGTERM)
+		select {
+		case <-done:
+		case <-time.After(grace):
+		}
+		syscall.Kill(-pgid, syscall.SIGKILL)
+	}()
+
+	err := cmd.Wait()
+	close(done)
+	<-killed
+
+	// Procesul principal a iesit: procesele ramase in fundal in grup sunt oprite
+	syscall.Kill(-pgid, syscall.SIGKILL)
+
+	return processResult(ctx, err)
+}
+
+// setCredential ruleaza comanda ca utilizatorul dat (fara sandbox)
+func setCredential(cmd *exec.Cmd, cred *sandbox.Credential) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = cred
+}
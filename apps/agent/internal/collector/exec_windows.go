@@ -0,0 +1,35 @@
+package collector
+
+import (
+	"context"
+	"os/exec"
+	"time"
+
+	"bittrail-agent/internal/sandbox"
+)
+
+// runProcessGroup opreste procesul principal la expirarea contextului. Pe
+// Windows nu exista grupuri de procese unix: procesele pornite de comanda nu
+// sunt oprite (ar necesita un job object), iar perioada de gratie nu se aplica.
+func runProcessGroup(ctx context.Context, cmd *exec.Cmd, grace time.Duration) error {
+	cmd.WaitDelay = pipeDrainDelay
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			cmd.Process.Kill()
+		}
+	}()
+	err := cmd.Wait()
+	close(done)
+	return processResult(ctx, err)
+}
+
+// setCredential nu face nimic: pe Windows agentul nu schimba utilizatorul
+// verificarilor (resolveExecUser nu intoarce niciodata un Credential)
+func setCredential(cmd *exec.Cmd, cred *sandbox.Credential) {}
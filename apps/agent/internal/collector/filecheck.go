@@ -0,0 +1,347 @@
+package collector
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileCheckSpec descrie asertiunile unei verificari FILE_CHECK.
+// Specificatia e transmisa ca JSON in campul command al verificarii,
+// astfel incat e acoperita de semnatura backend-ului.
+type FileCheckSpec struct {
+	Path      string `json:"path"`      // cale sau glob
+	Exists    *bool  `json:"exists"`    // implicit true
+	Mode      string `json:"mode"`      // permisiuni exacte (octal, ex: "0600")
+	MaxMode   string `json:"maxMode"`   // permisiuni maxime admise (octal, ex: "0644")
+	Owner     string `json:"owner"`     // nume sau UID
+	Group     string `json:"group"`     // nume sau GID
+	Immutable *bool  `json:"immutable"` // atribut chattr +i
+	SHA256    string `json:"sha256"`
+
+	// Implicit true: mode/owner/continut se verifica pe tinta link-ului simbolic
+	FollowSymlinks *bool `json:"followSymlinks"`
+
+	// Continut: liniile sunt filtrate (include/exclude) inainte de potrivire
+	IncludeLines    []string `json:"includeLines"`
+	ExcludeLines    []string `json:"excludeLines"`
+	ContentMatch    []string `json:"contentMatch"`    // fiecare regex trebuie sa se potriveasca
+	ContentNotMatch []string `json:"contentNotMatch"` // niciun regex nu trebuie sa se potriveasca
+}
+
+// FileCheckResult e rezultatul structurat al unei verificari FILE_CHECK
+type FileCheckResult struct {
+	Path  string            `json:"path"`
+	Pass  bool              `json:"pass"`
+	Files []FileCheckStatus `json:"files"`
+	Notes []string          `json:"notes,omitempty"`
+}
+
+// FileCheckStatus contine starea observata pentru un fisier
+type FileCheckStatus struct {
+	Path      string   `json:"path"`
+	Target    string   `json:"target,omitempty"` // tinta, daca path e link simbolic urmat
+	Exists    bool     `json:"exists"`
+	Mode      string   `json:"mode,omitempty"`
+	Owner     string   `json:"owner,omitempty"`
+	Group     string   `json:"group,omitempty"`
+	Immutable *bool    `json:"immutable,omitempty"`
+	SHA256    string   `json:"sha256,omitempty"`
+	Failures  []string `json:"failures,omitempty"`
+}
+
+// ParseFileCheckSpec decodeaza si valideaza specificatia FILE_CHECK
+func ParseFileCheckSpec(raw string) (*FileCheckSpec, error) {
+	var spec FileCheckSpec
+	if err := decodeSpec("FILE_CHECK", raw, &spec); err != nil {
+		return nil, err
+	}
+	if spec.Path == "" {
+		return nil, invalidSpec("specificatie FILE_CHECK invalida: path lipsa")
+	}
+	if !filepath.IsAbs(spec.Path) {
+		return nil, invalidSpec("specificatie FILE_CHECK invalida: path trebuie sa fie absolut")
+	}
+	for _, m := range []string{spec.Mode, spec.MaxMode} {
+		if m == "" {
+			continue
+		}
+		if _, err := parseFileMode(m); err != nil {
+			return nil, invalidSpec("specificatie FILE_CHECK invalida: %w", err)
+		}
+	}
+	for _, group := range [][]string{spec.IncludeLines, spec.ExcludeLines, spec.ContentMatch, spec.ContentNotMatch} {
+		for _, expr := range group {
+			if _, err := regexp.Compile(expr); err != nil {
+				return nil, invalidSpec("specificatie FILE_CHECK invalida: regex %q: %w", expr, err)
+			}
+		}
+	}
+	return &spec, nil
+}
+
+// RunFileCheck evalueaza specificatia fara shell
+func RunFileCheck(spec *FileCheckSpec) *FileCheckResult {
+	res := &FileCheckResult{Path: spec.Path, Pass: true, Files: []FileCheckStatus{}}
+
+	wantExists := spec.Exists == nil || *spec.Exists
+
+	paths, err := filepath.Glob(spec.Path)
+	if err != nil {
+		res.Pass = false
+		res.Notes = append(res.Notes, fmt.Sprintf("glob invalid: %v", err))
+		return res
+	}
+
+	if len(paths) == 0 {
+		res.Files = append(res.Files, FileCheckStatus{Path: spec.Path, Exists: false})
+		if wantExists {
+			res.Pass = false
+			res.Notes = append(res.Notes, "niciun fisier gasit")
+		}
+		return res
+	}
+
+	for _, p := range paths {
+		st := checkOneFile(p, spec, wantExists)
+		if len(st.Failures) > 0 {
+			res.Pass = false
+		}
+		res.Files = append(res.Files, st)
+	}
+	return res
+}
+
+func checkOneFile(path string, spec *FileCheckSpec, wantExists bool) FileCheckStatus {
+	st := FileCheckStatus{Path: path}
+
+	// Link-urile simbolice sunt rezolvate o singura data; fisierul e apoi
+	// deschis prin calea rezolvata cu O_NOFOLLOW
+	if spec.FollowSymlinks == nil || *spec.FollowSymlinks {
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				if wantExists {
+					st.Failures = append(st.Failures, "fisierul nu exista")
+				}
+				return st
+			}
+			st.Failures = append(st.Failures, fmt.Sprintf("rezolvare link: %v", err))
+			return st
+		}
+		if target != path {
+			st.Target = target
+			path = target
+		}
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if wantExists {
+				st.Failures = append(st.Failures, "fisierul nu exista")
+			}
+			return st
+		}
+		st.Failures = append(st.Failures, fmt.Sprintf("stat: %v", err))
+		return st
+	}
+	st.Exists = true
+	if !wantExists {
+		st.Failures = append(st.Failures, "fisierul exista dar nu ar trebui")
+		return st
+	}
+
+	perm := info.Mode().Perm() | (info.Mode() & (os.ModeSetuid | os.ModeSetgid | os.ModeSticky))
+	st.Mode = fmt.Sprintf("%04o", unixModeBits(perm))
+
+	if spec.Mode != "" {
+		want, _ := parseFileMode(spec.Mode)
+		if unixModeBits(perm) != want {
+			st.Failures = append(st.Failures, fmt.Sprintf("mode %s, asteptat %04o", st.Mode, want))
+		}
+	}
+	if spec.MaxMode != "" {
+		max, _ := parseFileMode(spec.MaxMode)
+		if extra := unixModeBits(perm) &^ max; extra != 0 {
+			st.Failures = append(st.Failures, fmt.Sprintf("mode %s depaseste %04o (biti in plus %04o)", st.Mode, max, extra))
+		}
+	}
+
+	if uid, gid, ok := fileOwnerIDs(info); ok {
+		st.Owner = uid
+		if u, err := user.LookupId(uid); err == nil {
+			st.Owner = u.Username
+		}
+		st.Group = gid
+		if g, err := user.LookupGroupId(gid); err == nil {
+			st.Group = g.Name
+		}
+		if spec.Owner != "" && spec.Owner != st.Owner && spec.Owner != uid {
+			st.Failures = append(st.Failures, fmt.Sprintf("owner %s, asteptat %s", st.Owner, spec.Owner))
+		}
+		if spec.Group != "" && spec.Group != st.Group && spec.Group != gid {
+			st.Failures = append(st.Failures, fmt.Sprintf("group %s, asteptat %s", st.Group, spec.Group))
+		}
+	} else if spec.Owner != "" || spec.Group != "" {
+		st.Failures = append(st.Failures, "owner/group indisponibile pe aceasta platforma")
+	}
+
+	if spec.Immutable != nil {
+		imm, err := isImmutable(path)
+		if err != nil {
+			st.Failures = append(st.Failures, fmt.Sprintf("atribute: %v", err))
+		} else {
+			st.Immutable = &imm
+			if imm != *spec.Immutable {
+				st.Failures = append(st.Failures, fmt.Sprintf("immutable=%t, asteptat %t", imm, *spec.Immutable))
+			}
+		}
+	}
+
+	needsContent := spec.SHA256 != "" || len(spec.ContentMatch) > 0 || len(spec.ContentNotMatch) > 0
+	if !needsContent {
+		return st
+	}
+	if !info.Mode().IsRegular() {
+		st.Failures = append(st.Failures, "verificarea continutului necesita un fisier obisnuit")
+		return st
+	}
+
+	if spec.SHA256 != "" {
+		sum, err := fileSHA256(path)
+		if err != nil {
+			st.Failures = append(st.Failures, fmt.Sprintf("sha256: %v", err))
+		} else {
+			st.SHA256 = sum
+			if !strings.EqualFold(sum, spec.SHA256) {
+				st.Failures = append(st.Failures, "sha256 diferit")
+			}
+		}
+	}
+
+	if len(spec.ContentMatch) > 0 || len(spec.ContentNotMatch) > 0 {
+		lines, err := filteredLines(path, spec.IncludeLines, spec.ExcludeLines)
+		if err != nil {
+			st.Failures = append(st.Failures, fmt.Sprintf("citire: %v", err))
+			return st
+		}
+		content := strings.Join(lines, "\n")
+		for _, expr := range spec.ContentMatch {
+			if !regexp.MustCompile("(?m)" + expr).MatchString(content) {
+				st.Failures = append(st.Failures, fmt.Sprintf("continut lipsa: %s", expr))
+			}
+		}
+		for _, expr := range spec.ContentNotMatch {
+			if regexp.MustCompile("(?m)" + expr).MatchString(content) {
+				st.Failures = append(st.Failures, fmt.Sprintf("continut interzis: %s", expr))
+			}
+		}
+	}
+
+	return st
+}
+
+// openRegular deschide un fisier obisnuit; tipul e reverificat pe descriptor
+func openRegular(path string) (*os.File, error) {
+	f, err := openNoBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return nil, fmt.Errorf("%s nu e un fisier obisnuit", path)
+	}
+	return f, nil
+}
+
+// filteredLines citeste fisierul si pastreaza liniile conform filtrelor
+func filteredLines(path string, include, exclude []string) ([]string, error) {
+	f, err := openRegular(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var inc, exc []*regexp.Regexp
+	for _, e := range include {
+		inc = append(inc, regexp.MustCompile(e))
+	}
+	for _, e := range exclude {
+		exc = append(exc, regexp.MustCompile(e))
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(inc) > 0 && !matchesAny(inc, line) {
+			continue
+		}
+		if matchesAny(exc, line) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func matchesAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := openRegular(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func parseFileMode(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || v > 07777 {
+		return 0, fmt.Errorf("mode invalid %q", s)
+	}
+	return uint32(v), nil
+}
+
+// unixModeBits converteste os.FileMode in biti unix (inclusiv setuid/setgid/sticky)
+func unixModeBits(m os.FileMode) uint32 {
+	bits := uint32(m.Perm())
+	if m&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if m&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if m&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+	return bits
+}
@@ -0,0 +1,25 @@
+//go:build linux
+
+package collector
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// Flag-uri inode (linux/fs.h)
+const fsImmutableFl = 0x00000010
+
+// isImmutable citeste atributul chattr +i prin FS_IOC_GETFLAGS
+func isImmutable(path string) (bool, error) {
+	f, err := openNoBlock(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	flags, err := unix.IoctlGetUint32(int(f.Fd()), unix.FS_IOC_GETFLAGS)
+	if err != nil {
+		return false, err
+	}
+	return flags&fsImmutableFl != 0, nil
+}
@@ -0,0 +1,10 @@
+//go:build unix && !linux
+
+package collector
+
+import "fmt"
+
+// isImmutable nu e suportat in afara Linux
+func isImmutable(path string) (bool, error) {
+	return false, fmt.Errorf("atribute inode indisponibile pe aceasta platforma")
+}
@@ -0,0 +1,109 @@
+//go:build unix
+
+package collector
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestRunFileCheck(t *testing.T) {
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "sshd_config")
+	if err := os.WriteFile(conf, []byte("PermitRootLogin no\n# PermitRootLogin yes\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(conf, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(conf, link); err != nil {
+		t.Fatal(err)
+	}
+	uid := strconv.Itoa(os.Getuid())
+	owner := uid
+	if u, err := user.LookupId(uid); err == nil {
+		owner = u.Username
+	}
+
+	tests := []struct {
+		name string
+		spec FileCheckSpec
+		pass bool
+	}{
+		{"mode exact", FileCheckSpec{Path: conf, Mode: "0600"}, true},
+		{"mode diferit", FileCheckSpec{Path: conf, Mode: "0644"}, false},
+		{"maxMode respectat", FileCheckSpec{Path: conf, MaxMode: "0644"}, true},
+		{"maxMode depasit", FileCheckSpec{Path: conf, MaxMode: "0400"}, false},
+		{"owner dupa uid", FileCheckSpec{Path: conf, Owner: uid}, true},
+		{"owner dupa nume", FileCheckSpec{Path: conf, Owner: owner}, true},
+		{"owner diferit", FileCheckSpec{Path: conf, Owner: "4242424"}, false},
+		{"exista", FileCheckSpec{Path: conf}, true},
+		{"lipseste", FileCheckSpec{Path: filepath.Join(dir, "nope")}, false},
+		{"lipseste, asteptat absent", FileCheckSpec{Path: filepath.Join(dir, "nope"), Exists: boolPtr(false)}, true},
+		{"exista, asteptat absent", FileCheckSpec{Path: conf, Exists: boolPtr(false)}, false},
+		{"glob", FileCheckSpec{Path: filepath.Join(dir, "sshd_*"), Mode: "0600"}, true},
+		{"continut", FileCheckSpec{Path: conf, ContentMatch: []string{`^PermitRootLogin no$`}}, true},
+		{"continut filtrat", FileCheckSpec{Path: conf, ExcludeLines: []string{`^#`}, ContentNotMatch: []string{`yes`}}, true},
+		{"continut interzis", FileCheckSpec{Path: conf, ContentNotMatch: []string{`yes`}}, false},
+		{"link urmat", FileCheckSpec{Path: link, Mode: "0600", ContentMatch: []string{`no$`}}, true},
+		{"link neurmat", FileCheckSpec{Path: link, FollowSymlinks: boolPtr(false), ContentMatch: []string{`no$`}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := RunFileCheck(&tt.spec)
+			if res.Pass != tt.pass {
+				t.Errorf("Pass = %v, asteptat %v: %+v", res.Pass, tt.pass, res)
+			}
+		})
+	}
+}
+
+func TestRunFileCheckSymlinkTarget(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	if err := os.WriteFile(target, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	res := RunFileCheck(&FileCheckSpec{Path: link})
+	if len(res.Files) != 1 || res.Files[0].Target != target {
+		t.Fatalf("tinta link-ului nu e raportata: %+v", res.Files)
+	}
+
+	// Continutul nu se citeste niciodata printr-un link simbolic (O_NOFOLLOW)
+	if _, err := openRegular(link); err == nil {
+		t.Error("openRegular a urmat link-ul simbolic")
+	}
+	res = RunFileCheck(&FileCheckSpec{Path: link, FollowSymlinks: boolPtr(false), SHA256: strings.Repeat("0", 64)})
+	if res.Pass || len(res.Files) != 1 || res.Files[0].SHA256 != "" {
+		t.Errorf("sha256 calculat prin link neurmat: %+v", res)
+	}
+}
+
+func TestRunFileCheckFIFO(t *testing.T) {
+	fifo := filepath.Join(t.TempDir(), "fifo")
+	if err := syscall.Mkfifo(fifo, 0o600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+	// Fara O_NONBLOCK deschiderea unui FIFO fara scriitor ar bloca testul
+	if _, err := openRegular(fifo); err == nil {
+		t.Error("FIFO acceptat ca fisier obisnuit")
+	}
+	res := RunFileCheck(&FileCheckSpec{Path: fifo, ContentMatch: []string{"x"}})
+	if res.Pass {
+		t.Errorf("continut verificat pe FIFO: %+v", res)
+	}
+}
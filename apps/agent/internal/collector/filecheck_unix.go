@@ -0,0 +1,27 @@
+//go:build unix
+
+package collector
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// Modul, proprietarul si link-urile simbolice au semantica unix
+const fileCheckSupported = true
+
+// openNoBlock deschide fisierul fara a urma link-uri simbolice si fara a
+// bloca pe FIFO-uri sau dispozitive
+func openNoBlock(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK|syscall.O_NOFOLLOW, 0)
+}
+
+// fileOwnerIDs intoarce uid-ul si gid-ul fisierului
+func fileOwnerIDs(info os.FileInfo) (uid, gid string, ok bool) {
+	sys, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", "", false
+	}
+	return strconv.FormatUint(uint64(sys.Uid), 10), strconv.FormatUint(uint64(sys.Gid), 10), true
+}
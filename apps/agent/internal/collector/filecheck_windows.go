@@ -0,0 +1,26 @@
+//go:build windows
+
+package collector
+
+import (
+	"errors"
+	"os"
+)
+
+// Pe Windows permisiunile sunt ACL-uri, fara mode/owner unix: verificarile
+// FILE_CHECK sunt raportate NOT_APPLICABLE inainte de executie
+const fileCheckSupported = false
+
+var errFileCheckUnsupported = errors.New("FILE_CHECK nesuportat pe windows")
+
+func openNoBlock(path string) (*os.File, error) {
+	return nil, errFileCheckUnsupported
+}
+
+func fileOwnerIDs(info os.FileInfo) (uid, gid string, ok bool) {
+	return "", "", false
+}
+
+func isImmutable(path string) (bool, error) {
+	return false, errFileCheckUnsupported
+}
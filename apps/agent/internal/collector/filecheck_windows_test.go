@@ -0,0 +1,18 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bittrail-agent/internal/api"
+)
+
+func TestRunCheckFileCheckNotApplicable(t *testing.T) {
+	ar := &AuditRunner{defaultTimeout: time.Second}
+	check := api.PendingCheck{CheckType: "FILE_CHECK", Command: `{"path":"C:\\Windows\\win.ini"}`}
+	res := ar.runCheck(context.Background(), openedCheck{PendingCheck: check}, nil)
+	if res.Status != api.StatusNotApplicable || res.ReasonCode != api.ReasonNotApplicable {
+		t.Errorf("FILE_CHECK pe windows: %s/%s", res.Status, res.ReasonCode)
+	}
+}
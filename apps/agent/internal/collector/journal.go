@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/config"
+	"bittrail-agent/internal/crypto"
+	"bittrail-agent/internal/journal"
+)
+
+// JournalPath intoarce fisierul jurnalului local de executii
+func JournalPath(cfg *config.Config) string {
+	return filepath.Join(cfg.StateDir, "journal.log")
+}
+
+// journalResults adauga rezultatele semnate in jurnal, inainte de trimitere,
+// impreuna cu payload-ul primit de la backend. Iesirea nu e pastrata, doar
+// outputHash (semnat): jurnalul nu e rotit si evaluarea continua il extinde
+// la fiecare termen.
+func (ar *AuditRunner) journalResults(checks []openedCheck, results []api.CheckResult) {
+	if ar.journal == nil {
+		return
+	}
+	for i, check := range checks {
+		result := results[i]
+		result.Output, result.Stderr = "", ""
+		entry := journal.Entry{
+			Type:       journal.TypeResult,
+			ServerID:   ar.serverID,
+			AuditRunID: check.AuditRunID,
+			Check: &journal.CheckRecord{
+				CheckID:          check.CheckID,
+				CheckType:        check.CheckType,
+				CommandSHA256:    crypto.CalculateHash(check.Command + "\n" + check.Script),
+				Payload:          check.Payload,
+				PayloadSignature: check.Signature,
+			},
+			Result: &result,
+		}
+		if _, err := ar.journal.Append(entry); err != nil {
+			log.Printf("WARNING: Failed to journal result for check %s: %v", check.CheckID, err)
+		}
+	}
+}
+
+// signedAnchor e structura canonica semnata a ancorei (chei sortate, ca
+// serializarea canonica a backend-ului)
+type signedAnchor struct {
+	Alg      string `json:"alg"`
+	Hash     string `json:"hash"`
+	Seq      uint64 `json:"seq"`
+	ServerID string `json:"serverId"`
+	Time     string `json:"time"`
+	Version  int    `json:"v"`
+}
+
+func anchorSignatureData(serverID string, anchor api.JournalAnchor) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(signedAnchor{
+		Alg:      anchor.SignatureAlg,
+		Hash:     anchor.Hash,
+		Seq:      anchor.Seq,
+		ServerID: serverID,
+		Time:     anchor.Time,
+		Version:  1,
+	})
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), err
+}
+
+// AnchorJournal trimite capul lantului la backend, semnat, si inregistreaza
+// ancora confirmata in jurnal. Nu face nimic daca nu exista intrari noi.
+// Un jurnal invalid e semnalat backend-ului in locul ancorei (si la fiecare
+// CheckAndRun), pana la confirmare.
+func (ar *AuditRunner) AnchorJournal() error {
+	if ar.journalErr != nil {
+		return ar.alertJournal()
+	}
+	if ar.journal == nil || ar.privateKey == nil {
+		return nil
+	}
+	head := ar.journal.Head()
+	if head.Seq == 0 || head.Anchored {
+		return nil
+	}
+
+	anchor := api.JournalAnchor{
+		Seq:          head.Seq,
+		Hash:         head.Hash,
+		Time:         time.Now().UTC().Format(time.RFC3339),
+		SignatureAlg: crypto.AlgRSAPSSSHA256,
+	}
+	data, err := anchorSignatureData(ar.serverID, anchor)
+	if err != nil {
+		return err
+	}
+	if anchor.Signature, err = crypto.SignDataPSS(ar.privateKey, data); err != nil {
+		return fmt.Errorf("semnare ancora: %w", err)
+	}
+	if err := ar.client.SendJournalAnchor(anchor); err != nil {
+		return fmt.Errorf("trimitere ancora: %w", err)
+	}
+
+	_, err = ar.journal.Append(journal.Entry{
+		Type:     journal.TypeAnchor,
+		ServerID: ar.serverID,
+		Anchor:   &journal.Anchor{Seq: head.Seq, Hash: head.Hash},
+	})
+	return err
+}
+
+// alertJournal trimite o singura data alerta de jurnal invalid
+func (ar *AuditRunner) alertJournal() error {
+	if ar.journalAlerted.Load() {
+		return nil
+	}
+	err := ar.client.SendJournalAlert(api.JournalAlert{
+		Reason: api.ReasonJournalUnavailable,
+		Detail: ar.journalErr.Error(),
+		Time:   time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return fmt.Errorf("trimitere alerta jurnal: %w", err)
+	}
+	ar.journalAlerted.Store(true)
+	return nil
+}
+
+// VerifyResultSignature verifica semnatura agentului pe un rezultat din jurnal
+func VerifyResultSignature(pub *rsa.PublicKey, serverID, auditRunID string, result api.CheckResult) error {
+	if result.Signature == "" {
+		return errors.New("rezultat nesemnat")
+	}
+	if result.SignatureAlg != crypto.AlgRSAPSSSHA256 ||
+		(result.SignatureVersion != resultSignatureVersion && result.SignatureVersion != resultSignatureVersionLegacy) {
+		return fmt.Errorf("algoritm semnatura nesuportat: %s v%d", result.SignatureAlg, result.SignatureVersion)
+	}
+	check := api.PendingCheck{AuditRunID: auditRunID, CheckID: result.CheckID}
+	data, err := resultSignatureDataVersion(serverID, check, &result, result.SignatureVersion)
+	if err != nil {
+		return err
+	}
+	if err := crypto.VerifyPSS(pub, data, result.Signature); err != nil {
+		return fmt.Errorf("semnatura rezultat invalida: %w", err)
+	}
+	return nil
+}
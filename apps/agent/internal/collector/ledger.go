@@ -0,0 +1,204 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"bittrail-agent/internal/api"
+)
+
+// adhocRunID e rularea verificarilor ad-hoc: re-executate la fiecare cerere,
+// rezultatul e asteptat sincron de backend, deci nu trec prin registru
+const adhocRunID = "ADHOC"
+
+// ledgerEntry e rezultatul semnat al unei verificari, asa cum a fost raportat
+type ledgerEntry struct {
+	AuditRunID string          `json:"auditRunId"`
+	Result     api.CheckResult `json:"result"`
+	RecordedAt int64           `json:"recordedAt"` // secunde unix
+	Acked      bool            `json:"acked"`      // salvat de backend
+}
+
+// ledger retine rezultatele pe (auditRunId, automatedCheckId): o verificare
+// servita din nou nu e re-executata, iar rezultatul stocat e retrimis pana la
+// confirmarea backend-ului. Persistat pe disc, supravietuieste repornirilor.
+type ledger struct {
+	mu        sync.Mutex
+	path      string
+	retention time.Duration
+	entries   map[string]*ledgerEntry
+}
+
+func ledgerKey(auditRunID, automatedCheckID string) string {
+	return auditRunID + "/" + automatedCheckID
+}
+
+func newLedger(path string, retention time.Duration, now time.Time) *ledger {
+	l := &ledger{path: path, retention: retention, entries: make(map[string]*ledgerEntry)}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &l.entries)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		// Fara registru, verificarile servite din nou sunt re-executate
+		l.entries = make(map[string]*ledgerEntry)
+		log.Printf("WARNING: Result ledger %s unreadable (%v). Pending checks may be executed again.", path, err)
+	}
+	if l.entries == nil {
+		l.entries = make(map[string]*ledgerEntry)
+	}
+	l.prune(now)
+	return l
+}
+
+// has intoarce adevarat daca verificarea are un rezultat inregistrat
+func (l *ledger) has(auditRunID, automatedCheckID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.entries[ledgerKey(auditRunID, automatedCheckID)]
+	return ok
+}
+
+// resend intoarce adevarat daca verificarea are deja un rezultat; acesta e
+// marcat pentru retrimitere (backend-ul o serveste inca, deci nu il are).
+// Un rezultat confirmat e retrimis fara iesire: semnatura acopera doar
+// outputHash, deci ramane valida.
+func (l *ledger) resend(auditRunID, automatedCheckID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[ledgerKey(auditRunID, automatedCheckID)]
+	if !ok {
+		return false
+	}
+	if entry.Acked {
+		entry.Acked = false
+		if err := l.save(); err != nil {
+			log.Printf("WARNING: Failed to save result ledger: %v", err)
+		}
+	}
+	return true
+}
+
+// record inregistreaza rezultatele noi inainte de trimitere
+func (l *ledger) record(auditRunID string, results []api.CheckResult, now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, result := range results {
+		l.entries[ledgerKey(auditRunID, result.AutomatedCheckID)] = &ledgerEntry{
+			AuditRunID: auditRunID,
+			Result:     result,
+			RecordedAt: now.Unix(),
+		}
+	}
+	return l.save()
+}
+
+// unacked intoarce rezultatele neconfirmate, grupate pe rulare de audit
+func (l *ledger) unacked() map[string][]api.CheckResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	keys := make([]string, 0, len(l.entries))
+	for key, entry := range l.entries {
+		if !entry.Acked {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	byRun := make(map[string][]api.CheckResult)
+	for _, key := range keys {
+		entry := l.entries[key]
+		byRun[entry.AuditRunID] = append(byRun[entry.AuditRunID], entry.Result)
+	}
+	return byRun
+}
+
+// ack marcheaza rezultatele confirmate de backend. Iesirea lor e stearsa:
+// backend-ul o are, iar registrul ramane mic (e rescris la fiecare salvare).
+func (l *ledger) ack(auditRunID string, automatedCheckIDs []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, id := range automatedCheckIDs {
+		if entry, ok := l.entries[ledgerKey(auditRunID, id)]; ok {
+			entry.Acked = true
+			entry.Result.Output, entry.Result.Stderr = "", ""
+		}
+	}
+	return l.save()
+}
+
+// drop sterge rezultatele unei rulari respinse definitiv de backend
+func (l *ledger) drop(auditRunID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, entry := range l.entries {
+		if entry.AuditRunID == auditRunID {
+			delete(l.entries, key)
+		}
+	}
+	return l.save()
+}
+
+// prune sterge intrarile mai vechi decat perioada de pastrare; cele
+// neconfirmate sunt abandonate cu avertisment
+func (l *ledger) prune(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := now.Add(-l.retention).Unix()
+	pruned := false
+	for key, entry := range l.entries {
+		if entry.RecordedAt >= cutoff {
+			continue
+		}
+		if !entry.Acked {
+			log.Printf("WARNING: Giving up on unacknowledged result %s after %s.", key, l.retention)
+		}
+		delete(l.entries, key)
+		pruned = true
+	}
+	if pruned {
+		if err := l.save(); err != nil {
+			log.Printf("WARNING: Failed to save result ledger: %v", err)
+		}
+	}
+}
+
+// save scrie atomic fisierul (temporar + fsync + rename), accesibil doar agentului
+func (l *ledger) save() error {
+	data, err := json.Marshal(l.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("director stare: %w", err)
+	}
+	tmp := l.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("salvare registru: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("salvare registru: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("salvare registru: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("salvare registru: %w", err)
+	}
+	return os.Rename(tmp, l.path)
+}
@@ -0,0 +1,215 @@
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/journal"
+)
+
+func TestLedgerPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	now := time.Now()
+	l := newLedger(path, time.Hour, now)
+	results := []api.CheckResult{
+		{AutomatedCheckID: "a", Status: api.StatusPass, Output: "ok", Stderr: "w", OutputHash: "h-a"},
+		{AutomatedCheckID: "b", Status: api.StatusFail, Output: "fail", OutputHash: "h-b"},
+	}
+	if err := l.record("run-1", results, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.ack("run-1", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dupa repornire: ambele au rezultat, doar "b" mai trebuie trimis
+	reopened := newLedger(path, time.Hour, now)
+	if pending := reopened.unacked()["run-1"]; len(pending) != 1 || pending[0].AutomatedCheckID != "b" {
+		t.Errorf("neconfirmate dupa repornire: %+v", pending)
+	}
+	if !reopened.resend("run-1", "a") || reopened.resend("run-1", "c") {
+		t.Error("resend nu recunoaste rezultatele stocate")
+	}
+	// "a" servita din nou: backend-ul nu o are, se retrimite
+	pending := reopened.unacked()["run-1"]
+	if len(pending) != 2 {
+		t.Fatalf("rezultat confirmat, dar servit din nou, nu e retrimis: %+v", pending)
+	}
+	// Iesirea rezultatelor confirmate nu e pastrata; cea neconfirmata da
+	if a := pending[0]; a.Output != "" || a.Stderr != "" || a.OutputHash != "h-a" {
+		t.Errorf("rezultat confirmat cu iesirea pastrata: %+v", a)
+	}
+	if b := pending[1]; b.Output != "fail" {
+		t.Errorf("iesire neconfirmata pierduta: %+v", b)
+	}
+
+	// Perioada de pastrare expirata
+	expired := newLedger(path, time.Hour, now.Add(2*time.Hour))
+	if expired.resend("run-1", "a") {
+		t.Error("intrare expirata pastrata")
+	}
+}
+
+// fakeBackend serveste aceleasi verificari pana primeste rezultatele;
+// primele failUploads incarcari esueaza. Heartbeat-urile sunt inregistrate
+// in progress, alertele de jurnal in alerts; cancelled semnaleaza anularea
+// rularii.
+type fakeBackend struct {
+	mu          sync.Mutex
+	checks      []api.PendingCheck
+	stored      map[string]api.CheckResult
+	failUploads int
+	uploads     int
+	progress    []api.RunProgress
+	alerts      []api.JournalAlert
+	cancelled   bool
+}
+
+func (b *fakeBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if r.Method == http.MethodGet {
+		var pending []api.PendingCheck
+		for _, check := range b.checks {
+			if _, ok := b.stored[check.AutomatedCheckID]; !ok {
+				pending = append(pending, check)
+			}
+		}
+		json.NewEncoder(w).Encode(pending)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/progress") {
+		var progress api.RunProgress
+		json.NewDecoder(r.Body).Decode(&progress)
+		b.progress = append(b.progress, progress)
+		json.NewEncoder(w).Encode(api.ProgressAck{Cancel: b.cancelled, Status: "RUNNING"})
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/journal/alert") {
+		var alert api.JournalAlert
+		json.NewDecoder(r.Body).Decode(&alert)
+		b.alerts = append(b.alerts, alert)
+		w.Write([]byte("{}"))
+		return
+	}
+
+	b.uploads++
+	if b.uploads <= b.failUploads {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	var body struct {
+		Results []api.CheckResult `json:"results"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	ack := api.ResultsAck{Accepted: []string{}}
+	for _, result := range body.Results {
+		b.stored[result.AutomatedCheckID] = result
+		ack.Accepted = append(ack.Accepted, result.AutomatedCheckID)
+	}
+	json.NewEncoder(w).Encode(ack)
+}
+
+func TestCheckAndRunExactlyOnce(t *testing.T) {
+	backend := &fakeBackend{
+		checks: []api.PendingCheck{
+			{AuditRunID: "run-1", AutomatedCheckID: "ac-1", CheckID: "c1", CheckType: "MANUAL"},
+			{AuditRunID: "run-1", AutomatedCheckID: "ac-2", CheckID: "c2", CheckType: "MANUAL"},
+		},
+		stored:      make(map[string]api.CheckResult),
+		failUploads: 2,
+	}
+	srv := httptest.NewServer(backend)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	jrnl, err := journal.Open(filepath.Join(dir, "journal.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newRunner := func() *AuditRunner {
+		return &AuditRunner{
+			client:         api.NewClient(srv.URL, "srv-1", "token", nil),
+			serverID:       "srv-1",
+			journal:        jrnl,
+			ledger:         newLedger(filepath.Join(dir, "ledger.json"), time.Hour, time.Now()),
+			checkpoint:     loadCheckpoint(filepath.Join(dir, "checkpoint.json")),
+			workers:        1,
+			runConcurrency: 1,
+		}
+	}
+
+	// Incarcarea esueaza, apoi agentul reporneste: verificarile sunt servite
+	// din nou, dar nu se re-executa
+	for i := 0; i < 3; i++ {
+		if err := newRunner().CheckAndRun(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if head := jrnl.Head(); head.Seq != 2 {
+		t.Errorf("executii: %d, asteptat 2", head.Seq)
+	}
+	if len(backend.stored) != 2 || backend.uploads != 3 {
+		t.Fatalf("rezultate salvate %d dupa %d incarcari", len(backend.stored), backend.uploads)
+	}
+	for id, result := range backend.stored {
+		if result.Status != api.StatusSkipped || !strings.HasPrefix(result.ErrorMessage, "tip verificare") {
+			t.Errorf("%s: rezultat %+v", id, result)
+		}
+	}
+
+	// Rulare convergenta: nimic de executat sau retrimis
+	ar := newRunner()
+	if err := ar.CheckAndRun(); err != nil {
+		t.Fatal(err)
+	}
+	if backend.uploads != 3 || len(ar.ledger.unacked()) != 0 {
+		t.Errorf("retrimitere dupa confirmare: %d incarcari", backend.uploads)
+	}
+}
+
+func TestCheckAndRunInvalidJournal(t *testing.T) {
+	backend := &fakeBackend{
+		checks: []api.PendingCheck{{AuditRunID: "run-1", AutomatedCheckID: "ac-1", CheckID: "c1", CheckType: "MANUAL"}},
+		stored: make(map[string]api.CheckResult),
+	}
+	srv := httptest.NewServer(backend)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	ar := &AuditRunner{
+		client:         api.NewClient(srv.URL, "srv-1", "token", nil),
+		serverID:       "srv-1",
+		journalErr:     errors.New("jurnal executii invalid: intrarea 2: hash invalid"),
+		ledger:         newLedger(filepath.Join(dir, "ledger.json"), time.Hour, time.Now()),
+		checkpoint:     loadCheckpoint(filepath.Join(dir, "checkpoint.json")),
+		workers:        1,
+		runConcurrency: 1,
+	}
+	if err := ar.CheckAndRun(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ar.AnchorJournal(); err != nil {
+		t.Fatal(err)
+	}
+
+	result := backend.stored["ac-1"]
+	if result.Status != api.StatusBlocked || result.ReasonCode != api.ReasonJournalUnavailable {
+		t.Errorf("verificare cu jurnal invalid: %+v", result)
+	}
+	// Alerta trimisa o singura data, dupa confirmare
+	if len(backend.alerts) != 1 || backend.alerts[0].Reason != api.ReasonJournalUnavailable ||
+		!strings.Contains(backend.alerts[0].Detail, "hash invalid") {
+		t.Errorf("alerte jurnal: %+v", backend.alerts)
+	}
+}
@@ -0,0 +1,61 @@
+package collector
+
+import (
+	"log"
+	"sync"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/config"
+	"bittrail-agent/internal/crypto"
+	"bittrail-agent/internal/template"
+)
+
+// LocalRunID e rularea auditurilor locale (comanda audit), fara backend;
+// semnatura rezultatelor locale e legata de ea
+const LocalRunID = "LOCAL"
+
+// NewLocalAuditRunner construieste un runner fara backend: aceeasi executie,
+// normalizare si comparatie ca in CheckAndRun, fara registru sau jurnal.
+// Pe un agent inrolat rezultatele sunt semnate cu cheia agentului.
+func NewLocalAuditRunner(cfg *config.Config) *AuditRunner {
+	ar := newExecRunner(cfg)
+	ar.checkpoint = &checkpoint{entries: make(map[string]*checkpointEntry)}
+	ar.serverID = cfg.ServerID
+	if cfg.KeyFile != "" {
+		key, err := crypto.LoadPrivateKey(cfg.KeyFile)
+		if err != nil {
+			log.Printf("WARNING: Failed to load agent private key: %v. Local results will not be signed.", err)
+		} else {
+			ar.privateKey = key
+		}
+	}
+	return ar
+}
+
+// RunTemplate executa verificarile automate ale sablonului si intoarce
+// rezultatele pe checkId
+func (ar *AuditRunner) RunTemplate(tpl *template.Template) map[string]api.CheckResult {
+	var checks []openedCheck
+	for _, control := range tpl.Controls {
+		for _, check := range control.AutomatedChecks {
+			// Sablonul e dat local de operator, deci de incredere: ruleaza ca o
+			// verificare semnata (runAs si env permise, ca in modul verify)
+			pc := check.PendingCheck(LocalRunID)
+			pc.ControlID = control.ControlID
+			checks = append(checks, openedCheck{PendingCheck: pc, signed: true})
+		}
+	}
+
+	results := make(map[string]api.CheckResult, len(checks))
+	var mu sync.Mutex
+	runs := ar.newRunStates(checks)
+	ar.runPool(checks, runs, func(check openedCheck, result api.CheckResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[check.CheckID] = result
+	})
+	for _, run := range runs {
+		run.cancel(nil)
+	}
+	return results
+}
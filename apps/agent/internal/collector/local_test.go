@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"path/filepath"
+	"testing"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/config"
+	"bittrail-agent/internal/template"
+)
+
+func TestRunTemplate(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := config.LoadLocal(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.SandboxMode = "off"
+	cfg.ExecPolicyFile = filepath.Join(dir, "exec-policy.yaml") // lipsa: politica implicita
+	cfg.StateDir = dir
+
+	tpl, err := template.Parse([]byte(`{"$schema":"bittrail-template@1.0","metadata":{"name":"t","version":"1"},
+		"controls":[{"controlId":"C1","title":"c","category":"x","severity":"LOW","automatedChecks":[
+			{"checkId":"C1.a","command":"echo '  Enabled '","expectedResult":"enabled","normalize":["LOWER"]},
+			{"checkId":"C1.b","command":"echo 3","expectedResult":"5","comparison":"NUM_GE"},
+			{"checkId":"C1.c","command":"true","platformScope":["bittrail-no-such-os"]}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := NewLocalAuditRunner(cfg).RunTemplate(tpl)
+	want := map[string]string{"C1.a": api.StatusPass, "C1.b": api.StatusFail, "C1.c": api.StatusNotApplicable}
+	for id, status := range want {
+		if got := results[id]; got.Status != status || got.CheckID != id {
+			t.Errorf("%s: %+v, asteptat %s", id, got, status)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// nonceStore retine nonce-urile payload-urilor acceptate pana la expirarea
+// lor, persistate pe disc ca o repornire a agentului sa nu permita reluarea
+type nonceStore struct {
+	mu   sync.Mutex
+	path string
+	seen map[string]int64 // nonce -> expiresAt (secunde unix)
+
+	// Daca fisierul nu a putut fi citit, payload-urile emise inainte de
+	// pornire sunt refuzate: nonce-urile lor nu mai pot fi verificate
+	minIssued int64
+}
+
+func newNonceStore(path string, now time.Time) *nonceStore {
+	s := &nonceStore{path: path, seen: make(map[string]int64)}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &s.seen)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		s.seen = make(map[string]int64)
+		s.minIssued = now.Unix()
+		log.Printf("WARNING: Nonce store %s unreadable (%v). Payloads issued before startup will be refused.", path, err)
+	}
+	if s.seen == nil {
+		s.seen = make(map[string]int64)
+	}
+	return s
+}
+
+// accept inregistreaza nonce-ul; intoarce fals daca a mai fost folosit
+func (s *nonceStore) accept(nonce string, issuedAt, expiresAt int64, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if issuedAt < s.minIssued {
+		return false, nil
+	}
+	for n, exp := range s.seen {
+		if exp < now.Unix() {
+			delete(s.seen, n)
+		}
+	}
+	if _, used := s.seen[nonce]; used {
+		return false, nil
+	}
+	s.seen[nonce] = expiresAt
+	if err := s.save(); err != nil {
+		delete(s.seen, nonce)
+		return false, err
+	}
+	return true, nil
+}
+
+// save scrie atomic fisierul (temporar + rename), accesibil doar agentului
+func (s *nonceStore) save() error {
+	data, err := json.Marshal(s.seen)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("director stare: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("salvare nonce-uri: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,210 @@
+package collector
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"bittrail-agent/internal/crypto"
+)
+
+// Lungimea maxima a unei linii pastrate in asteptarea '\n' inainte de redactare
+const maxPendingLine = 64 * 1024
+
+// Cat ramane in asteptare dupa taierea unei linii prea lungi: mai mult decat
+// orice marcaj de cheie privata sau cuvant cheie de secret, ca un tipar inca
+// incomplet la finalul bufferului sa nu fie taiat
+const pendingOverlap = 256
+
+// Blocurile de chei private pot acoperi mai multe linii; continutul lor nu e emis
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+const redactedPrivateKey = "[REDACTED PRIVATE KEY]"
+
+// cappedBuffer redacteaza secretele pe masura ce fluxul soseste, pastreaza
+// primii limit octeti redactati si calculeaza SHA-256 si dimensiunea pe
+// intregul flux redactat (inclusiv partea trunchiata)
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+	total int64
+	hash  hash.Hash
+
+	pending []byte // linie incompleta, inca neredactata
+	inKey   bool   // in interiorul unui bloc de cheie privata
+}
+
+func newCappedBuffer(limit int) *cappedBuffer {
+	return &cappedBuffer{limit: limit, hash: sha256.New()}
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	c.pending = append(c.pending, p...)
+	for {
+		i := bytes.IndexByte(c.pending, '\n')
+		if i < 0 {
+			if len(c.pending) < maxPendingLine {
+				break
+			}
+			// Linie prea lunga: taiata doar intre secrete; fara un punct
+			// sigur, bufferul intreg e redactat ca o singura linie
+			cut := splitPoint(string(c.pending))
+			if cut == 0 {
+				cut = len(c.pending)
+			}
+			c.redactLine(string(c.pending[:cut]))
+			c.pending = c.pending[cut:]
+			continue
+		}
+		c.redactLine(string(c.pending[:i+1]))
+		c.pending = c.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// splitPoint alege unde poate fi taiata o linie prea lunga fara a imparti un
+// secret intre doua bucati redactate separat: dupa un caracter care nu poate
+// face parte din valoarea unui secret, in afara oricarei potriviri si cu cel
+// putin pendingOverlap octeti (nu doar spatii) pastrati. 0 = niciun punct sigur.
+func splitPoint(s string) int {
+	spans := crypto.SecretSpans(s)
+	for _, re := range []*regexp.Regexp{privateKeyBegin, privateKeyEnd} {
+		spans = append(spans, re.FindAllStringIndex(s, -1)...)
+	}
+	// Spatiile de la final pot separa un cuvant cheie de '=' inca nesosit
+	last := len(strings.TrimRightFunc(s, unicode.IsSpace))
+next:
+	for i := min(len(s)-pendingOverlap, last-1); i > 0; i-- {
+		if isSecretValueChar(s[i-1]) {
+			continue
+		}
+		for _, span := range spans {
+			if span[0] < i && i < span[1] {
+				continue next
+			}
+		}
+		return i
+	}
+	return 0
+}
+
+// isSecretValueChar indica octetii care pot aparea in valoarea unui secret
+func isSecretValueChar(b byte) bool {
+	switch {
+	case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9':
+		return true
+	}
+	return strings.IndexByte("_-.@!", b) >= 0
+}
+
+// flush redacteaza ultima linie (fara '\n' final)
+func (c *cappedBuffer) flush() {
+	if len(c.pending) > 0 {
+		c.redactLine(string(c.pending))
+		c.pending = nil
+	}
+}
+
+// redactLine elimina cheile private si secretele dintr-o linie si o adauga
+// la fluxul pastrat
+func (c *cappedBuffer) redactLine(line string) {
+	if c.inKey {
+		end := privateKeyEnd.FindStringIndex(line)
+		if end == nil {
+			return
+		}
+		c.inKey = false
+		line = line[end[1]:]
+	}
+
+	var out strings.Builder
+	for {
+		begin := privateKeyBegin.FindStringIndex(line)
+		if begin == nil {
+			out.WriteString(crypto.RedactSecrets(line))
+			break
+		}
+		out.WriteString(crypto.RedactSecrets(line[:begin[0]]))
+		out.WriteString(redactedPrivateKey)
+		rest := line[begin[1]:]
+		end := privateKeyEnd.FindStringIndex(rest)
+		if end == nil {
+			c.inKey = true
+			if strings.HasSuffix(rest, "\n") {
+				out.WriteString("\n")
+			}
+			break
+		}
+		line = rest[end[1]:]
+	}
+	c.emit([]byte(out.String()))
+}
+
+func (c *cappedBuffer) emit(p []byte) {
+	c.total += int64(len(p))
+	c.hash.Write(p)
+	if room := c.limit - c.buf.Len(); room > 0 {
+		if len(p) > room {
+			c.buf.Write(p[:room])
+		} else {
+			c.buf.Write(p)
+		}
+	}
+}
+
+func (c *cappedBuffer) truncated() bool {
+	return c.total > int64(c.buf.Len())
+}
+
+func (c *cappedBuffer) String() string {
+	return c.buf.String()
+}
+
+// Sum intoarce hash-ul hex al fluxului redactat complet
+func (c *cappedBuffer) Sum() string {
+	return hex.EncodeToString(c.hash.Sum(nil))
+}
+
+// checkOutput contine iesirea capturata a unei verificari
+type checkOutput struct {
+	Stdout     string
+	Stderr     string
+	StdoutHash string // SHA-256 pe stdout redactat complet, inainte de trunchiere
+	StdoutSize int64
+	StderrSize int64
+	ExitCode   int
+}
+
+func (o *checkOutput) stdoutTruncated() bool {
+	return o.StdoutSize > int64(len(o.Stdout))
+}
+
+func (o *checkOutput) stderrTruncated() bool {
+	return o.StderrSize > int64(len(o.Stderr))
+}
+
+func newCheckOutput(stdout, stderr *cappedBuffer, exitCode int) *checkOutput {
+	stdout.flush()
+	stderr.flush()
+	return &checkOutput{
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+		StdoutHash: stdout.Sum(),
+		StdoutSize: stdout.total,
+		StderrSize: stderr.total,
+		ExitCode:   exitCode,
+	}
+}
+
+// truncationMarker e adaugat la iesirea trunchiata
+func truncationMarker(kept int, total int64) string {
+	return fmt.Sprintf("\n...[TRUNCATED: %d of %d bytes]", kept, total)
+}
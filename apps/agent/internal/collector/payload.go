@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/crypto"
+)
+
+// openedCheck e o verificare primita de la backend, dupa verificarea
+// payload-ului semnat
+type openedCheck struct {
+	api.PendingCheck
+	signed bool  // payload semnat de backend, verificat
+	err    error // refuzata inainte de executie (semnatura, expirare, reluare)
+}
+
+// openCheck verifica payload-ul semnat si intoarce verificarea decodata din
+// el; campurile din afara payload-ului sunt ignorate. Fara cheie backend
+// (mod verify) verificarea ruleaza nesemnata, ca inainte.
+func (ar *AuditRunner) openCheck(raw api.PendingCheck, now time.Time) openedCheck {
+	if ar.backendKeyErr != nil {
+		return openedCheck{PendingCheck: raw, err: blocked(api.ReasonSignatureMissing, ar.backendKeyErr)}
+	}
+	if len(ar.backendKey) == 0 {
+		return openedCheck{PendingCheck: raw}
+	}
+
+	check, err := ar.verifyPayload(raw, now)
+	if err != nil {
+		return openedCheck{PendingCheck: raw, err: err}
+	}
+	return openedCheck{PendingCheck: check, signed: true}
+}
+
+func (ar *AuditRunner) verifyPayload(raw api.PendingCheck, now time.Time) (api.PendingCheck, error) {
+	if raw.Payload == "" || raw.Signature == "" {
+		return raw, blocked(api.ReasonSignatureMissing, errors.New("verificare fara payload semnat"))
+	}
+	payload, err := base64.StdEncoding.DecodeString(raw.Payload)
+	if err != nil {
+		return raw, blocked(api.ReasonSignatureInvalid, fmt.Errorf("payload invalid: %w", err))
+	}
+	if err := crypto.VerifySignature(ar.backendKey, payload, raw.Signature); err != nil {
+		return raw, blocked(api.ReasonSignatureInvalid, fmt.Errorf("semnatura invalida: %w", err))
+	}
+
+	var check api.PendingCheck
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	if err := dec.Decode(&check); err != nil {
+		return raw, blocked(api.ReasonSignatureInvalid, fmt.Errorf("payload invalid: %w", err))
+	}
+	check.Payload, check.Signature = raw.Payload, raw.Signature
+
+	if check.ServerID != ar.serverID {
+		return raw, blocked(api.ReasonSignatureInvalid, fmt.Errorf("payload emis pentru serverul %q", check.ServerID))
+	}
+	if check.IssuedAt == 0 || check.ExpiresAt == 0 || check.Nonce == "" {
+		return raw, blocked(api.ReasonSignatureInvalid, errors.New("payload fara issuedAt, expiresAt sau nonce"))
+	}
+	skew := int64(ar.clockSkew / time.Second)
+	if check.IssuedAt > now.Unix()+skew {
+		return raw, blocked(api.ReasonSignatureInvalid, fmt.Errorf("payload emis in viitor (%s)", time.Unix(check.IssuedAt, 0).UTC().Format(time.RFC3339)))
+	}
+	if check.ExpiresAt-check.IssuedAt > int64(ar.maxValidity/time.Second) {
+		return raw, blocked(api.ReasonSignatureInvalid, fmt.Errorf("valabilitate payload peste %s", ar.maxValidity))
+	}
+	if check.ExpiresAt+skew < now.Unix() {
+		return raw, blocked(api.ReasonSignatureExpired, fmt.Errorf("payload expirat la %s", time.Unix(check.ExpiresAt, 0).UTC().Format(time.RFC3339)))
+	}
+
+	fresh, err := ar.nonces.accept(check.Nonce, check.IssuedAt, check.ExpiresAt+skew, now)
+	if err != nil {
+		return raw, blocked(api.ReasonReplayed, fmt.Errorf("nonce neinregistrat: %w", err))
+	}
+	if !fresh {
+		return raw, blocked(api.ReasonReplayed, fmt.Errorf("nonce %s deja folosit", check.Nonce))
+	}
+	return check, nil
+}
@@ -0,0 +1,168 @@
+package collector
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/crypto"
+)
+
+func signedTestRunner(t *testing.T) (*AuditRunner, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar := &AuditRunner{
+		serverID:    "srv-1",
+		backendKey:  pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+		clockSkew:   time.Minute,
+		maxValidity: time.Hour,
+		nonces:      newNonceStore(filepath.Join(t.TempDir(), "nonces.json"), time.Now()),
+	}
+	return ar, key
+}
+
+// signCheck reproduce semnarea backend-ului: JSON canonic, semnat ca atare
+func signCheck(t *testing.T, key *rsa.PrivateKey, check api.PendingCheck) api.PendingCheck {
+	t.Helper()
+	payload, err := json.Marshal(check)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := crypto.SignData(key, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check.Payload = base64.StdEncoding.EncodeToString(payload)
+	check.Signature = sig
+	return check
+}
+
+func reasonOf(err error) string {
+	var ce *checkError
+	if errors.As(err, &ce) {
+		return ce.reason
+	}
+	return ""
+}
+
+func TestOpenCheck(t *testing.T) {
+	ar, key := signedTestRunner(t)
+	now := time.Now()
+	base := api.PendingCheck{
+		AuditRunID: "run-1", AutomatedCheckID: "ac-1", CheckID: "c1",
+		Command: "uname -r", ExpectedResult: "6.1", ServerID: "srv-1",
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(15 * time.Minute).Unix(),
+	}
+	nonce := 0
+	fresh := func(mutate func(*api.PendingCheck)) api.PendingCheck {
+		check := base
+		nonce++
+		check.Nonce = fmt.Sprintf("n%d", nonce)
+		if mutate != nil {
+			mutate(&check)
+		}
+		return signCheck(t, key, check)
+	}
+
+	valid := fresh(nil)
+	opened := ar.openCheck(valid, now)
+	if opened.err != nil || !opened.signed || opened.Command != "uname -r" {
+		t.Fatalf("payload valid refuzat: %+v", opened)
+	}
+	if again := ar.openCheck(valid, now); reasonOf(again.err) != api.ReasonReplayed {
+		t.Errorf("reluare: %v", again.err)
+	}
+
+	// Campurile din afara payload-ului nu pot schimba verificarea
+	outer := fresh(nil)
+	outer.Command = "rm -rf /"
+	if opened := ar.openCheck(outer, now); opened.err != nil || opened.Command != "uname -r" {
+		t.Errorf("camp exterior folosit: %q, %v", opened.Command, opened.err)
+	}
+
+	tampered := fresh(nil)
+	payload, _ := base64.StdEncoding.DecodeString(tampered.Payload)
+	var changed api.PendingCheck
+	json.Unmarshal(payload, &changed)
+	changed.ExpectedResult = "anything"
+	payload, _ = json.Marshal(changed)
+	tampered.Payload = base64.StdEncoding.EncodeToString(payload)
+
+	unsigned := base
+	unsigned.Nonce = "unsigned"
+
+	tests := []struct {
+		name   string
+		check  api.PendingCheck
+		reason string
+	}{
+		{"nesemnat", unsigned, api.ReasonSignatureMissing},
+		{"modificat", tampered, api.ReasonSignatureInvalid},
+		{"alt server", fresh(func(c *api.PendingCheck) { c.ServerID = "srv-2" }), api.ReasonSignatureInvalid},
+		{"fara nonce", fresh(func(c *api.PendingCheck) { c.Nonce = "" }), api.ReasonSignatureInvalid},
+		{"expirat", fresh(func(c *api.PendingCheck) {
+			c.IssuedAt = now.Add(-time.Hour).Unix()
+			c.ExpiresAt = now.Add(-30 * time.Minute).Unix()
+		}), api.ReasonSignatureExpired},
+		{"viitor", fresh(func(c *api.PendingCheck) {
+			c.IssuedAt = now.Add(time.Hour).Unix()
+			c.ExpiresAt = now.Add(time.Hour + time.Minute).Unix()
+		}), api.ReasonSignatureInvalid},
+		{"valabilitate lunga", fresh(func(c *api.PendingCheck) { c.ExpiresAt = now.Add(48 * time.Hour).Unix() }), api.ReasonSignatureInvalid},
+	}
+	for _, tt := range tests {
+		if opened := ar.openCheck(tt.check, now); reasonOf(opened.err) != tt.reason {
+			t.Errorf("%s: %v, vrem %s", tt.name, opened.err, tt.reason)
+		}
+	}
+}
+
+func TestOpenCheckWithoutKey(t *testing.T) {
+	check := api.PendingCheck{CheckID: "c1", Command: "uname -r"}
+
+	ar := &AuditRunner{}
+	if opened := ar.openCheck(check, time.Now()); opened.err != nil || opened.signed {
+		t.Errorf("fara cheie (verify): %+v", opened)
+	}
+
+	strict := &AuditRunner{backendKeyErr: errors.New("mod strict: backend_key_file nu e configurat")}
+	if opened := strict.openCheck(check, time.Now()); reasonOf(opened.err) != api.ReasonSignatureMissing {
+		t.Errorf("fara cheie (strict): %v", opened.err)
+	}
+}
+
+func TestNonceStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonces.json")
+	now := time.Now()
+	exp := now.Add(time.Hour).Unix()
+
+	store := newNonceStore(path, now)
+	if ok, err := store.accept("n1", now.Unix(), exp, now); !ok || err != nil {
+		t.Fatalf("accept: %t, %v", ok, err)
+	}
+
+	// Dupa repornire, nonce-ul ramane folosit pana la expirare
+	restarted := newNonceStore(path, now)
+	if ok, _ := restarted.accept("n1", now.Unix(), exp, now); ok {
+		t.Error("nonce reluat dupa repornire")
+	}
+	later := now.Add(2 * time.Hour)
+	if ok, _ := restarted.accept("n1", later.Unix(), later.Add(time.Hour).Unix(), later); !ok {
+		t.Error("nonce expirat nu a fost eliberat")
+	}
+}
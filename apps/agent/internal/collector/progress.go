@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"bittrail-agent/internal/api"
+)
+
+// errRunCancelled e cauza anularii contextului unei rulari anulate din backend
+var errRunCancelled = errors.New("rulare de audit anulata")
+
+// runState urmareste o rulare de audit intr-un job: contextul ei (anulat la
+// semnalul backend-ului), limita de concurenta si progresul raportat
+type runState struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	slots  chan struct{}
+
+	mu        sync.Mutex
+	total     int
+	completed int
+	running   map[string]bool // checkId-uri in executie
+}
+
+func newRunState(concurrency int) *runState {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	return &runState{
+		ctx:     ctx,
+		cancel:  cancel,
+		slots:   make(chan struct{}, concurrency),
+		running: make(map[string]bool),
+	}
+}
+
+// newRunStates creeaza starea fiecarei rulari din job
+func (ar *AuditRunner) newRunStates(checks []openedCheck) map[string]*runState {
+	runs := make(map[string]*runState)
+	for _, check := range checks {
+		run, ok := runs[check.AuditRunID]
+		if !ok {
+			run = newRunState(ar.runConcurrency)
+			runs[check.AuditRunID] = run
+		}
+		run.total++
+	}
+	return runs
+}
+
+func (r *runState) start(checkID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running[checkID] = true
+}
+
+func (r *runState) finish(checkID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.running, checkID)
+	r.completed++
+}
+
+func (r *runState) progress(state string) api.RunProgress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	running := make([]string, 0, len(r.running))
+	for id := range r.running {
+		running = append(running, id)
+	}
+	sort.Strings(running)
+	return api.RunProgress{
+		State:     state,
+		Total:     r.total,
+		Completed: r.completed,
+		Running:   running,
+		Time:      time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// reportProgress trimite heartbeat-ul rularii; daca backend-ul a anulat
+// rularea, verificarile ramase nu mai pornesc, iar cele in executie sunt
+// oprite (grupul de procese primeste SIGTERM, apoi SIGKILL)
+func (ar *AuditRunner) reportProgress(runID string, run *runState, state string) {
+	ack, err := ar.client.SendRunProgress(runID, run.progress(state))
+	if err != nil {
+		log.Printf("Failed to send progress for run %s: %v", runID, err)
+		return
+	}
+	if ack.Cancel && run.ctx.Err() == nil {
+		log.Printf("Audit run %s cancelled by backend (%s). Aborting remaining checks.", runID, ack.Status)
+		run.cancel(errRunCancelled)
+	}
+}
+
+// heartbeat raporteaza "running" pentru rularile active la fiecare
+// interval, pana la inchiderea lui done
+func (ar *AuditRunner) heartbeat(runs map[string]*runState, done <-chan struct{}) {
+	if ar.heartbeatInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(ar.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			for runID, run := range runs {
+				if runID != adhocRunID && run.ctx.Err() == nil {
+					ar.reportProgress(runID, run, api.ProgressRunning)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/journal"
+	"bittrail-agent/internal/policy"
+)
+
+func progressTestRunner(t *testing.T, backend *fakeBackend) (*AuditRunner, *journal.Journal) {
+	t.Helper()
+	srv := httptest.NewServer(backend)
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	jrnl, err := journal.Open(filepath.Join(dir, "journal.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &AuditRunner{
+		client:         api.NewClient(srv.URL, "srv-1", "token", nil),
+		serverID:       "srv-1",
+		journal:        jrnl,
+		ledger:         newLedger(filepath.Join(dir, "ledger.json"), time.Hour, time.Now()),
+		checkpoint:     loadCheckpoint(filepath.Join(dir, "checkpoint.json")),
+		workers:        1,
+		runConcurrency: 1,
+	}, jrnl
+}
+
+func TestCheckAndRunProgress(t *testing.T) {
+	backend := &fakeBackend{
+		checks: []api.PendingCheck{
+			{AuditRunID: "run-1", AutomatedCheckID: "ac-1", CheckID: "c1", CheckType: "MANUAL"},
+			{AuditRunID: "run-1", AutomatedCheckID: "ac-2", CheckID: "c2", CheckType: "MANUAL"},
+		},
+		stored: make(map[string]api.CheckResult),
+	}
+	ar, _ := progressTestRunner(t, backend)
+	if err := ar.CheckAndRun(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backend.progress) != 2 {
+		t.Fatalf("heartbeat-uri: %+v", backend.progress)
+	}
+	started, finished := backend.progress[0], backend.progress[1]
+	if started.State != api.ProgressStarted || started.Total != 2 || started.Completed != 0 {
+		t.Errorf("started: %+v", started)
+	}
+	if finished.State != api.ProgressFinished || finished.Completed != 2 || len(finished.Running) != 0 {
+		t.Errorf("finished: %+v", finished)
+	}
+	if len(backend.stored) != 2 {
+		t.Errorf("rezultate salvate: %d", len(backend.stored))
+	}
+}
+
+func TestCheckAndRunCancelled(t *testing.T) {
+	backend := &fakeBackend{
+		checks: []api.PendingCheck{
+			{AuditRunID: "run-1", AutomatedCheckID: "ac-1", CheckID: "c1", CheckType: "MANUAL"},
+		},
+		stored:    make(map[string]api.CheckResult),
+		cancelled: true,
+	}
+	ar, jrnl := progressTestRunner(t, backend)
+	if err := ar.CheckAndRun(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Anulata la "started": nimic executat, nimic trimis
+	if head := jrnl.Head(); head.Seq != 0 {
+		t.Errorf("executii dupa anulare: %d", head.Seq)
+	}
+	if backend.uploads != 0 || len(ar.ledger.unacked()) != 0 {
+		t.Errorf("rezultate trimise dupa anulare: %d", backend.uploads)
+	}
+}
+
+func TestRunPoolCancelStopsRemaining(t *testing.T) {
+	ar := &AuditRunner{
+		checkpoint:     loadCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json")),
+		workers:        1,
+		runConcurrency: 1,
+	}
+	checks := []openedCheck{
+		{PendingCheck: api.PendingCheck{AuditRunID: "run-1", CheckID: "c1", CheckType: "MANUAL"}},
+		{PendingCheck: api.PendingCheck{AuditRunID: "run-1", CheckID: "c2", CheckType: "MANUAL"}},
+		{PendingCheck: api.PendingCheck{AuditRunID: "run-2", CheckID: "c3", CheckType: "MANUAL"}},
+	}
+	runs := ar.newRunStates(checks)
+
+	var done []string
+	ar.runPool(checks, runs, func(check openedCheck, result api.CheckResult) {
+		done = append(done, check.CheckID)
+		if check.CheckID == "c1" {
+			runs["run-1"].cancel(errRunCancelled)
+		}
+	})
+	if len(done) != 2 || done[0] != "c1" || done[1] != "c3" {
+		t.Errorf("executate: %v (c2 trebuia oprita, run-2 continua)", done)
+	}
+}
+
+func TestRunCancelKillsProcessGroup(t *testing.T) {
+	run := newRunState(1)
+	ctx, cancel := context.WithTimeout(run.ctx, time.Minute)
+	defer cancel()
+
+	// Procesul nepot (sleep din sh) trebuie oprit odata cu grupul
+	cmd := exec.Command("/bin/sh", "-c", "sleep 30; echo done")
+	time.AfterFunc(100*time.Millisecond, func() { run.cancel(errRunCancelled) })
+	start := time.Now()
+	err := runProcessGroup(ctx, cmd, 100*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("procesul nu a fost oprit (%s)", elapsed)
+	}
+	if status, reason := classifyError(ctx, err); status != api.StatusError || reason != api.ReasonCancelled {
+		t.Errorf("anulare: %s/%s (%v)", status, reason, err)
+	}
+}
+
+// poolTestRunner ruleaza verificari COMMAND reale (sleep), fara sandbox
+func poolTestRunner(t *testing.T, workers int) *AuditRunner {
+	t.Helper()
+	pol, err := policy.Parse([]byte("programs: [sleep]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &AuditRunner{
+		checkpoint:     loadCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json")),
+		policy:         pol,
+		maxOutput:      1024,
+		defaultTimeout: 10 * time.Second,
+		killGrace:      100 * time.Millisecond,
+		workers:        workers,
+		runConcurrency: workers,
+	}
+}
+
+// sampleRunning urmareste verificarile in executie din toate rularile pana
+// la stop si intoarce maximul simultan si suprapunerile verificarilor serial
+func sampleRunning(runs map[string]*runState, serial map[string]bool, stop <-chan struct{}) (max int, overlaps []string) {
+	for {
+		var ids []string
+		for _, run := range runs {
+			ids = append(ids, run.progress("running").Running...)
+		}
+		n := len(ids)
+		if n > max {
+			max = n
+		}
+		for _, id := range ids {
+			if serial[id] && n > 1 {
+				overlaps = append(overlaps, fmt.Sprintf("%s cu %v", id, ids))
+			}
+		}
+		select {
+		case <-stop:
+			return max, overlaps
+		case <-time.After(2 * time.Millisecond):
+		}
+	}
+}
+
+func TestRunPoolCapsConcurrency(t *testing.T) {
+	ar := poolTestRunner(t, 2)
+	var checks []openedCheck
+	for i := 0; i < 6; i++ {
+		checks = append(checks, openedCheck{PendingCheck: api.PendingCheck{
+			AuditRunID: fmt.Sprintf("run-%d", i%2), CheckID: fmt.Sprintf("c%d", i), Command: "sleep 0.2",
+		}})
+	}
+	runs := ar.newRunStates(checks)
+
+	stop := make(chan struct{})
+	var max int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		max, _ = sampleRunning(runs, nil, stop)
+	}()
+	var mu sync.Mutex
+	var results []api.CheckResult
+	ar.runPool(checks, runs, func(check openedCheck, result api.CheckResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, result)
+	})
+	close(stop)
+	wg.Wait()
+
+	if len(results) != len(checks) {
+		t.Fatalf("rezultate: %d, asteptat %d", len(results), len(checks))
+	}
+	for _, r := range results {
+		if r.Status != api.StatusPass {
+			t.Errorf("%s: %s %s", r.AutomatedCheckID, r.Status, r.ErrorMessage)
+		}
+	}
+	if max > 2 {
+		t.Errorf("%d verificari simultane, limita audit_workers 2", max)
+	}
+	if max < 2 {
+		t.Errorf("verificarile nu au rulat in paralel (maxim %d)", max)
+	}
+}
+
+func TestRunPoolSerialChecksRunAlone(t *testing.T) {
+	ar := poolTestRunner(t, 4)
+	serial := map[string]bool{"s1": true, "s2": true}
+	var checks []openedCheck
+	for _, id := range []string{"c1", "s1", "c2", "c3", "s2", "c4", "c5"} {
+		checks = append(checks, openedCheck{PendingCheck: api.PendingCheck{
+			AuditRunID: "run-1", CheckID: id, Command: "sleep 0.1", Serial: serial[id],
+		}})
+	}
+	runs := ar.newRunStates(checks)
+
+	stop := make(chan struct{})
+	var max int
+	var overlaps []string
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		max, overlaps = sampleRunning(runs, serial, stop)
+	}()
+	var mu sync.Mutex
+	var done int
+	ar.runPool(checks, runs, func(_ openedCheck, result api.CheckResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		if result.Status == api.StatusPass {
+			done++
+		}
+	})
+	close(stop)
+	wg.Wait()
+
+	if done != len(checks) {
+		t.Fatalf("executate %d din %d", done, len(checks))
+	}
+	if len(overlaps) > 0 {
+		t.Errorf("verificari serial suprapuse: %v", overlaps[0])
+	}
+	if max < 2 {
+		t.Errorf("verificarile normale nu au rulat in paralel (maxim %d)", max)
+	}
+}
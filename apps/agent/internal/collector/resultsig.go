@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/crypto"
+)
+
+// Versiunea structurii canonice semnate a rezultatelor; v3 acopera si
+// exceptia (waiver) aplicata. v2 (fara waiver) e acceptata doar la
+// verificarea jurnalelor scrise de agenti mai vechi.
+const (
+	resultSignatureVersion       = 3
+	resultSignatureVersionLegacy = 2
+)
+
+// signedResult e structura canonica semnata: leaga rezultatul de server,
+// rulare si verificare, ca semnatura sa nu poata fi mutata pe alt rezultat.
+// Campurile sunt in ordine alfabetica, ca in serializarea canonica a
+// backend-ului (chei sortate); nu schimba ordinea fara a creste versiunea.
+type signedResult struct {
+	Alg              string        `json:"alg"`
+	AuditRunID       string        `json:"auditRunId"`
+	AutomatedCheckID string        `json:"automatedCheckId"`
+	CheckID          string        `json:"checkId"`
+	ExitCode         int           `json:"exitCode"`
+	Hostname         string        `json:"hostname"`
+	OutputHash       string        `json:"outputHash"`
+	ReasonCode       string        `json:"reasonCode"`
+	ServerID         string        `json:"serverId"`
+	Status           string        `json:"status"`
+	Timestamp        string        `json:"timestamp"`
+	User             string        `json:"user"`
+	Version          int           `json:"v"`
+	Waiver           *signedWaiver `json:"waiver,omitempty"`
+}
+
+// signedWaiver sunt metadatele exceptiei acoperite de semnatura, tot in
+// ordine alfabetica; fara ele un intermediar ar putea schimba aprobatorul,
+// expirarea sau statusul evaluat fara sa invalideze rezultatul WAIVED
+type signedWaiver struct {
+	Approver        string `json:"approver"`
+	EvaluatedStatus string `json:"evaluatedStatus"`
+	Expired         bool   `json:"expired"`
+	ExpiresAt       string `json:"expiresAt"`
+	ID              string `json:"id"`
+	Justification   string `json:"justification"`
+}
+
+// resultSignatureData intoarce JSON-ul canonic semnat pentru rezultat
+func resultSignatureData(serverID string, check api.PendingCheck, result *api.CheckResult) ([]byte, error) {
+	return resultSignatureDataVersion(serverID, check, result, resultSignatureVersion)
+}
+
+// resultSignatureDataVersion intoarce structura canonica pentru o versiune
+// anume (v2 nu contine exceptia)
+func resultSignatureDataVersion(serverID string, check api.PendingCheck, result *api.CheckResult, version int) ([]byte, error) {
+	var waiver *signedWaiver
+	if result.Waiver != nil && version >= 3 {
+		waiver = &signedWaiver{
+			Approver:        result.Waiver.Approver,
+			EvaluatedStatus: result.Waiver.EvaluatedStatus,
+			Expired:         result.Waiver.Expired,
+			ExpiresAt:       result.Waiver.ExpiresAt,
+			ID:              result.Waiver.ID,
+			Justification:   result.Waiver.Justification,
+		}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(signedResult{
+		Alg:              crypto.AlgRSAPSSSHA256,
+		AuditRunID:       check.AuditRunID,
+		AutomatedCheckID: result.AutomatedCheckID,
+		CheckID:          check.CheckID,
+		ExitCode:         result.ExitCode,
+		Hostname:         result.ExecHostname,
+		OutputHash:       result.OutputHash,
+		ReasonCode:       result.ReasonCode,
+		ServerID:         serverID,
+		Status:           result.Status,
+		Timestamp:        result.ExecTimestamp,
+		User:             result.ExecUser,
+		Version:          version,
+		Waiver:           waiver,
+	})
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), err
+}
+
+// signResult semneaza structura canonica a rezultatului cu RSA-PSS
+func (ar *AuditRunner) signResult(check api.PendingCheck, result *api.CheckResult) {
+	result.CheckID = check.CheckID
+	if ar.privateKey == nil {
+		return
+	}
+	data, err := resultSignatureData(ar.serverID, check, result)
+	if err != nil {
+		log.Printf("Error signing result: %v", err)
+		return
+	}
+	sig, err := crypto.SignDataPSS(ar.privateKey, data)
+	if err != nil {
+		log.Printf("Error signing result: %v", err)
+		return
+	}
+	result.Signature = sig
+	result.SignatureAlg = crypto.AlgRSAPSSSHA256
+	result.SignatureVersion = resultSignatureVersion
+}
@@ -0,0 +1,170 @@
+package collector
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+
+	"bittrail-agent/internal/api"
+	agentcrypto "bittrail-agent/internal/crypto"
+	"bittrail-agent/internal/journal"
+)
+
+func TestResultSignatureData(t *testing.T) {
+	check := api.PendingCheck{AuditRunID: "run-1", CheckID: "c1"}
+	result := api.CheckResult{
+		AutomatedCheckID: "ac-1", Status: api.StatusFail, OutputHash: "ab",
+		ExecTimestamp: "2026-10-18T12:00:00Z", ExecHostname: "web<1>", ExecUser: "root", ExitCode: 1,
+	}
+
+	// Formatul e verificat de backend: chei sortate, fara escape HTML
+	want := `{"alg":"RSA-PSS-SHA256","auditRunId":"run-1","automatedCheckId":"ac-1","checkId":"c1","exitCode":1,` +
+		`"hostname":"web<1>","outputHash":"ab","reasonCode":"","serverId":"srv-1","status":"FAIL",` +
+		`"timestamp":"2026-10-18T12:00:00Z","user":"root","v":3}`
+	data, err := resultSignatureData("srv-1", check, &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("structura canonica\n got %s\nwant %s", data, want)
+	}
+
+	for name, mutate := range map[string]func(*api.PendingCheck, *api.CheckResult){
+		"auditRunId": func(c *api.PendingCheck, r *api.CheckResult) { c.AuditRunID = "run-2" },
+		"checkId":    func(c *api.PendingCheck, r *api.CheckResult) { c.CheckID = "c2" },
+		"exitCode":   func(c *api.PendingCheck, r *api.CheckResult) { r.ExitCode = 0 },
+		"hostname":   func(c *api.PendingCheck, r *api.CheckResult) { r.ExecHostname = "web2" },
+		"user":       func(c *api.PendingCheck, r *api.CheckResult) { r.ExecUser = "nobody" },
+	} {
+		c, r := check, result
+		mutate(&c, &r)
+		if changed, _ := resultSignatureData("srv-1", c, &r); string(changed) == want {
+			t.Errorf("%s nu e acoperit de semnatura", name)
+		}
+	}
+	if other, _ := resultSignatureData("srv-2", check, &result); string(other) == want {
+		t.Error("serverId nu e acoperit de semnatura")
+	}
+}
+
+func TestResultSignatureDataWaiver(t *testing.T) {
+	check := api.PendingCheck{AuditRunID: "run-1", CheckID: "c1"}
+	result := api.CheckResult{
+		AutomatedCheckID: "ac-1", Status: api.StatusWaived, OutputHash: "ab",
+		ExecTimestamp: "2026-10-18T12:00:00Z", ExecHostname: "web1", ExecUser: "nobody",
+		Waiver: &api.WaiverInfo{
+			ID: "w-1", Justification: "sistem legacy", Approver: "secops",
+			ExpiresAt: "2026-12-31T00:00:00Z", EvaluatedStatus: api.StatusFail,
+		},
+	}
+
+	want := `{"alg":"RSA-PSS-SHA256","auditRunId":"run-1","automatedCheckId":"ac-1","checkId":"c1","exitCode":0,` +
+		`"hostname":"web1","outputHash":"ab","reasonCode":"","serverId":"srv-1","status":"WAIVED",` +
+		`"timestamp":"2026-10-18T12:00:00Z","user":"nobody","v":3,` +
+		`"waiver":{"approver":"secops","evaluatedStatus":"FAIL","expired":false,"expiresAt":"2026-12-31T00:00:00Z",` +
+		`"id":"w-1","justification":"sistem legacy"}}`
+	data, err := resultSignatureData("srv-1", check, &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("structura canonica\n got %s\nwant %s", data, want)
+	}
+
+	for name, mutate := range map[string]func(*api.WaiverInfo){
+		"id":              func(w *api.WaiverInfo) { w.ID = "w-2" },
+		"justification":   func(w *api.WaiverInfo) { w.Justification = "altceva" },
+		"approver":        func(w *api.WaiverInfo) { w.Approver = "intrus" },
+		"expiresAt":       func(w *api.WaiverInfo) { w.ExpiresAt = "2030-01-01T00:00:00Z" },
+		"evaluatedStatus": func(w *api.WaiverInfo) { w.EvaluatedStatus = api.StatusPass },
+		"expired":         func(w *api.WaiverInfo) { w.Expired = true },
+	} {
+		r, w := result, *result.Waiver
+		mutate(&w)
+		r.Waiver = &w
+		if changed, _ := resultSignatureData("srv-1", check, &r); string(changed) == want {
+			t.Errorf("waiver.%s nu e acoperit de semnatura", name)
+		}
+	}
+	r := result
+	r.Waiver = nil
+	if changed, _ := resultSignatureData("srv-1", check, &r); string(changed) == want {
+		t.Error("eliminarea exceptiei nu e acoperita de semnatura")
+	}
+}
+
+func TestSignResult(t *testing.T) {
+	key, err := agentcrypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar := &AuditRunner{privateKey: key, serverID: "srv-1"}
+	check := api.PendingCheck{AuditRunID: "run-1", CheckID: "c1"}
+	result := api.CheckResult{AutomatedCheckID: "ac-1", Status: api.StatusPass, OutputHash: "ab"}
+	ar.signResult(check, &result)
+
+	if result.CheckID != "c1" || result.SignatureAlg != agentcrypto.AlgRSAPSSSHA256 || result.SignatureVersion != 3 {
+		t.Fatalf("metadate semnatura: %+v", result)
+	}
+	data, _ := resultSignatureData("srv-1", check, &result)
+	sig, _ := base64.StdEncoding.DecodeString(result.Signature)
+	hashed := sha256.Sum256(data)
+	if err := rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hashed[:], sig, nil); err != nil {
+		t.Errorf("semnatura PSS invalida: %v", err)
+	}
+
+	if err := VerifyResultSignature(&key.PublicKey, "srv-1", "run-1", result); err != nil {
+		t.Errorf("VerifyResultSignature: %v", err)
+	}
+	if err := VerifyResultSignature(&key.PublicKey, "srv-1", "run-2", result); err == nil {
+		t.Error("semnatura acceptata pe alta rulare")
+	}
+
+	// Jurnalele scrise de agenti mai vechi (v2, fara waiver) raman verificabile
+	legacy := result
+	data, _ = resultSignatureDataVersion("srv-1", check, &legacy, resultSignatureVersionLegacy)
+	if legacy.Signature, err = agentcrypto.SignDataPSS(key, data); err != nil {
+		t.Fatal(err)
+	}
+	legacy.SignatureVersion = resultSignatureVersionLegacy
+	if err := VerifyResultSignature(&key.PublicKey, "srv-1", "run-1", legacy); err != nil {
+		t.Errorf("VerifyResultSignature v2: %v", err)
+	}
+}
+
+func TestJournalResultsOmitOutput(t *testing.T) {
+	key, err := agentcrypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "journal.log")
+	jrnl, err := journal.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar := &AuditRunner{privateKey: key, serverID: "srv-1", journal: jrnl}
+	check := api.PendingCheck{AuditRunID: "run-1", CheckID: "c1"}
+	result := api.CheckResult{AutomatedCheckID: "ac-1", Status: api.StatusFail, Output: "PermitRootLogin yes", Stderr: "warn",
+		OutputHash: agentcrypto.CalculateHash("PermitRootLogin yes")}
+	ar.signResult(check, &result)
+	results := []api.CheckResult{result}
+	ar.journalResults([]openedCheck{{PendingCheck: check}}, results)
+
+	if results[0].Output == "" {
+		t.Error("rezultatul trimis la backend a pierdut iesirea")
+	}
+	var entries []journal.Entry
+	if _, err := journal.Verify(path, func(e journal.Entry) error {
+		entries = append(entries, e)
+		return VerifyResultSignature(&key.PublicKey, "srv-1", e.AuditRunID, *e.Result)
+	}); err != nil {
+		t.Fatalf("jurnal: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Result.Output != "" || entries[0].Result.Stderr != "" ||
+		entries[0].Result.OutputHash != result.OutputHash {
+		t.Errorf("intrare jurnal: %+v", entries[0].Result)
+	}
+}
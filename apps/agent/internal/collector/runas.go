@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/config"
+	"bittrail-agent/internal/sandbox"
+)
+
+// Inlocuibile in teste
+var (
+	geteuid    = os.Geteuid
+	lookupUser = user.Lookup
+	lookupID   = user.LookupId
+)
+
+// execUser e utilizatorul sub care ruleaza o verificare
+type execUser struct {
+	Name       string
+	Credential *sandbox.Credential // nil = fara schimbare (utilizatorul agentului)
+}
+
+// agentUser intoarce utilizatorul procesului agent
+func agentUser() *execUser {
+	if u, err := user.Current(); err == nil {
+		return &execUser{Name: u.Username}
+	}
+	return &execUser{Name: strconv.Itoa(os.Geteuid())}
+}
+
+// resolveExecUser alege utilizatorul verificarii: runAs din verificare sau
+// utilizatorul neprivilegiat din configurare (implicit nobody); root doar cu
+// runAs explicit. Doar un agent root poate schimba utilizatorul; altfel
+// verificarea ruleaza ca agentul. runAs e acceptat doar cu semnatura backend valida.
+func (ar *AuditRunner) resolveExecUser(check api.PendingCheck, signed bool) (*execUser, error) {
+	name := check.RunAs
+	if name != "" && !signed {
+		return nil, blocked(api.ReasonSignatureMissing, fmt.Errorf("runAs %q fara semnatura backend valida", name))
+	}
+	if name == "" {
+		name = ar.checkUser
+	}
+	if name == "" {
+		name = config.DefaultCheckUser
+	}
+	if geteuid() != 0 {
+		return agentUser(), nil
+	}
+
+	u, err := lookupExecUser(name)
+	if err != nil {
+		return nil, fmt.Errorf("utilizator executie %q: %w", name, err)
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("utilizator executie %q: uid invalid %s", name, u.Uid)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("utilizator executie %q: gid invalid %s", name, u.Gid)
+	}
+	if uid == 0 {
+		return &execUser{Name: u.Username}, nil
+	}
+
+	// Doar grupul primar, fara grupurile suplimentare ale agentului
+	return &execUser{
+		Name: u.Username,
+		Credential: &sandbox.Credential{
+			Uid:    uint32(uid),
+			Gid:    uint32(gid),
+			Groups: []uint32{},
+		},
+	}, nil
+}
+
+// lookupExecUser cauta utilizatorul dupa nume sau uid; un uid fara intrare
+// in baza de utilizatori ruleaza cu grupul egal cu uid-ul (ca docker --user)
+func lookupExecUser(name string) (*user.User, error) {
+	u, err := lookupUser(name)
+	if err == nil {
+		return u, nil
+	}
+	if _, numErr := strconv.ParseUint(name, 10, 32); numErr != nil {
+		return nil, err
+	}
+	if u, err := lookupID(name); err == nil {
+		return u, nil
+	}
+	return &user.User{Uid: name, Gid: name, Username: name}, nil
+}
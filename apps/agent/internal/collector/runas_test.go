@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"os/user"
+	"testing"
+
+	"bittrail-agent/internal/api"
+)
+
+func TestResolveExecUser(t *testing.T) {
+	users := map[string]*user.User{
+		"root":   {Uid: "0", Gid: "0", Username: "root"},
+		"nobody": {Uid: "65534", Gid: "65534", Username: "nobody"},
+		"audit":  {Uid: "1001", Gid: "1002", Username: "audit"},
+	}
+	defer func(e func() int, l, i func(string) (*user.User, error)) {
+		geteuid, lookupUser, lookupID = e, l, i
+	}(geteuid, lookupUser, lookupID)
+	lookupUser = func(name string) (*user.User, error) {
+		if u, ok := users[name]; ok {
+			return u, nil
+		}
+		return nil, user.UnknownUserError(name)
+	}
+	lookupID = func(id string) (*user.User, error) {
+		for _, u := range users {
+			if u.Uid == id {
+				return u, nil
+			}
+		}
+		return nil, user.UnknownUserIdError(0)
+	}
+
+	tests := []struct {
+		name      string
+		checkUser string
+		runAs     string
+		signed    bool
+		euid      int
+		wantName  string
+		wantUID   uint32
+		wantGID   uint32
+		wantCred  bool
+		wantErr   bool
+	}{
+		{"implicit nobody", "", "", true, 0, "nobody", 65534, 65534, true, false},
+		{"check_user din configurare", "audit", "", true, 0, "audit", 1001, 1002, true, false},
+		{"runAs cu nume", "", "audit", true, 0, "audit", 1001, 1002, true, false},
+		{"uid numeric cunoscut", "", "65534", true, 0, "nobody", 65534, 65534, true, false},
+		{"uid numeric fara intrare", "", "4242", true, 0, "4242", 4242, 4242, true, false},
+		{"utilizator necunoscut", "", "ghost", true, 0, "", 0, 0, false, true},
+		{"runAs root explicit", "", "root", true, 0, "root", 0, 0, false, false},
+		{"runAs fara semnatura", "", "root", false, 0, "", 0, 0, false, true},
+		{"agent neprivilegiat", "", "audit", true, 1000, "", 0, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geteuid = func() int { return tt.euid }
+			ar := &AuditRunner{checkUser: tt.checkUser}
+			got, err := ar.resolveExecUser(api.PendingCheck{RunAs: tt.runAs}, tt.signed)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("asteptam eroare, am primit %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("eroare neasteptata: %v", err)
+			}
+			if tt.euid != 0 {
+				if got.Credential != nil {
+					t.Errorf("agent neprivilegiat nu poate schimba utilizatorul: %+v", got.Credential)
+				}
+				return
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("Name = %q, asteptat %q", got.Name, tt.wantName)
+			}
+			if (got.Credential != nil) != tt.wantCred {
+				t.Fatalf("Credential = %+v, asteptat prezent=%v", got.Credential, tt.wantCred)
+			}
+			if got.Credential != nil && (got.Credential.Uid != tt.wantUID || got.Credential.Gid != tt.wantGID) {
+				t.Errorf("uid/gid = %d/%d, asteptat %d/%d", got.Credential.Uid, got.Credential.Gid, tt.wantUID, tt.wantGID)
+			}
+		})
+	}
+}
+
+func TestResolveExecUserUnsignedBlocked(t *testing.T) {
+	ar := &AuditRunner{}
+	_, err := ar.resolveExecUser(api.PendingCheck{RunAs: "root"}, false)
+	if reasonOf(err) != api.ReasonSignatureMissing {
+		t.Fatalf("asteptam blocare %s, am primit %v", api.ReasonSignatureMissing, err)
+	}
+}
@@ -0,0 +1,279 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"bittrail-agent/internal/api"
+)
+
+// Platform e platforma detectata a gazdei, comparata cu platformScope
+type Platform struct {
+	OS      string // linux, darwin, windows
+	Distro  string // ubuntu, redhat, rocky, debian...
+	Family  string // debian, rhel, suse...
+	Version string // 22.04, 9.3
+	Arch    string // x86_64, aarch64
+}
+
+// Nume alternative folosite in template-uri pentru distributii
+var distroAliases = map[string]string{
+	"rhel": "redhat",
+	"amzn": "amazon",
+	"ol":   "oracle",
+	"sles": "suse",
+}
+
+// Distributiile a caror versiune urmeaza RHEL: "rhel:8,9" se aplica si lor
+var rhelRebuilds = map[string]bool{
+	"redhat": true, "centos": true, "rocky": true, "almalinux": true,
+	"oracle": true, "cloudlinux": true, "scientific": true,
+}
+
+// Nume alternative pentru arhitecturi (GOARCH / Debian -> uname -m)
+var archAliases = map[string]string{
+	"amd64": "x86_64",
+	"x64":   "x86_64",
+	"arm64": "aarch64",
+	"armhf": "armv7l",
+	"386":   "i686",
+	"i386":  "i686",
+}
+
+func platformFromFacts(facts map[string]string) Platform {
+	return Platform{
+		OS:      strings.ToLower(facts["os"]),
+		Distro:  strings.ToLower(facts["platform"]),
+		Family:  strings.ToLower(facts["platformFamily"]),
+		Version: facts["platformVersion"],
+		Arch:    normalizeArch(facts["kernelArch"]),
+	}
+}
+
+func (p Platform) String() string {
+	return fmt.Sprintf("%s %s (%s)", p.Distro, p.Version, p.Arch)
+}
+
+// inScope evalueaza platformScope: verificarea se aplica daca lista e goala
+// sau daca cel putin o intrare corespunde platformei. O intrare are forma
+// distributie[operator versiune][@arhitectura], ex: ubuntu>=22.04, rhel:8,9,
+// debian@arm64, @x86_64. Operatorii: >=, <=, >, <, = si ":" (lista de versiuni).
+// Versiunea gazdei e comparata doar pe numarul de componente din intrare
+// (rhel<=8 include 8.9).
+func inScope(scope []string, p Platform) (bool, error) {
+	if len(scope) == 0 {
+		return true, nil
+	}
+	for _, entry := range scope {
+		ok, err := matchScopeEntry(entry, p)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchScopeEntry(entry string, p Platform) (bool, error) {
+	spec := strings.ToLower(strings.TrimSpace(entry))
+	spec, arch, hasArch := strings.Cut(spec, "@")
+	if hasArch {
+		if arch == "" {
+			return false, fmt.Errorf("platformScope %q: arhitectura lipsa", entry)
+		}
+		if normalizeArch(arch) != p.Arch {
+			return false, nil
+		}
+		if spec == "" {
+			return true, nil
+		}
+	}
+
+	name, op, version := spec, "", ""
+	if i := strings.IndexAny(spec, "<>=:"); i >= 0 {
+		name = strings.TrimSpace(spec[:i])
+		rest := spec[i:]
+		for _, candidate := range []string{">=", "<=", ">", "<", "==", "=", ":"} {
+			if strings.HasPrefix(rest, candidate) {
+				op, version = candidate, strings.TrimSpace(rest[len(candidate):])
+				break
+			}
+		}
+		if version == "" {
+			return false, fmt.Errorf("platformScope %q: versiune lipsa", entry)
+		}
+	}
+	if name == "" {
+		return false, fmt.Errorf("platformScope %q: distributie lipsa", entry)
+	}
+
+	if !distroMatches(name, p, op != "") {
+		return false, nil
+	}
+	if op == "" {
+		return true, nil
+	}
+
+	if op == ":" {
+		for _, v := range strings.Split(version, ",") {
+			if v = strings.TrimSpace(v); v != "" && compareScopeVersion(p.Version, v) == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	cmp := compareScopeVersion(p.Version, version)
+	switch op {
+	case ">=":
+		return cmp >= 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<":
+		return cmp < 0, nil
+	default:
+		return cmp == 0, nil
+	}
+}
+
+// distroMatches compara numele din intrare cu distributia sau familia gazdei.
+// Familia fara versiune include toate distributiile ei (debian -> ubuntu);
+// cu versiune, doar distributiile care ii urmeaza numerotarea (rhel -> rocky).
+func distroMatches(name string, p Platform, versioned bool) bool {
+	if alias, ok := distroAliases[name]; ok && alias == p.Distro {
+		return true
+	}
+	switch name {
+	case p.Distro:
+		return true
+	case "any", "*":
+		return !versioned
+	case p.OS:
+		return !versioned
+	case p.Family:
+		return !versioned || (name == "rhel" && rhelRebuilds[p.Distro])
+	}
+	return false
+}
+
+// compareScopeVersion compara versiunea gazdei cu cea din intrare, pe numarul
+// de componente al intrarii; componentele numerice se compara numeric
+func compareScopeVersion(host, want string) int {
+	hostParts := strings.Split(host, ".")
+	wantParts := strings.Split(want, ".")
+	for i, w := range wantParts {
+		h := "0"
+		if i < len(hostParts) {
+			h = hostParts[i]
+		}
+		hn, herr := strconv.Atoi(h)
+		wn, werr := strconv.Atoi(w)
+		switch {
+		case herr == nil && werr == nil:
+			if hn != wn {
+				if hn < wn {
+					return -1
+				}
+				return 1
+			}
+		case h != w:
+			if h < w {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func normalizeArch(arch string) string {
+	arch = strings.ToLower(strings.TrimSpace(arch))
+	if alias, ok := archAliases[arch]; ok {
+		return alias
+	}
+	return arch
+}
+
+// unmetRequirement intoarce prima preconditie requires neindeplinita:
+// "bin:nume" (program in PATH-ul verificarilor sau cale absoluta),
+// "pkg:nume" (pachet instalat); un nume simplu e un program
+func unmetRequirement(ctx context.Context, requires []string, snap *SystemSnapshot) (string, error) {
+	for _, req := range requires {
+		kind, name, ok := strings.Cut(strings.TrimSpace(req), ":")
+		if !ok {
+			kind, name = "bin", kind
+		}
+		if name == "" {
+			return "", fmt.Errorf("requires %q: nume lipsa", req)
+		}
+
+		switch kind {
+		case "bin":
+			if !binaryExists(name) {
+				return fmt.Sprintf("programul %s nu exista", name), nil
+			}
+		case "pkg":
+			_, packages, err := snap.installedPackages(ctx)
+			if err != nil {
+				return "", fmt.Errorf("requires %q: %w", req, err)
+			}
+			if _, installed := packages[name]; !installed {
+				return fmt.Sprintf("pachetul %s nu e instalat", name), nil
+			}
+		default:
+			return "", fmt.Errorf("requires %q: tip necunoscut %s (bin, pkg)", req, kind)
+		}
+	}
+	return "", nil
+}
+
+// binaryExists cauta programul in PATH-ul fix al verificarilor
+func binaryExists(name string) bool {
+	candidates := []string{name}
+	if !strings.Contains(name, "/") {
+		candidates = nil
+		for _, dir := range filepath.SplitList(checkPath) {
+			candidates = append(candidates, filepath.Join(dir, name))
+		}
+	}
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// notApplicableReason intoarce codul si motivul pentru care verificarea nu
+// se aplica gazdei (cod gol = se aplica); platformScope sau requires
+// invalide sunt erori de specificatie
+func (ar *AuditRunner) notApplicableReason(ctx context.Context, check api.PendingCheck, snap *SystemSnapshot) (code, message string, err error) {
+	if strings.EqualFold(check.CheckType, "FILE_CHECK") && !fileCheckSupported {
+		return api.ReasonNotApplicable, "FILE_CHECK nesuportat pe " + runtime.GOOS, nil
+	}
+	platform := platformFromFacts(ar.facts)
+	ok, err := inScope(check.PlatformScope, platform)
+	if err != nil {
+		return "", "", failedWith(api.ReasonInvalidSpec, err)
+	}
+	if !ok {
+		return api.ReasonNotApplicable, fmt.Sprintf("platformScope %v nu include %s", check.PlatformScope, platform), nil
+	}
+	message, err = unmetRequirement(ctx, check.Requires, snap)
+	if err != nil {
+		return "", "", failedWith(api.ReasonInvalidSpec, err)
+	}
+	if message != "" {
+		return api.ReasonRequirementNotMet, message, nil
+	}
+	return "", "", nil
+}
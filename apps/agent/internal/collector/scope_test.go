@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInScope(t *testing.T) {
+	ubuntu := Platform{OS: "linux", Distro: "ubuntu", Family: "debian", Version: "22.04", Arch: "x86_64"}
+	rocky := Platform{OS: "linux", Distro: "rocky", Family: "rhel", Version: "8.9", Arch: "aarch64"}
+	rhel := Platform{OS: "linux", Distro: "redhat", Family: "rhel", Version: "9.3", Arch: "x86_64"}
+	amazon := Platform{OS: "linux", Distro: "amazon", Family: "rhel", Version: "2023", Arch: "x86_64"}
+
+	tests := []struct {
+		scope    []string
+		platform Platform
+		want     bool
+	}{
+		{nil, ubuntu, true},
+		{[]string{"ubuntu"}, ubuntu, true},
+		{[]string{"Ubuntu"}, ubuntu, true},
+		{[]string{"debian"}, ubuntu, true},
+		{[]string{"debian", "ubuntu"}, rhel, false},
+		{[]string{"linux"}, rocky, true},
+		{[]string{"ubuntu>=22.04"}, ubuntu, true},
+		{[]string{"ubuntu>=24.04"}, ubuntu, false},
+		{[]string{"ubuntu<22.04"}, ubuntu, false},
+		{[]string{"ubuntu=22.04"}, ubuntu, true},
+		{[]string{"ubuntu:20.04,22.04"}, ubuntu, true},
+		{[]string{"debian>=12"}, ubuntu, false},
+		{[]string{"rhel:8,9"}, rhel, true},
+		{[]string{"rhel:8,9"}, rocky, true},
+		{[]string{"rhel:9"}, rocky, false},
+		{[]string{"rhel<=8"}, rocky, true},
+		{[]string{"rhel>8"}, rocky, false},
+		{[]string{"rhel"}, amazon, true},
+		{[]string{"rhel:8,9"}, amazon, false},
+		{[]string{"rocky@arm64"}, rocky, true},
+		{[]string{"rocky@amd64"}, rocky, false},
+		{[]string{"@x86_64"}, ubuntu, true},
+		{[]string{"ubuntu>=22.04@amd64"}, ubuntu, true},
+		{[]string{"ubuntu", "rhel:8,9"}, rocky, true},
+	}
+	for _, tt := range tests {
+		got, err := inScope(tt.scope, tt.platform)
+		if err != nil {
+			t.Errorf("%v pe %s: %v", tt.scope, tt.platform, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%v pe %s = %t, vrem %t", tt.scope, tt.platform, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"ubuntu>=", ">=22.04", "ubuntu@", "rhel:"} {
+		if _, err := inScope([]string{invalid}, ubuntu); err == nil {
+			t.Errorf("%q acceptat", invalid)
+		}
+	}
+}
+
+func TestUnmetRequirement(t *testing.T) {
+	ctx := context.Background()
+	if reason, err := unmetRequirement(ctx, []string{"sh", "bin:/bin/sh"}, nil); err != nil || reason != "" {
+		t.Errorf("sh: %q, %v", reason, err)
+	}
+	if reason, err := unmetRequirement(ctx, []string{"bin:bittrail-no-such-binary"}, nil); err != nil || reason == "" {
+		t.Errorf("program lipsa: %q, %v", reason, err)
+	}
+	if _, err := unmetRequirement(ctx, []string{"svc:sshd"}, nil); err == nil {
+		t.Error("tip necunoscut acceptat")
+	}
+}
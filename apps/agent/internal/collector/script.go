@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"bittrail-agent/internal/api"
+)
+
+// Interpretoare ale caror scripturi pot fi verificate in AST de politica
+var shellInterpreters = map[string]bool{"sh": true, "bash": true, "dash": true}
+
+// preparedScript e un script scris intr-un director privat, gata de executie
+type preparedScript struct {
+	dir  string
+	argv []string // interpretor, fisier script, argumente
+}
+
+func (s *preparedScript) remove() {
+	os.RemoveAll(s.dir)
+}
+
+// needsPinnedScript indica scripturile care pot rula doar cu amprenta
+// SHA-256 semnata: alt interpretor decat sh, argumente sau amprenta ceruta
+func needsPinnedScript(check api.PendingCheck) bool {
+	return check.ScriptSHA256 != "" || len(check.Args) > 0 ||
+		(check.Interpreter != "" && check.Interpreter != "sh")
+}
+
+// prepareScript alege interpretorul din allowlist, verifica amprenta
+// SHA-256 fixata de payload-ul semnat si scrie scriptul intr-un fisier
+// 0700 accesibil doar utilizatorului verificarii
+func (ar *AuditRunner) prepareScript(check api.PendingCheck, as *execUser, signed bool) (*preparedScript, error) {
+	bin, err := ar.policy.Interpreter(check.Interpreter)
+	if err != nil {
+		return nil, blocked(api.ReasonBlockedByPolicy, err)
+	}
+	interpreter := path.Base(bin)
+
+	// Scripturile sh simple (fara amprenta) sunt acceptate pentru compatibilitate;
+	// ele trec oricum prin politica AST
+	if needsPinnedScript(check) {
+		if !signed {
+			return nil, blocked(api.ReasonSignatureMissing, fmt.Errorf("script %s fara semnatura backend valida", check.CheckID))
+		}
+		if check.ScriptSHA256 == "" {
+			return nil, blocked(api.ReasonBlockedByPolicy, fmt.Errorf("scriptSha256 lipsa (obligatoriu pentru interpretorul %s)", check.Interpreter))
+		}
+		if !hashMatches(check.Script, check.ScriptSHA256) {
+			return nil, blocked(api.ReasonSignatureInvalid, fmt.Errorf("amprenta script nu corespunde cu scriptSha256"))
+		}
+	}
+
+	if shellInterpreters[interpreter] {
+		if err := ar.policy.Check(check.Script); err != nil {
+			log.Printf("[SECURITY] Comanda BLOCATA pe agent (%s): %v", check.CheckID, err)
+			return nil, blocked(api.ReasonBlockedByPolicy, fmt.Errorf("comanda blocata de politica: %w", err))
+		}
+	}
+
+	if err := os.MkdirAll(ar.scriptDir, 0711); err != nil {
+		return nil, fmt.Errorf("director scripturi: %w", err)
+	}
+	// Doar traversare pentru utilizatorul verificarii, fara listare
+	if err := os.Chmod(ar.scriptDir, 0711); err != nil {
+		return nil, fmt.Errorf("director scripturi: %w", err)
+	}
+	dir, err := os.MkdirTemp(ar.scriptDir, "check-")
+	if err != nil {
+		return nil, fmt.Errorf("director script: %w", err)
+	}
+	script := &preparedScript{dir: dir}
+
+	file := filepath.Join(dir, "script")
+	if err := os.WriteFile(file, []byte(check.Script), 0700); err != nil {
+		script.remove()
+		return nil, fmt.Errorf("scriere script: %w", err)
+	}
+	if err := os.Chmod(file, 0700); err != nil {
+		script.remove()
+		return nil, fmt.Errorf("scriere script: %w", err)
+	}
+	if as.Credential != nil {
+		uid, gid := int(as.Credential.Uid), int(as.Credential.Gid)
+		if err := os.Chown(dir, uid, gid); err != nil {
+			script.remove()
+			return nil, fmt.Errorf("chown director script: %w", err)
+		}
+		if err := os.Chown(file, uid, gid); err != nil {
+			script.remove()
+			return nil, fmt.Errorf("chown script: %w", err)
+		}
+	}
+
+	// Reverificare pe continutul de pe disc, imediat inainte de executie
+	if check.ScriptSHA256 != "" {
+		written, err := os.ReadFile(file)
+		if err != nil || !hashMatches(string(written), check.ScriptSHA256) {
+			script.remove()
+			return nil, blocked(api.ReasonSignatureInvalid, fmt.Errorf("amprenta script de pe disc nu corespunde cu scriptSha256"))
+		}
+	}
+
+	script.argv = append([]string{bin, file}, check.Args...)
+	return script, nil
+}
+
+func hashMatches(content, expected string) bool {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:]) == strings.ToLower(strings.TrimSpace(expected))
+}
@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/policy"
+)
+
+func TestPrepareScript(t *testing.T) {
+	pol, err := policy.Parse(policy.DefaultPolicyYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar := &AuditRunner{policy: pol, scriptDir: filepath.Join(t.TempDir(), "scripts")}
+	as := agentUser()
+
+	script := "import sys\nprint(sys.argv[1:])\n"
+	sum := sha256.Sum256([]byte(script))
+	check := api.PendingCheck{
+		CheckID:      "c1",
+		CheckType:    "SCRIPT",
+		Script:       script,
+		Interpreter:  "python3",
+		ScriptSHA256: hex.EncodeToString(sum[:]),
+		Args:         []string{"--strict"},
+	}
+
+	if _, err := ar.prepareScript(check, as, false); err == nil {
+		t.Error("script python3 nesemnat acceptat")
+	}
+
+	wrong := check
+	wrong.Script += "import os\n"
+	if _, err := ar.prepareScript(wrong, as, true); err == nil {
+		t.Error("script cu amprenta gresita acceptat")
+	}
+
+	noPin := check
+	noPin.ScriptSHA256 = ""
+	if _, err := ar.prepareScript(noPin, as, true); err == nil {
+		t.Error("script python3 fara scriptSha256 acceptat")
+	}
+
+	prepared, err := ar.prepareScript(check, as, true)
+	if err != nil {
+		t.Fatalf("script semnat respins: %v", err)
+	}
+	defer prepared.remove()
+
+	if len(prepared.argv) != 3 || prepared.argv[0] != "/usr/bin/python3" || prepared.argv[2] != "--strict" {
+		t.Errorf("argv %q", prepared.argv)
+	}
+	file := prepared.argv[1]
+	if !strings.HasPrefix(file, ar.scriptDir+"/") {
+		t.Errorf("script in afara directorului privat: %s", file)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("mod fisier %o, vrem 0700", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(file); string(data) != script {
+		t.Errorf("continut script %q", data)
+	}
+
+	prepared.remove()
+	if _, err := os.Stat(filepath.Dir(file)); !os.IsNotExist(err) {
+		t.Error("directorul scriptului nu a fost sters")
+	}
+}
+
+func TestPrepareScriptShellPolicy(t *testing.T) {
+	pol, err := policy.Parse(policy.DefaultPolicyYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar := &AuditRunner{policy: pol, scriptDir: filepath.Join(t.TempDir(), "scripts")}
+
+	// Scripturile sh fara amprenta trec prin politica AST
+	check := api.PendingCheck{CheckID: "c1", CheckType: "SCRIPT", Script: "rm -rf /tmp/x\n"}
+	if _, err := ar.prepareScript(check, agentUser(), false); err == nil {
+		t.Error("script sh blocat de politica acceptat")
+	}
+
+	check.Script = "grep -c root /etc/passwd\n"
+	prepared, err := ar.prepareScript(check, agentUser(), false)
+	if err != nil {
+		t.Fatalf("script sh permis respins: %v", err)
+	}
+	prepared.remove()
+}
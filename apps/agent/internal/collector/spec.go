@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/policy"
+)
+
+// Valorile implementate de agent. La executie, o valoare necunoscuta nu e o
+// eroare (comparatia revine la EQUALS, parserul si normalizarea sunt ignorate),
+// deci un sablon care o foloseste esueaza fara explicatie: ValidateCheck o respinge.
+var (
+	comparisons    = map[string]bool{"EQUALS": true, "CONTAINS": true, "REGEX": true, "NUM_EQ": true, "NUM_GE": true, "NUM_LE": true, "NUM_GT": true, "NUM_LT": true}
+	parsers        = map[string]bool{"RAW": true, "FIRST_LINE": true}
+	normalizeRules = map[string]bool{"TRIM": true, "LOWER": true, "SQUASH_WS": true} // TRIM e aplicat oricum
+)
+
+// ValidateCheck verifica specificatia unei verificari fara a o executa: tipul,
+// comparatia, parserul si normalizarea suportate, expresiile REGEX si pragurile
+// numerice, specificatiile native (campuri obligatorii si comparatia SYSCTL,
+// validate ca la executie) si platformScope
+func ValidateCheck(check api.PendingCheck) []error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if !supportedCheckType(check.CheckType) {
+		add("checkType %q necunoscut", check.CheckType)
+	}
+	// Doar checkType SCRIPT executa campul script; celelalte executa command
+	if check.CheckType == "SCRIPT" {
+		if check.Script == "" {
+			add("checkType SCRIPT fara script")
+		}
+	} else if check.Command == "" && check.Script != "" {
+		add("script ignorat: checkType %q executa command (gol)", check.CheckType)
+	}
+	comparison := strings.ToUpper(check.Comparison)
+	if comparison != "" && !comparisons[comparison] {
+		add("comparison %q necunoscut", check.Comparison)
+	}
+	if check.Parser != "" && !parsers[check.Parser] {
+		add("parser %q necunoscut", check.Parser)
+	}
+	for _, rule := range check.Normalize {
+		if !normalizeRules[strings.ToUpper(rule)] {
+			add("regula normalize %q necunoscuta", rule)
+		}
+	}
+
+	// expectedResult si warnResult folosesc acelasi operator
+	for _, v := range []struct{ field, value string }{{"expectedResult", check.ExpectedResult}, {"warnResult", check.WarnResult}} {
+		if v.value == "" {
+			continue
+		}
+		switch {
+		case comparison == "REGEX":
+			if _, err := regexp.Compile(v.value); err != nil {
+				add("%s: regex invalid: %v", v.field, err)
+			}
+		case strings.HasPrefix(comparison, "NUM_"):
+			if _, err := strconv.ParseFloat(v.value, 64); err != nil {
+				add("%s %q nu e numeric (comparison %s)", v.field, v.value, comparison)
+			}
+		}
+	}
+
+	switch strings.ToUpper(check.CheckType) {
+	case "FILE_CHECK":
+		_, err := ParseFileCheckSpec(check.Command)
+		errs = appendSpecError(errs, err)
+	case "SYSCTL":
+		_, err := ParseSysctlSpec(check.Command)
+		errs = appendSpecError(errs, err)
+	case "SERVICE_STATE":
+		_, err := ParseServiceStateSpec(check.Command)
+		errs = appendSpecError(errs, err)
+	case "PACKAGE":
+		_, err := ParsePackageSpec(check.Command)
+		errs = appendSpecError(errs, err)
+	case "PORT_LISTENING":
+		_, err := ParsePortListeningSpec(check.Command)
+		errs = appendSpecError(errs, err)
+	}
+
+	// Fiecare intrare, nu doar pana la prima potrivire
+	for _, entry := range check.PlatformScope {
+		if _, err := matchScopeEntry(entry, Platform{}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func appendSpecError(errs []error, err error) []error {
+	if err != nil {
+		return append(errs, err)
+	}
+	return errs
+}
+
+// CheckPolicy aplica politica de executie ca la rulare: comanda (sau scriptul
+// shell) trece prin analiza AST, interpretorul trebuie sa fie in allowlist, iar
+// scripturile fixate trebuie sa aiba amprenta corecta
+func CheckPolicy(pol *policy.Policy, check api.PendingCheck) error {
+	if isNativeCheck(check.CheckType) {
+		return nil
+	}
+	if check.CheckType != "SCRIPT" {
+		return pol.Check(check.Command)
+	}
+
+	bin, err := pol.Interpreter(check.Interpreter)
+	if err != nil {
+		return err
+	}
+	if needsPinnedScript(check) {
+		if check.ScriptSHA256 == "" {
+			return fmt.Errorf("scriptSha256 lipsa (obligatoriu pentru interpretorul %s)", check.Interpreter)
+		}
+		if !hashMatches(check.Script, check.ScriptSHA256) {
+			return errors.New("amprenta script nu corespunde cu scriptSha256")
+		}
+	}
+	if shellInterpreters[path.Base(bin)] {
+		return pol.Check(check.Script)
+	}
+	return nil
+}
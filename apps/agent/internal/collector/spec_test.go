@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/policy"
+)
+
+func TestValidateCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		check api.PendingCheck
+		errs  []string
+	}{
+		{"comanda", api.PendingCheck{Command: "true", Comparison: "num_ge", ExpectedResult: "3", Normalize: []string{"lower"}}, nil},
+		{"tip necunoscut", api.PendingCheck{CheckType: "SHELL", Command: "true"}, []string{`checkType "SHELL"`}},
+		{"valori necunoscute", api.PendingCheck{Command: "true", Comparison: "LESS_THAN", Parser: "INT", Normalize: []string{"UPPER"}},
+			[]string{`comparison "LESS_THAN"`, `parser "INT"`, `normalize "UPPER"`}},
+		{"regex", api.PendingCheck{Command: "true", Comparison: "REGEX", ExpectedResult: "^(a", WarnResult: "b+"}, []string{"expectedResult: regex invalid"}},
+		{"numeric", api.PendingCheck{Command: "true", Comparison: "NUM_LE", WarnResult: "ten"}, []string{`warnResult "ten"`}},
+		{"script fara SCRIPT", api.PendingCheck{Script: "true"}, []string{"script ignorat"}},
+		{"SCRIPT gol", api.PendingCheck{CheckType: "SCRIPT"}, []string{"fara script"}},
+		{"nativ", api.PendingCheck{CheckType: "SYSCTL", Command: "{"}, []string{"SYSCTL"}},
+		{"sysctl valid", api.PendingCheck{CheckType: "SYSCTL", Command: `{"key":"kernel.randomize_va_space","value":"2","comparison":"num_ge"}`}, nil},
+		{"sysctl fara key", api.PendingCheck{CheckType: "SYSCTL", Command: `{"value":"1"}`}, []string{"key lipsa"}},
+		{"sysctl comparatie", api.PendingCheck{CheckType: "SYSCTL", Command: `{"key":"net.ipv4.ip_forward","value":"0","comparison":"LESS"}`}, []string{`comparison "LESS"`}},
+		{"sysctl numeric", api.PendingCheck{CheckType: "SYSCTL", Command: `{"key":"fs.suid_dumpable","value":"off","comparison":"NUM_LE"}`}, []string{"nu e numeric"}},
+		{"sysctl regex", api.PendingCheck{CheckType: "SYSCTL", Command: `{"key":"fs.suid_dumpable","value":"(0","comparison":"REGEX"}`}, []string{"regex"}},
+		{"serviciu fara unit", api.PendingCheck{CheckType: "SERVICE_STATE", Command: `{"active":true}`}, []string{"unit lipsa"}},
+		{"serviciu fara stare", api.PendingCheck{CheckType: "SERVICE_STATE", Command: `{"unit":"sshd"}`}, []string{"enabled sau active"}},
+		{"pachet fara nume", api.PendingCheck{CheckType: "PACKAGE", Command: `{"installed":false}`}, []string{"name lipsa"}},
+		{"port lipsa", api.PendingCheck{CheckType: "PORT_LISTENING", Command: `{"protocol":"tcp"}`}, []string{"port invalid"}},
+		{"port protocol", api.PendingCheck{CheckType: "PORT_LISTENING", Command: `{"port":22,"protocol":"sctp"}`}, []string{`protocol "sctp"`}},
+		{"platformScope", api.PendingCheck{Command: "true", PlatformScope: []string{"ubuntu>=22.04", ">=9"}}, []string{"distributie lipsa"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateCheck(tt.check)
+			if len(errs) != len(tt.errs) {
+				t.Fatalf("erori %v, asteptat %v", errs, tt.errs)
+			}
+			for i, want := range tt.errs {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("eroare %q nu contine %q", errs[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckPolicy(t *testing.T) {
+	pol, err := policy.Parse(policy.DefaultPolicyYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := "print('ok')\n"
+	sum := sha256.Sum256([]byte(script))
+	pinned := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name  string
+		check api.PendingCheck
+		allow bool
+	}{
+		{"comanda", api.PendingCheck{CheckType: "COMMAND", Command: "ss -lnt"}, true},
+		{"comanda interzisa", api.PendingCheck{CheckType: "COMMAND", Command: "rm -rf /tmp/x"}, false},
+		{"nativ", api.PendingCheck{CheckType: "FILE_CHECK", Command: `{"path":"/etc/passwd"}`}, true},
+		{"script shell", api.PendingCheck{CheckType: "SCRIPT", Script: "cat /etc/hostname"}, true},
+		{"script shell interzis", api.PendingCheck{CheckType: "SCRIPT", Interpreter: "bash", Script: "reboot"}, false},
+		{"python fixat", api.PendingCheck{CheckType: "SCRIPT", Interpreter: "python3", Script: script, ScriptSHA256: pinned}, true},
+		{"python nefixat", api.PendingCheck{CheckType: "SCRIPT", Interpreter: "python3", Script: script}, false},
+		{"amprenta gresita", api.PendingCheck{CheckType: "SCRIPT", Interpreter: "python3", Script: script + "x\n", ScriptSHA256: pinned}, false},
+		{"interpretor necunoscut", api.PendingCheck{CheckType: "SCRIPT", Interpreter: "ruby", Script: "puts 1"}, false},
+	}
+	for _, tt := range tests {
+		err := CheckPolicy(pol, tt.check)
+		if tt.allow && err != nil {
+			t.Errorf("%s respinsa: %v", tt.name, err)
+		}
+		if !tt.allow && err == nil {
+			t.Errorf("%s permisa", tt.name)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"bittrail-agent/internal/api"
+)
+
+// checkError poarta statusul si codul motiv al unei verificari care nu a
+// putut rula sau nu a putut fi evaluata
+type checkError struct {
+	status string
+	reason string
+	err    error
+}
+
+func (e *checkError) Error() string { return e.err.Error() }
+func (e *checkError) Unwrap() error { return e.err }
+
+// blocked marcheaza o verificare refuzata inainte de executie
+func blocked(reason string, err error) error {
+	return &checkError{status: api.StatusBlocked, reason: reason, err: err}
+}
+
+// failedWith marcheaza o verificare care a rulat, dar nu a putut fi evaluata
+func failedWith(reason string, err error) error {
+	return &checkError{status: api.StatusError, reason: reason, err: err}
+}
+
+// classifyError intoarce statusul si codul motiv pentru eroarea unei verificari
+func classifyError(ctx context.Context, err error) (status, reason string) {
+	if ctx.Err() == context.DeadlineExceeded {
+		return api.StatusError, api.ReasonTimeout
+	}
+	if context.Cause(ctx) == errRunCancelled {
+		return api.StatusError, api.ReasonCancelled
+	}
+	var ce *checkError
+	if errors.As(err, &ce) {
+		return ce.status, ce.reason
+	}
+	return api.StatusError, api.ReasonExecutionError
+}
+
+// supportedCheckType indica tipurile de verificari pe care agentul le poate rula
+func supportedCheckType(checkType string) bool {
+	switch strings.ToUpper(checkType) {
+	case "", "COMMAND", "SCRIPT":
+		return true
+	}
+	return isNativeCheck(checkType)
+}
+
+// Exit code-ul shell-ului cand programul nu exista
+const exitCommandNotFound = 127
+
+func errCommandNotFound(stderr string) error {
+	return failedWith(api.ReasonCommandNotFound, fmt.Errorf("program inexistent (exit %d): %s", exitCommandNotFound, stderr))
+}
+
+// invalidSpec e eroarea unei specificatii de verificare invalide (eroare de template)
+func invalidSpec(format string, args ...interface{}) error {
+	return failedWith(api.ReasonInvalidSpec, fmt.Errorf(format, args...))
+}
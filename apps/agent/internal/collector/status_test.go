@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"bittrail-agent/internal/api"
+)
+
+func TestClassifyError(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		err    error
+		status string
+		reason string
+	}{
+		{errors.New("exec: fork"), api.StatusError, api.ReasonExecutionError},
+		{blocked(api.ReasonBlockedByPolicy, errors.New("rm")), api.StatusBlocked, api.ReasonBlockedByPolicy},
+		{fmt.Errorf("script: %w", blocked(api.ReasonSignatureInvalid, errors.New("sha"))), api.StatusBlocked, api.ReasonSignatureInvalid},
+		{invalidSpec("specificatie %s invalida", "SYSCTL"), api.StatusError, api.ReasonInvalidSpec},
+		{errCommandNotFound("sh: foo: not found"), api.StatusError, api.ReasonCommandNotFound},
+	}
+	for _, tt := range tests {
+		status, reason := classifyError(ctx, tt.err)
+		if status != tt.status || reason != tt.reason {
+			t.Errorf("%v: %s/%s, vrem %s/%s", tt.err, status, reason, tt.status, tt.reason)
+		}
+	}
+
+	expired, cancel := context.WithTimeout(ctx, 0)
+	defer cancel()
+	<-expired.Done()
+	if status, reason := classifyError(expired, errors.New("signal: killed")); status != api.StatusError || reason != api.ReasonTimeout {
+		t.Errorf("timeout: %s/%s", status, reason)
+	}
+}
+
+func TestRunCheckNotExecuted(t *testing.T) {
+	ar := &AuditRunner{
+		defaultTimeout: time.Second,
+		facts:          map[string]string{"os": "linux", "platform": "ubuntu", "platformFamily": "debian", "platformVersion": "22.04", "kernelArch": "x86_64"},
+	}
+	tests := []struct {
+		check  api.PendingCheck
+		status string
+		reason string
+	}{
+		{api.PendingCheck{CheckType: "REGISTRY"}, api.StatusSkipped, api.ReasonUnsupportedType},
+		{api.PendingCheck{Command: "true", PlatformScope: []string{"rhel"}}, api.StatusNotApplicable, api.ReasonNotApplicable},
+		{api.PendingCheck{Command: "true", Requires: []string{"bittrail-no-such-binary"}}, api.StatusNotApplicable, api.ReasonRequirementNotMet},
+		{api.PendingCheck{Command: "true", PlatformScope: []string{"ubuntu>="}}, api.StatusError, api.ReasonInvalidSpec},
+	}
+	for _, tt := range tests {
+		res := ar.runCheck(context.Background(), openedCheck{PendingCheck: tt.check}, nil)
+		if res.Status != tt.status || res.ReasonCode != tt.reason {
+			t.Errorf("%+v: %s/%s, vrem %s/%s", tt.check, res.Status, res.ReasonCode, tt.status, tt.reason)
+		}
+	}
+}
+
+func TestMatchesWarn(t *testing.T) {
+	check := api.PendingCheck{ExpectedResult: "90", WarnResult: "60", Comparison: "NUM_GE"}
+	if matchesExpected("75", check) {
+		t.Error("75 >= 90")
+	}
+	if !matchesWarn("75", check) {
+		t.Error("75 >= 60 respins")
+	}
+	if matchesWarn("30", check) {
+		t.Error("30 >= 60")
+	}
+}
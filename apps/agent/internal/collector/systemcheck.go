@@ -0,0 +1,345 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Specificatiile verificarilor native de stare sistem sunt transmise ca JSON
+// in campul command (la fel ca FILE_CHECK), deci sunt acoperite de semnatura.
+
+// SysctlSpec - SYSCTL: valoarea unui parametru kernel
+type SysctlSpec struct {
+	Key        string `json:"key"`        // ex: net.ipv4.ip_forward
+	Value      string `json:"value"`      // valoare asteptata
+	Comparison string `json:"comparison"` // EQUALS (implicit), NUM_GE, REGEX, etc
+}
+
+// ServiceStateSpec - SERVICE_STATE: starea unei unitati systemd
+type ServiceStateSpec struct {
+	Unit    string `json:"unit"`    // ex: sshd sau sshd.service
+	Enabled *bool  `json:"enabled"` // systemctl is-enabled
+	Active  *bool  `json:"active"`  // systemctl is-active
+}
+
+// PackageSpec - PACKAGE: pachet (ne)instalat si versiune
+type PackageSpec struct {
+	Name       string `json:"name"`
+	Installed  *bool  `json:"installed"`  // implicit true
+	MinVersion string `json:"minVersion"` // cea mai noua versiune instalata >= (ordonare dpkg/rpm)
+	MaxVersion string `json:"maxVersion"` // versiune <=
+}
+
+// PortListeningSpec - PORT_LISTENING: port (ne)ascultat
+type PortListeningSpec struct {
+	Port      uint32 `json:"port"`
+	Protocol  string `json:"protocol"`  // tcp (implicit) sau udp
+	Address   string `json:"address"`   // optional: adresa de legare
+	Listening *bool  `json:"listening"` // implicit true
+}
+
+// SystemCheckResult e rezultatul structurat al verificarilor de stare sistem
+type SystemCheckResult struct {
+	Type     string                 `json:"type"`
+	Target   string                 `json:"target"`
+	Pass     bool                   `json:"pass"`
+	Observed map[string]interface{} `json:"observed"`
+	Failures []string               `json:"failures,omitempty"`
+}
+
+// SystemSnapshot memoreaza, pe durata unui job de audit, starile costisitoare
+// de citit si comune mai multor verificari (baza de date dpkg/rpm)
+type SystemSnapshot struct {
+	mu       sync.Mutex
+	loaded   bool
+	manager  string
+	packages map[string]InstalledPackage
+	err      error
+}
+
+// installedPackages citeste pachetele o singura data per snapshot; un
+// snapshot nil interogheaza direct managerul de pachete
+func (s *SystemSnapshot) installedPackages(ctx context.Context) (string, map[string]InstalledPackage, error) {
+	if s == nil {
+		return queryPackages(ctx)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		manager, packages, err := queryPackages(ctx)
+		if ctx.Err() != nil {
+			// Rezultatul unei interogari intrerupte nu e memorat
+			return manager, packages, err
+		}
+		s.manager, s.packages, s.err, s.loaded = manager, packages, err, true
+	}
+	return s.manager, s.packages, s.err
+}
+
+func newSystemCheckResult(checkType, target string) *SystemCheckResult {
+	return &SystemCheckResult{
+		Type:     checkType,
+		Target:   target,
+		Pass:     true,
+		Observed: make(map[string]interface{}),
+	}
+}
+
+func (r *SystemCheckResult) fail(format string, args ...interface{}) {
+	r.Pass = false
+	r.Failures = append(r.Failures, fmt.Sprintf(format, args...))
+}
+
+func decodeSpec(checkType, raw string, v interface{}) error {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return invalidSpec("specificatie %s invalida: %w", checkType, err)
+	}
+	return nil
+}
+
+// ParseSysctlSpec decodeaza si valideaza specificatia SYSCTL
+func ParseSysctlSpec(raw string) (*SysctlSpec, error) {
+	var spec SysctlSpec
+	if err := decodeSpec("SYSCTL", raw, &spec); err != nil {
+		return nil, err
+	}
+	if spec.Key == "" || strings.Contains(spec.Key, "..") {
+		return nil, invalidSpec("specificatie SYSCTL invalida: key lipsa sau invalid")
+	}
+	comparison := strings.ToUpper(spec.Comparison)
+	switch {
+	case comparison != "" && !comparisons[comparison]:
+		return nil, invalidSpec("specificatie SYSCTL invalida: comparison %q necunoscut", spec.Comparison)
+	case comparison == "REGEX":
+		if _, err := regexp.Compile(spec.Value); err != nil {
+			return nil, invalidSpec("specificatie SYSCTL invalida: regex %q: %w", spec.Value, err)
+		}
+	case strings.HasPrefix(comparison, "NUM_"):
+		if _, err := strconv.ParseFloat(strings.TrimSpace(spec.Value), 64); err != nil {
+			return nil, invalidSpec("specificatie SYSCTL invalida: value %q nu e numeric (comparison %s)", spec.Value, comparison)
+		}
+	}
+	return &spec, nil
+}
+
+// RunSysctlCheck citeste /proc/sys si compara valoarea
+func RunSysctlCheck(raw string) (*SystemCheckResult, error) {
+	spec, err := ParseSysctlSpec(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	res := newSystemCheckResult("SYSCTL", spec.Key)
+	path := filepath.Join("/proc/sys", strings.ReplaceAll(spec.Key, ".", "/"))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		res.fail("parametru indisponibil: %v", err)
+		return res, nil
+	}
+
+	// Valorile multiple (ex: tcp_rmem) sunt separate prin tab
+	value := strings.Join(strings.Fields(string(data)), " ")
+	res.Observed["value"] = value
+
+	expected := strings.Join(strings.Fields(spec.Value), " ")
+	if !compareValues(value, expected, spec.Comparison) {
+		res.fail("valoare %q, asteptat %q", value, expected)
+	}
+	return res, nil
+}
+
+// ParseServiceStateSpec decodeaza si valideaza specificatia SERVICE_STATE
+func ParseServiceStateSpec(raw string) (*ServiceStateSpec, error) {
+	var spec ServiceStateSpec
+	if err := decodeSpec("SERVICE_STATE", raw, &spec); err != nil {
+		return nil, err
+	}
+	if spec.Unit == "" || strings.HasPrefix(spec.Unit, "-") {
+		return nil, invalidSpec("specificatie SERVICE_STATE invalida: unit lipsa sau invalid")
+	}
+	if spec.Enabled == nil && spec.Active == nil {
+		return nil, invalidSpec("specificatie SERVICE_STATE invalida: enabled sau active necesar")
+	}
+	return &spec, nil
+}
+
+// RunServiceStateCheck interogheaza systemctl direct (fara shell)
+func RunServiceStateCheck(ctx context.Context, raw string) (*SystemCheckResult, error) {
+	spec, err := ParseServiceStateSpec(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	unit := spec.Unit
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+	res := newSystemCheckResult("SERVICE_STATE", unit)
+
+	if spec.Enabled != nil {
+		state := systemctlQuery(ctx, "is-enabled", unit)
+		res.Observed["enabled"] = state
+		enabled := state == "enabled" || state == "enabled-runtime"
+		if enabled != *spec.Enabled {
+			res.fail("is-enabled=%s, asteptat enabled=%t", state, *spec.Enabled)
+		}
+	}
+	if spec.Active != nil {
+		state := systemctlQuery(ctx, "is-active", unit)
+		res.Observed["active"] = state
+		if (state == "active") != *spec.Active {
+			res.fail("is-active=%s, asteptat active=%t", state, *spec.Active)
+		}
+	}
+	return res, nil
+}
+
+func systemctlQuery(ctx context.Context, verb, unit string) string {
+	// Exit code nenul e normal (ex: inactive); starea e pe stdout
+	cmd := exec.CommandContext(ctx, "systemctl", verb, "--", unit)
+	cmd.Env = nativeEnv()
+	out, _ := cmd.Output()
+	state := strings.TrimSpace(string(out))
+	if state == "" {
+		return "unknown"
+	}
+	return strings.SplitN(state, "\n", 2)[0]
+}
+
+// ParsePackageSpec decodeaza si valideaza specificatia PACKAGE
+func ParsePackageSpec(raw string) (*PackageSpec, error) {
+	var spec PackageSpec
+	if err := decodeSpec("PACKAGE", raw, &spec); err != nil {
+		return nil, err
+	}
+	if spec.Name == "" {
+		return nil, invalidSpec("specificatie PACKAGE invalida: name lipsa")
+	}
+	return &spec, nil
+}
+
+// RunPackageCheck verifica prezenta si versiunea pachetului
+func RunPackageCheck(ctx context.Context, raw string, snap *SystemSnapshot) (*SystemCheckResult, error) {
+	spec, err := ParsePackageSpec(raw)
+	if err != nil {
+		return nil, err
+	}
+	wantInstalled := spec.Installed == nil || *spec.Installed
+
+	res := newSystemCheckResult("PACKAGE", spec.Name)
+	manager, packages, err := snap.installedPackages(ctx)
+	if err != nil {
+		res.fail("%v", err)
+		return res, nil
+	}
+	res.Observed["manager"] = manager
+
+	pkg, installed := packages[spec.Name]
+	res.Observed["installed"] = installed
+	if installed {
+		// Cu mai multe versiuni instalate (kernel) e comparata cea mai noua
+		res.Observed["version"] = pkg.Newest()
+		if len(pkg.Versions) > 1 {
+			res.Observed["versions"] = pkg.Versions
+		}
+	}
+
+	if installed != wantInstalled {
+		res.fail("installed=%t, asteptat %t", installed, wantInstalled)
+		return res, nil
+	}
+	if !installed {
+		return res, nil
+	}
+
+	version := pkg.Newest()
+	if spec.MinVersion != "" && CompareVersions(manager, version, spec.MinVersion) < 0 {
+		res.fail("versiune %s < %s", version, spec.MinVersion)
+	}
+	if spec.MaxVersion != "" && CompareVersions(manager, version, spec.MaxVersion) > 0 {
+		res.fail("versiune %s > %s", version, spec.MaxVersion)
+	}
+	return res, nil
+}
+
+// ParsePortListeningSpec decodeaza si valideaza specificatia PORT_LISTENING;
+// protocolul e normalizat (tcp implicit)
+func ParsePortListeningSpec(raw string) (*PortListeningSpec, error) {
+	var spec PortListeningSpec
+	if err := decodeSpec("PORT_LISTENING", raw, &spec); err != nil {
+		return nil, err
+	}
+	if spec.Port == 0 || spec.Port > 65535 {
+		return nil, invalidSpec("specificatie PORT_LISTENING invalida: port invalid")
+	}
+	spec.Protocol = strings.ToLower(spec.Protocol)
+	if spec.Protocol == "" {
+		spec.Protocol = "tcp"
+	}
+	if spec.Protocol != "tcp" && spec.Protocol != "udp" {
+		return nil, invalidSpec("specificatie PORT_LISTENING invalida: protocol %q", spec.Protocol)
+	}
+	return &spec, nil
+}
+
+// RunPortListeningCheck foloseste aceeasi sursa ca inventarul de porturi
+func RunPortListeningCheck(raw string) (*SystemCheckResult, error) {
+	spec, err := ParsePortListeningSpec(raw)
+	if err != nil {
+		return nil, err
+	}
+	proto := spec.Protocol
+	wantListening := spec.Listening == nil || *spec.Listening
+
+	res := newSystemCheckResult("PORT_LISTENING", fmt.Sprintf("%s/%d", proto, spec.Port))
+	sockets, err := getListeningSockets()
+	if err != nil {
+		res.fail("listare socket-uri: %v", err)
+		return res, nil
+	}
+
+	var addresses []string
+	for _, s := range sockets {
+		if s.Protocol != proto || s.Port != spec.Port {
+			continue
+		}
+		if spec.Address != "" && s.Address != spec.Address {
+			continue
+		}
+		addresses = append(addresses, s.Address)
+	}
+	listening := len(addresses) > 0
+	res.Observed["listening"] = listening
+	if listening {
+		res.Observed["addresses"] = addresses
+	}
+
+	if listening != wantListening {
+		res.fail("listening=%t, asteptat %t", listening, wantListening)
+	}
+	return res, nil
+}
+
+func runSystemCheck(ctx context.Context, checkType, raw string, snap *SystemSnapshot) (*SystemCheckResult, error) {
+	switch checkType {
+	case "SYSCTL":
+		return RunSysctlCheck(raw)
+	case "SERVICE_STATE":
+		return RunServiceStateCheck(ctx, raw)
+	case "PACKAGE":
+		return RunPackageCheck(ctx, raw, snap)
+	case "PORT_LISTENING":
+		return RunPortListeningCheck(raw)
+	}
+	return nil, fmt.Errorf("tip verificare necunoscut: %s", checkType)
+}
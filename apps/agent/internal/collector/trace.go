@@ -0,0 +1,199 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bittrail-agent/internal/api"
+)
+
+// execTrace captureaza iesirea bruta (inainte de redactare) si iesirea
+// procesata a verificarii depanate
+type execTrace struct {
+	stdout, stderr *rawBuffer
+	output         *checkOutput
+	err            error
+}
+
+// rawBuffer pastreaza primii limit octeti ai fluxului, ca iesirea redactata
+type rawBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (r *rawBuffer) Write(p []byte) (int, error) {
+	if room := r.limit - r.buf.Len(); room > 0 {
+		if len(p) > room {
+			r.buf.Write(p[:room])
+		} else {
+			r.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// CheckTrace descrie fiecare etapa a evaluarii unei verificari
+type CheckTrace struct {
+	Check api.PendingCheck
+
+	// Executed e false daca verificarea nu a ajuns la executie (neaplicabila,
+	// tip nesuportat, utilizator runAs invalid)
+	Executed  bool
+	ExecError error
+	ExitCode  int
+
+	// Iesirea bruta (doar comenzi si scripturi) si cea redactata, comparata
+	RawStdout, RawStderr string
+	Stdout, Stderr       string
+	StdoutRedacted       bool
+	StderrRedacted       bool
+
+	// Normalize: TRIM (mereu) si fiecare regula, cu iesirea dupa ea
+	Normalize   []TraceStep
+	Parsed      string
+	Comparisons []TraceComparison
+
+	Result api.CheckResult
+
+	// Structura canonica semnata si verificarea semnaturii cu cheia publica
+	// a agentului (nil daca rezultatul e semnat si valid)
+	SignedData     string
+	SignatureError error
+}
+
+// TraceStep e iesirea dupa o regula Normalize
+type TraceStep struct {
+	Rule   string
+	Output string
+}
+
+// TraceComparison e o comparatie efectuata de runCheck
+type TraceComparison struct {
+	Field    string // expectedResult, warnResult sau exitCode
+	Operator string
+	Actual   string
+	Expected string
+	Matched  bool
+	Note     string // motivul pentru care comparatia esueaza independent de valori
+}
+
+// TraceCheck executa o singura verificare pe acelasi drum ca auditul
+// (aplicabilitate, politica, sandbox, redactare, semnare) si intoarce
+// etapele intermediare. Verificarea e tratata ca semnata, ca in auditul
+// local. Nu trebuie apelata in paralel cu alte executii ale runner-ului.
+func (ar *AuditRunner) TraceCheck(check api.PendingCheck) *CheckTrace {
+	ar.trace = &execTrace{
+		stdout: &rawBuffer{limit: ar.maxOutput},
+		stderr: &rawBuffer{limit: ar.maxOutput},
+	}
+	defer func() { ar.trace = nil }()
+
+	result := ar.runCheck(context.Background(), openedCheck{PendingCheck: check, signed: true}, &SystemSnapshot{})
+	t := &CheckTrace{Check: check, Result: result}
+
+	if out := ar.trace.output; out != nil {
+		t.Executed = true
+		t.ExecError = ar.trace.err
+		t.ExitCode = out.ExitCode
+		t.Stdout, t.Stderr = out.Stdout, out.Stderr
+		if isNativeCheck(check.CheckType) {
+			// Iesirea nativa e generata de agent, fara redactare
+			t.RawStdout, t.RawStderr = out.Stdout, out.Stderr
+		} else {
+			t.RawStdout, t.RawStderr = ar.trace.stdout.buf.String(), ar.trace.stderr.buf.String()
+		}
+		t.StdoutRedacted = !sameCapture(t.RawStdout, out.Stdout, out.stdoutTruncated())
+		t.StderrRedacted = !sameCapture(t.RawStderr, out.Stderr, out.stderrTruncated())
+		// Comparatia e facuta doar daca executia nu s-a incheiat cu eroare
+		// (inclusiv comanda negasita, exit 127)
+		if t.ExecError == nil && result.ErrorMessage == "" {
+			t.trace(check)
+		}
+	}
+
+	if ar.privateKey != nil {
+		if data, err := resultSignatureData(ar.serverID, check, &result); err == nil {
+			t.SignedData = string(data)
+		}
+		t.SignatureError = VerifyResultSignature(&ar.privateKey.PublicKey, ar.serverID, check.AuditRunID, result)
+	}
+	return t
+}
+
+// sameCapture compara iesirea bruta cu cea redactata; la trunchiere doar
+// prefixul comun e comparabil
+func sameCapture(raw, redacted string, truncated bool) bool {
+	if truncated {
+		return strings.HasPrefix(raw, redacted)
+	}
+	return raw == redacted
+}
+
+// trace reface pasii din matchesResult (normalizare, parsare, comparatie)
+// sau, fara expectedResult, conditia pe exit code
+func (t *CheckTrace) trace(check api.PendingCheck) {
+	if check.ExpectedResult == "" || isNativeCheck(check.CheckType) {
+		t.Comparisons = append(t.Comparisons, TraceComparison{
+			Field:    "exitCode",
+			Operator: "EQUALS",
+			Actual:   strconv.Itoa(t.ExitCode),
+			Expected: "0",
+			Matched:  t.ExitCode == 0,
+		})
+		return
+	}
+
+	output := strings.TrimSpace(t.Stdout)
+	t.Normalize = append(t.Normalize, TraceStep{Rule: "TRIM", Output: output})
+	for _, rule := range check.Normalize {
+		output = normalizeRule(output, rule)
+		t.Normalize = append(t.Normalize, TraceStep{Rule: rule, Output: output})
+	}
+	t.Parsed = parseOutput(output, check.Parser)
+
+	comparison := strings.ToUpper(check.Comparison)
+	if comparison == "" {
+		comparison = "EQUALS"
+	}
+	for _, v := range []struct{ field, expected string }{{"expectedResult", check.ExpectedResult}, {"warnResult", check.WarnResult}} {
+		if v.expected == "" {
+			continue
+		}
+		c := TraceComparison{
+			Field:    v.field,
+			Operator: comparison,
+			Actual:   t.Parsed,
+			Expected: v.expected,
+			Matched:  compareValues(t.Parsed, v.expected, comparison),
+			Note:     comparisonNote(t.Parsed, v.expected, comparison),
+		}
+		t.Comparisons = append(t.Comparisons, c)
+		if c.Matched {
+			break // warnResult e evaluat doar daca expectedResult esueaza
+		}
+	}
+}
+
+// comparisonNote explica esecurile care nu tin de valori: operator
+// necunoscut, regex invalid, valori nenumerice
+func comparisonNote(actual, expected, comparison string) string {
+	switch {
+	case !comparisons[comparison]:
+		return "operator necunoscut, comparat ca EQUALS"
+	case comparison == "REGEX":
+		if _, err := regexp.Compile(expected); err != nil {
+			return "regex invalid: " + err.Error()
+		}
+	case strings.HasPrefix(comparison, "NUM_"):
+		if _, err := strconv.ParseFloat(actual, 64); err != nil {
+			return "iesirea nu e numerica"
+		}
+		if _, err := strconv.ParseFloat(expected, 64); err != nil {
+			return "valoarea asteptata nu e numerica"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/config"
+)
+
+func TestTraceCheck(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := config.LoadLocal(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.SandboxMode = "off"
+	cfg.ExecPolicyFile = filepath.Join(dir, "exec-policy.yaml") // lipsa: politica implicita
+	cfg.StateDir = dir
+	ar := NewLocalAuditRunner(cfg)
+
+	check := api.PendingCheck{
+		AuditRunID:     LocalRunID,
+		CheckID:        "T1",
+		CheckType:      "COMMAND",
+		Command:        `printf '  Foo   BAR\npassword=hunter2\n'`,
+		ExpectedResult: "foo bar!",
+		WarnResult:     "^foo",
+		Comparison:     "REGEX",
+		Parser:         "FIRST_LINE",
+		Normalize:      []string{"SQUASH_WS", "LOWER"},
+	}
+	tr := ar.TraceCheck(check)
+	if ar.trace != nil {
+		t.Error("captura ramasa activa dupa TraceCheck")
+	}
+	if !tr.Executed || tr.ExitCode != 0 || tr.Result.Status != api.StatusWarn {
+		t.Fatalf("executie: %+v", tr)
+	}
+	if !strings.Contains(tr.RawStdout, "hunter2") || strings.Contains(tr.Stdout, "hunter2") || !tr.StdoutRedacted || tr.StderrRedacted {
+		t.Errorf("redactare: brut %q, redactat %q", tr.RawStdout, tr.Stdout)
+	}
+
+	steps := []string{"TRIM", "SQUASH_WS", "LOWER"}
+	if len(tr.Normalize) != len(steps) {
+		t.Fatalf("normalizare: %+v", tr.Normalize)
+	}
+	for i, rule := range steps {
+		if tr.Normalize[i].Rule != rule {
+			t.Errorf("pas %d: %s, asteptat %s", i, tr.Normalize[i].Rule, rule)
+		}
+	}
+	if last := tr.Normalize[2].Output; last != "foo bar password: [redacted]" {
+		t.Errorf("dupa LOWER: %q", last)
+	}
+	if tr.Parsed != "foo bar password: [redacted]" {
+		t.Errorf("parser: %q", tr.Parsed)
+	}
+	if len(tr.Comparisons) != 2 || tr.Comparisons[0].Matched || !tr.Comparisons[1].Matched || tr.Comparisons[1].Field != "warnResult" {
+		t.Errorf("comparatii: %+v", tr.Comparisons)
+	}
+
+	// Fara expectedResult decide exit code-ul; fara cheie, rezultat nesemnat
+	tr = ar.TraceCheck(api.PendingCheck{AuditRunID: LocalRunID, CheckID: "T2", CheckType: "COMMAND", Command: "true"})
+	if len(tr.Comparisons) != 1 || tr.Comparisons[0].Field != "exitCode" || !tr.Comparisons[0].Matched || tr.Result.Status != api.StatusPass {
+		t.Errorf("exit code: %+v", tr.Comparisons)
+	}
+	if tr.SignedData != "" || tr.Result.Signature != "" {
+		t.Error("rezultat semnat fara cheie")
+	}
+
+	// Exit code nenul e o eroare de executie: nicio comparatie
+	tr = ar.TraceCheck(api.PendingCheck{AuditRunID: LocalRunID, CheckID: "T3", CheckType: "COMMAND", Command: "echo x; false", ExpectedResult: "x"})
+	if tr.ExecError == nil || tr.ExitCode != 1 || len(tr.Comparisons) != 0 || tr.Result.Status == api.StatusPass {
+		t.Errorf("exit 1: %+v", tr)
+	}
+
+	// Neaplicabila: nu ajunge la executie
+	tr = ar.TraceCheck(api.PendingCheck{AuditRunID: LocalRunID, CheckID: "T4", CheckType: "COMMAND", Command: "true", PlatformScope: []string{"bittrail-no-such-os"}})
+	if tr.Executed || tr.Result.Status != api.StatusNotApplicable {
+		t.Errorf("neaplicabila: %+v", tr)
+	}
+}
+
+func TestComparisonNote(t *testing.T) {
+	tests := []struct{ actual, expected, comparison, want string }{
+		{"3", "2", "NUM_GE", ""},
+		{"abc", "2", "NUM_GE", "iesirea nu e numerica"},
+		{"3", "x", "NUM_LT", "valoarea asteptata nu e numerica"},
+		{"a", "(", "REGEX", "regex invalid"},
+		{"a", "a", "LESS_THAN", "operator necunoscut"},
+	}
+	for _, tt := range tests {
+		if got := comparisonNote(tt.actual, tt.expected, tt.comparison); !strings.HasPrefix(got, tt.want) || (tt.want == "") != (got == "") {
+			t.Errorf("%s %q %q: %q, asteptat %q", tt.comparison, tt.actual, tt.expected, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package collector
+
+import "strings"
+
+// CompareVersions compara doua versiuni dupa regulile managerului de pachete.
+// Rezultat: -1 (a < b), 0 (egal), 1 (a > b). Manager: "dpkg" sau "rpm".
+func CompareVersions(manager, a, b string) int {
+	if manager == "rpm" {
+		return compareRPMEVR(a, b)
+	}
+	return compareDebVersion(a, b)
+}
+
+// splitEpoch separa epoch-ul ("1:2.3" -> "1", "2.3")
+func splitEpoch(v string) (string, string) {
+	if i := strings.Index(v, ":"); i >= 0 {
+		return v[:i], v[i+1:]
+	}
+	return "0", v
+}
+
+func compareEpoch(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return sign(len(a) - len(b))
+	}
+	return sign(strings.Compare(a, b))
+}
+
+// compareDebVersion implementeaza ordonarea dpkg (epoch:upstream-revision)
+func compareDebVersion(a, b string) int {
+	ea, ra := splitEpoch(a)
+	eb, rb := splitEpoch(b)
+	if c := compareEpoch(ea, eb); c != 0 {
+		return c
+	}
+
+	ua, revA := ra, ""
+	if i := strings.LastIndex(ra, "-"); i >= 0 {
+		ua, revA = ra[:i], ra[i+1:]
+	}
+	ub, revB := rb, ""
+	if i := strings.LastIndex(rb, "-"); i >= 0 {
+		ub, revB = rb[:i], rb[i+1:]
+	}
+
+	if c := debVerRevCmp(ua, ub); c != 0 {
+		return c
+	}
+	return debVerRevCmp(revA, revB)
+}
+
+// debOrder: '~' inaintea oricarui caracter (si a sfarsitului), litere inaintea non-litere
+func debOrder(c byte) int {
+	switch {
+	case c == '~':
+		return -1
+	case c >= '0' && c <= '9':
+		return 0
+	case c == 0:
+		return 0
+	case isAlpha(c):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+func debVerRevCmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		firstDiff := 0
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			var ca, cb byte
+			if i < len(a) {
+				ca = a[i]
+			}
+			if j < len(b) {
+				cb = b[j]
+			}
+			ac, bc := debOrder(ca), debOrder(cb)
+			if ac != bc {
+				return sign(ac - bc)
+			}
+			i++
+			j++
+		}
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return sign(firstDiff)
+		}
+	}
+	return 0
+}
+
+// compareRPMEVR implementeaza ordonarea rpm (epoch:version-release)
+func compareRPMEVR(a, b string) int {
+	ea, ra := splitEpoch(a)
+	eb, rb := splitEpoch(b)
+	if c := compareEpoch(ea, eb); c != 0 {
+		return c
+	}
+
+	va, relA := ra, ""
+	if i := strings.LastIndex(ra, "-"); i >= 0 {
+		va, relA = ra[:i], ra[i+1:]
+	}
+	vb, relB := rb, ""
+	if i := strings.LastIndex(rb, "-"); i >= 0 {
+		vb, relB = rb[:i], rb[i+1:]
+	}
+
+	if c := rpmvercmp(va, vb); c != 0 {
+		return c
+	}
+	if relA == "" || relB == "" {
+		return 0
+	}
+	return rpmvercmp(relA, relB)
+}
+
+// rpmvercmp urmeaza algoritmul din rpmio/rpmvercmp.c
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	isSep := func(c byte) bool { return !isAlnum(c) && c != '~' && c != '^' }
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && isSep(a[i]) {
+			i++
+		}
+		for j < len(b) && isSep(b[j]) {
+			j++
+		}
+
+		// tilda sorteaza inaintea oricarui lucru
+		if (i < len(a) && a[i] == '~') || (j < len(b) && b[j] == '~') {
+			if i >= len(a) || a[i] != '~' {
+				return 1
+			}
+			if j >= len(b) || b[j] != '~' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		// caret: sorteaza dupa sfarsit, dar inaintea oricarui alt segment
+		if (i < len(a) && a[i] == '^') || (j < len(b) && b[j] == '^') {
+			if i >= len(a) {
+				return -1
+			}
+			if j >= len(b) {
+				return 1
+			}
+			if a[i] != '^' {
+				return 1
+			}
+			if b[j] != '^' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		if i >= len(a) || j >= len(b) {
+			break
+		}
+
+		si, sj := i, j
+		numeric := isDigit(a[i])
+		if numeric {
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+		} else {
+			for i < len(a) && isAlpha(a[i]) {
+				i++
+			}
+			for j < len(b) && isAlpha(b[j]) {
+				j++
+			}
+		}
+
+		segA, segB := a[si:i], b[sj:j]
+		if segB == "" {
+			// segmente de tip diferit: numeric e mai nou
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		if numeric {
+			segA = strings.TrimLeft(segA, "0")
+			segB = strings.TrimLeft(segB, "0")
+			if len(segA) != len(segB) {
+				return sign(len(segA) - len(segB))
+			}
+		}
+		if c := strings.Compare(segA, segB); c != 0 {
+			return c
+		}
+	}
+
+	if i >= len(a) && j >= len(b) {
+		return 0
+	}
+	if i >= len(a) {
+		return -1
+	}
+	return 1
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+func isAlpha(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isAlnum(c byte) bool { return isDigit(c) || isAlpha(c) }
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersionsDpkg(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~rc1", "1.0~rc2", -1},
+		{"1.0~~", "1.0~", -1},
+		{"1.0", "1.0+b1", -1},
+		{"1.0a", "1.0", 1},
+		{"1.0a", "1.0+", -1},
+		{"1:1.0", "2.0", 1},
+		{"0:1.0", "1.0", 0},
+		{"2:1.0", "10:0.1", -1},
+		{"1.2.3-1", "1.2.3-2", -1},
+		{"1.2.3-1ubuntu1", "1.2.3-1", 1},
+		{"1.2.3-10", "1.2.3-9", 1},
+		{"1:8.9p1-3ubuntu0.10", "1:8.9p1-3ubuntu0.4", 1},
+		{"2.36-9+deb12u4", "2.36-9+deb12u10", -1},
+		{"1.001", "1.1", 0},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions("dpkg", tt.a, tt.b); got != tt.want {
+			t.Errorf("dpkg %s vs %s = %d, vrem %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareVersions("dpkg", tt.b, tt.a); got != -tt.want {
+			t.Errorf("dpkg %s vs %s = %d, vrem %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestCompareVersionsRPM(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0:1.0-1", "0:1.0-1", 0},
+		{"0:1.0-1", "0:1.0-2", -1},
+		{"0:1.10-1", "0:1.9-1", 1},
+		{"1:1.0-1", "0:2.0-1", 1},
+		{"0:1.0~rc1-1", "0:1.0-1", -1},
+		{"0:1.0^git1-1", "0:1.0-1", 1},
+		{"0:1.0^git1-1", "0:1.0.1-1", -1},
+		{"0:1.0^-1", "0:1.0~-1", 1},
+		{"0:1.0a-1", "0:1.0-1", 1},
+		{"0:1.0-1.el9", "0:1.0-1.el8", 1},
+		{"0:2.a-1", "0:2.1-1", -1},
+		{"0:1.0_1-1", "0:1.0.1-1", 0},
+		{"0:1.0", "0:1.0-5", 0},
+		{"0:8.7p1-38.el9_4.4", "0:8.7p1-38.el9_4.1", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions("rpm", tt.a, tt.b); got != tt.want {
+			t.Errorf("rpm %s vs %s = %d, vrem %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareVersions("rpm", tt.b, tt.a); got != -tt.want {
+			t.Errorf("rpm %s vs %s = %d, vrem %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestParsePackageList(t *testing.T) {
+	// Ordinea rpm -qa nu e garantata; multilib repeta aceeasi versiune
+	rpm := parsePackageList("rpm", strings.Join([]string{
+		"kernel\t0:5.14.0-427.el9",
+		"glibc\t0:2.34-100.el9",
+		"kernel\t0:5.14.0-503.el9",
+		"glibc\t0:2.34-100.el9",
+		"kernel\t0:5.14.0-70.el9",
+	}, "\n"))
+	if got := strings.Join(rpm["kernel"].Versions, " "); got != "0:5.14.0-70.el9 0:5.14.0-427.el9 0:5.14.0-503.el9" {
+		t.Errorf("kernel: %s", got)
+	}
+	if got := rpm["glibc"].Versions; len(got) != 1 {
+		t.Errorf("glibc multilib: %v", got)
+	}
+
+	dpkg := parsePackageList("dpkg", "openssh-server\t1:9.2p1-2\tii \nold\t1.0\trc \n")
+	if _, ok := dpkg["old"]; ok || dpkg["openssh-server"].Newest() != "1:9.2p1-2" {
+		t.Errorf("dpkg: %+v", dpkg)
+	}
+}
+
+func TestRunPackageCheckNewestVersion(t *testing.T) {
+	for _, order := range [][]string{{"0:5.14.0-503.el9", "0:5.14.0-70.el9"}, {"0:5.14.0-70.el9", "0:5.14.0-503.el9"}} {
+		snap := &SystemSnapshot{loaded: true, manager: "rpm", packages: parsePackageList("rpm",
+			"kernel\t"+order[0]+"\nkernel\t"+order[1])}
+
+		res, err := RunPackageCheck(context.Background(), `{"name":"kernel","minVersion":"0:5.14.0-427.el9"}`, snap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Pass || res.Observed["version"] != "0:5.14.0-503.el9" || len(res.Observed["versions"].([]string)) != 2 {
+			t.Errorf("ordine %v: %+v", order, res)
+		}
+
+		res, _ = RunPackageCheck(context.Background(), `{"name":"kernel","maxVersion":"0:5.14.0-427.el9"}`, snap)
+		if res.Pass {
+			t.Errorf("ordine %v: maxVersion depasit de cea mai noua versiune acceptat", order)
+		}
+	}
+}
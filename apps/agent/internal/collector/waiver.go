@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/config"
+	"bittrail-agent/internal/waiver"
+)
+
+// waiverStore tine exceptiile gazdei (waiver_file); fisierul e recitit cand
+// se schimba, deci o exceptie noua sau revocata se aplica fara repornire
+type waiverStore struct {
+	path     string
+	key      []byte // cheia publica a aprobatorului; fara ea exceptiile sunt ignorate
+	serverID string
+
+	mu          sync.Mutex
+	modTime     time.Time
+	size        int64
+	set         *waiver.Set
+	lastWarning string          // ultimul avertisment de incarcare, afisat o singura data
+	expired     map[string]bool // exceptii expirate deja semnalate
+}
+
+func newWaiverStore(cfg *config.Config) *waiverStore {
+	s := &waiverStore{path: cfg.WaiverFile, serverID: cfg.ServerID, expired: make(map[string]bool)}
+	if cfg.WaiverKeyFile != "" {
+		key, err := os.ReadFile(cfg.WaiverKeyFile)
+		if err != nil {
+			log.Printf("WARNING: Waiver key %s unreadable: %v. Waivers will be ignored.", cfg.WaiverKeyFile, err)
+		} else {
+			s.key = key
+		}
+	}
+	return s
+}
+
+// lookup intoarce exceptia care acopera verificarea (vezi waiver.Set.Match);
+// o exceptie expirata e semnalata in log la prima folosire
+func (s *waiverStore) lookup(check api.PendingCheck, now time.Time) *waiver.Waiver {
+	if s == nil || s.path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reload(now)
+	if s.set == nil {
+		return nil
+	}
+	w := s.set.Match(check, now)
+	if w != nil && w.Expired(now) && !s.expired[w.ID] {
+		s.expired[w.ID] = true
+		log.Printf("WARNING: Waiver %s (approved by %s) expired at %s. Check %s is reported with its evaluated status.",
+			w.ID, w.Approver, w.ExpiresAt, check.CheckID)
+	}
+	return w
+}
+
+// reload reciteste fisierul daca s-a schimbat (mtime sau dimensiune); un
+// fisier invalid sau nesemnat anuleaza toate exceptiile
+func (s *waiverStore) reload(now time.Time) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		if s.set != nil || !os.IsNotExist(err) {
+			s.warn("WARNING: Waiver file %s unavailable: %v. Waivers ignored.", s.path, err)
+		}
+		s.set, s.modTime, s.size = nil, time.Time{}, 0
+		return
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return
+	}
+	s.set, s.modTime, s.size = nil, info.ModTime(), info.Size()
+
+	if len(s.key) == 0 {
+		s.warn("WARNING: Waiver file %s present but no approver key (waiver_key_file). Waivers ignored.", s.path)
+		return
+	}
+	set, err := waiver.Load(s.path, s.key, s.serverID)
+	if err != nil {
+		s.warn("SECURITY ALERT: Waiver file %s rejected: %v. Waivers ignored.", s.path, err)
+		return
+	}
+	s.set, s.lastWarning = set, ""
+	s.expired = make(map[string]bool)
+	log.Printf("Loaded %d waivers from %s", len(set.Waivers), s.path)
+	for _, w := range set.Waivers {
+		if w.Expired(now) {
+			s.expired[w.ID] = true
+			log.Printf("WARNING: Waiver %s (approved by %s) expired at %s.", w.ID, w.Approver, w.ExpiresAt)
+		}
+	}
+}
+
+// warn afiseaza un avertisment de incarcare o singura data, nu la fiecare verificare
+func (s *waiverStore) warn(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if msg != s.lastWarning {
+		s.lastWarning = msg
+		log.Print(msg)
+	}
+}
+
+// applyWaiver raporteaza ca WAIVED verificarea executata acoperita de o
+// exceptie activa, cu statusul evaluat in metadatele exceptiei; cu o exceptie
+// expirata statusul evaluat ramane, iar exceptia e semnalata ca expirata.
+// Doar rezultatele evaluate (PASS/FAIL/WARN) sunt acoperite: BLOCKED, ERROR,
+// SKIPPED sau NOT_APPLICABLE nu au un rezultat care sa fie acceptat.
+func (ar *AuditRunner) applyWaiver(check api.PendingCheck, result *api.CheckResult, now time.Time) {
+	switch result.Status {
+	case api.StatusPass, api.StatusFail, api.StatusWarn:
+	default:
+		return
+	}
+	w := ar.waivers.lookup(check, now)
+	if w == nil {
+		return
+	}
+	result.Waiver = w.Info(result.Status, now)
+	if !result.Waiver.Expired {
+		result.Status = api.StatusWaived
+	}
+}
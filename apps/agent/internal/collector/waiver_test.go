@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/waiver"
+)
+
+func TestApplyWaiver(t *testing.T) {
+	ar, key := signedTestRunner(t)
+	path := filepath.Join(t.TempDir(), "waivers.json")
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	writeWaivers := func(waivers ...waiver.Waiver) {
+		t.Helper()
+		f, err := waiver.Sign(key, &waiver.Set{ServerID: "srv-1", Waivers: waivers})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(f)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		// mtime distinct, ca rescrierea in aceeasi secunda sa fie observata
+		modTime := now.Add(time.Duration(len(waivers)) * time.Minute)
+		os.Chtimes(path, modTime, modTime)
+	}
+	writeWaivers(
+		waiver.Waiver{ID: "W-1", CheckIDs: []string{"c1"}, Justification: "legacy", Approver: "secops", ExpiresAt: "2026-12-31"},
+		waiver.Waiver{ID: "W-2", ControlIDs: []string{"CTL-2"}, Justification: "migrare", Approver: "secops", ExpiresAt: "2026-10-01"},
+	)
+	ar.waivers = &waiverStore{path: path, key: ar.backendKey, serverID: "srv-1", expired: make(map[string]bool)}
+
+	tests := []struct {
+		name    string
+		check   api.PendingCheck
+		status  string
+		want    string
+		waiver  string
+		expired bool
+	}{
+		{"esec acoperit", api.PendingCheck{CheckID: "c1"}, api.StatusFail, api.StatusWaived, "W-1", false},
+		{"conform acoperit", api.PendingCheck{CheckID: "c1"}, api.StatusPass, api.StatusWaived, "W-1", false},
+		{"avertisment acoperit", api.PendingCheck{CheckID: "c1"}, api.StatusWarn, api.StatusWaived, "W-1", false},
+		{"blocata", api.PendingCheck{CheckID: "c1"}, api.StatusBlocked, api.StatusBlocked, "", false},
+		{"eroare sau timeout", api.PendingCheck{CheckID: "c1"}, api.StatusError, api.StatusError, "", false},
+		{"nesuportata", api.PendingCheck{CheckID: "c1"}, api.StatusSkipped, api.StatusSkipped, "", false},
+		{"neaplicabila", api.PendingCheck{CheckID: "c1"}, api.StatusNotApplicable, api.StatusNotApplicable, "", false},
+		{"neacoperita", api.PendingCheck{CheckID: "c2", ControlID: "CTL-1"}, api.StatusFail, api.StatusFail, "", false},
+		{"exceptie expirata", api.PendingCheck{CheckID: "c3", ControlID: "CTL-2"}, api.StatusFail, api.StatusFail, "W-2", true},
+	}
+	for _, tt := range tests {
+		result := api.CheckResult{Status: tt.status}
+		ar.applyWaiver(tt.check, &result, now)
+		if result.Status != tt.want {
+			t.Errorf("%s: status %s, asteptat %s", tt.name, result.Status, tt.want)
+		}
+		if tt.waiver == "" {
+			if result.Waiver != nil {
+				t.Errorf("%s: exceptie %+v", tt.name, result.Waiver)
+			}
+			continue
+		}
+		if w := result.Waiver; w == nil || w.ID != tt.waiver || w.Expired != tt.expired || w.EvaluatedStatus != tt.status || w.Approver != "secops" {
+			t.Errorf("%s: exceptie %+v", tt.name, result.Waiver)
+		}
+	}
+
+	// Fisier schimbat: exceptia revocata nu mai e aplicata
+	writeWaivers(waiver.Waiver{ID: "W-3", CheckIDs: []string{"c9"}, Justification: "x", Approver: "secops", ExpiresAt: "2026-12-31"})
+	result := api.CheckResult{Status: api.StatusFail}
+	ar.applyWaiver(api.PendingCheck{CheckID: "c1"}, &result, now)
+	if result.Status != api.StatusFail || result.Waiver != nil {
+		t.Errorf("exceptie revocata aplicata: %+v", result)
+	}
+
+	// Fara cheia aprobatorului exceptiile sunt ignorate
+	ar.waivers = &waiverStore{path: path, serverID: "srv-1", expired: make(map[string]bool)}
+	result = api.CheckResult{Status: api.StatusFail}
+	ar.applyWaiver(api.PendingCheck{CheckID: "c9"}, &result, now)
+	if result.Status != api.StatusFail || result.Waiver != nil {
+		t.Errorf("exceptie fara cheie aplicata: %+v", result)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// Utilizatorul neprivilegiat al verificarilor fara runAs
+const DefaultCheckUser = "nobody"
+
 type Config struct {
 	ServerID   string `yaml:"server_id"`
 	ServerURL  string `yaml:"server_url"`
@@ -16,6 +19,75 @@ type Config struct {
 	InventoryInterval  int `yaml:"inventory_interval"`   // secunde
 	AuditCheckInterval int `yaml:"audit_check_interval"` // secunde
 
+	// Executie verificari
+	CheckTimeout    int `yaml:"check_timeout"`     // secunde, pentru verificari fara timeoutSeconds
+	MaxCheckTimeout int `yaml:"max_check_timeout"` // secunde, plafon pentru timeoutSeconds
+	KillGracePeriod int `yaml:"kill_grace_period"` // secunde intre SIGTERM si SIGKILL
+	MaxOutputBytes  int `yaml:"max_output_bytes"`  // plafon stdout/stderr trimis per verificare
+
+	// Sandbox verificari (Linux): namespace-uri + limite cgroup v2
+	SandboxMode       string `yaml:"sandbox_mode"`        // strict (implicit), best-effort, off
+	SandboxCgroupRoot string `yaml:"sandbox_cgroup_root"` // implicit sub cgroup-ul delegat al agentului
+	SandboxCPUPercent int    `yaml:"sandbox_cpu_percent"` // procent dintr-un CPU
+	SandboxMemoryMB   int    `yaml:"sandbox_memory_mb"`
+	SandboxPidsMax    int    `yaml:"sandbox_pids_max"`
+
+	// Verificarile observa implicit reteaua, procesele si /run ale gazdei
+	// (ss, pgrep, systemctl is-active); izolarea lor strica aceste verificari
+	SandboxIsolateNetwork bool `yaml:"sandbox_isolate_network"` // namespace de retea propriu
+	SandboxIsolatePID     bool `yaml:"sandbox_isolate_pid"`     // namespace PID propriu
+	SandboxIsolateRun     bool `yaml:"sandbox_isolate_run"`     // /run gol (fara socket-uri systemd, D-Bus, docker)
+
+	// Utilizatorul verificarilor fara runAs (nume sau uid); verificarile care
+	// au nevoie de root (/etc/shadow, sshd -T) il cer explicit cu runAs: root
+	CheckUser string `yaml:"check_user"` // implicit nobody
+
+	// Variabile preluate din mediul agentului (ex: HTTPS_PROXY); restul mediului
+	// verificarilor e fix: LC_ALL=C, TZ=UTC, PATH standard
+	CheckEnvAllowlist []string `yaml:"check_env_allowlist"`
+
+	// Variabile pe care template-urile le pot seta pe langa CHECK_* (ex: SSHD_CONFIG)
+	CheckEnvNames []string `yaml:"check_env_names"`
+
+	// Director pentru scripturile temporare (in afara /tmp, care e privat in sandbox)
+	ScriptDir string `yaml:"script_dir"` // implicit /var/lib/bittrail-agent/scripts
+
+	// Politica executie (allowlist programe, redirectari, substitutii)
+	ExecPolicyFile string `yaml:"exec_policy_file"` // implicit /etc/bittrail-agent/exec-policy.yaml
+
+	// Pool executie verificari
+	AuditWorkers        int `yaml:"audit_workers"`         // verificari executate simultan
+	AuditRunConcurrency int `yaml:"audit_run_concurrency"` // maxim simultan per rulare audit
+
+	// Heartbeat progres per rulare de audit; raspunsul poarta semnalul de anulare
+	AuditHeartbeatInterval int `yaml:"audit_heartbeat_interval"` // secunde
+
+	// Verificare payload-uri semnate de backend: cu cheia backend configurata,
+	// verificarile nesemnate, expirate sau reluate sunt refuzate
+	CheckSignatureMode string `yaml:"check_signature_mode"` // verify (implicit); strict: cheia backend e obligatorie
+	SignatureClockSkew int    `yaml:"signature_clock_skew"` // secunde toleranta ceas fata de backend
+	MaxCheckValidity   int    `yaml:"max_check_validity"`   // secunde, plafon expiresAt - issuedAt
+
+	// Stare persistenta agent (nonce-uri folosite, jurnal executii, registru rezultate)
+	StateDir string `yaml:"state_dir"` // implicit /var/lib/bittrail-agent
+
+	// Registru rezultate: o verificare (auditRunId, automatedCheckId) se executa o
+	// singura data; rezultatul e retrimis pana la confirmarea backend-ului
+	LedgerRetention int `yaml:"ledger_retention"` // secunde pastrare intrari
+
+	// Jurnal local hash-chained al executiilor; capul lantului e ancorat periodic la backend
+	JournalAnchorInterval int `yaml:"journal_anchor_interval"` // secunde
+
+	// Evaluare continua: seturile atribuite gazdei sunt pastrate local si
+	// reevaluate dupa programul lor cron; intervalul e cat de des e verificat
+	// programul si sincronizate atribuirile
+	ContinuousCheckInterval int `yaml:"continuous_check_interval"` // secunde
+
+	// Exceptii aprobate pentru gazda (verificari executate, raportate WAIVED);
+	// fisierul e semnat de aprobator si verificat cu cheia lui publica
+	WaiverFile    string `yaml:"waiver_file"`     // implicit /etc/bittrail-agent/waivers.json
+	WaiverKeyFile string `yaml:"waiver_key_file"` // cheie publica aprobator (PEM); fara ea exceptiile sunt ignorate
+
 	// Configurare PKI
 	KeyFile        string `yaml:"key_file"`
 	CertFile       string `yaml:"cert_file"`
@@ -38,8 +110,24 @@ func Load(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
+	setDefaults(&cfg)
+	return &cfg, nil
+}
 
-	// Valori implicite
+// LoadLocal incarca configurarea pentru comenzile locale (audit offline):
+// fara fisier (agent neinrolat) se folosesc valorile implicite
+func LoadLocal(path string) (*Config, error) {
+	cfg, err := Load(path)
+	if os.IsNotExist(err) {
+		cfg = &Config{}
+		setDefaults(cfg)
+		return cfg, nil
+	}
+	return cfg, err
+}
+
+// setDefaults completeaza valorile implicite
+func setDefaults(cfg *Config) {
 	if cfg.MetricsInterval == 0 {
 		cfg.MetricsInterval = 10
 	}
@@ -50,6 +138,78 @@ func Load(path string) (*Config, error) {
 		cfg.AuditCheckInterval = 5
 	}
 
+	if cfg.CheckTimeout == 0 {
+		cfg.CheckTimeout = 30
+	}
+	if cfg.MaxCheckTimeout == 0 {
+		cfg.MaxCheckTimeout = 600
+	}
+	if cfg.KillGracePeriod == 0 {
+		cfg.KillGracePeriod = 5
+	}
+	if cfg.MaxOutputBytes == 0 {
+		cfg.MaxOutputBytes = 64 * 1024
+	}
+
+	if cfg.SandboxMode == "" {
+		cfg.SandboxMode = "strict"
+	}
+	if cfg.SandboxCPUPercent == 0 {
+		cfg.SandboxCPUPercent = 50
+	}
+	if cfg.SandboxMemoryMB == 0 {
+		cfg.SandboxMemoryMB = 256
+	}
+	if cfg.SandboxPidsMax == 0 {
+		cfg.SandboxPidsMax = 128
+	}
+
+	if cfg.CheckUser == "" {
+		cfg.CheckUser = DefaultCheckUser
+	}
+
+	if cfg.ScriptDir == "" {
+		cfg.ScriptDir = "/var/lib/bittrail-agent/scripts"
+	}
+	if cfg.ExecPolicyFile == "" {
+		cfg.ExecPolicyFile = "/etc/bittrail-agent/exec-policy.yaml"
+	}
+
+	if cfg.CheckSignatureMode == "" {
+		cfg.CheckSignatureMode = "verify"
+	}
+	if cfg.SignatureClockSkew == 0 {
+		cfg.SignatureClockSkew = 300
+	}
+	if cfg.MaxCheckValidity == 0 {
+		cfg.MaxCheckValidity = 3600
+	}
+	if cfg.StateDir == "" {
+		cfg.StateDir = "/var/lib/bittrail-agent"
+	}
+	if cfg.LedgerRetention == 0 {
+		cfg.LedgerRetention = 7 * 24 * 3600 // 7 zile
+	}
+	if cfg.AuditHeartbeatInterval == 0 {
+		cfg.AuditHeartbeatInterval = 15
+	}
+	if cfg.JournalAnchorInterval == 0 {
+		cfg.JournalAnchorInterval = 3600 // 1 ora
+	}
+	if cfg.ContinuousCheckInterval == 0 {
+		cfg.ContinuousCheckInterval = 60
+	}
+	if cfg.WaiverFile == "" {
+		cfg.WaiverFile = "/etc/bittrail-agent/waivers.json"
+	}
+
+	if cfg.AuditWorkers <= 0 {
+		cfg.AuditWorkers = 4
+	}
+	if cfg.AuditRunConcurrency <= 0 || cfg.AuditRunConcurrency > cfg.AuditWorkers {
+		cfg.AuditRunConcurrency = cfg.AuditWorkers
+	}
+
 	// Valori implicite securitate
 	if cfg.AgentKeyPath == "" {
 		cfg.AgentKeyPath = "certs/agent.key"
@@ -63,8 +223,6 @@ func Load(path string) (*Config, error) {
 	if cfg.BackendPubPath == "" {
 		cfg.BackendPubPath = "certs/backend_pub.pem"
 	}
-
-	return &cfg, nil
 }
 
 func Save(path string, cfg *Config) error {
@@ -0,0 +1,279 @@
+// Package journal pastreaza jurnalul local append-only al executiilor:
+// fiecare intrare contine hash-ul intrarii anterioare si rezultatul semnat,
+// astfel incat orice modificare, stergere sau reordonare rupe lantul.
+package journal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"bittrail-agent/internal/api"
+)
+
+// GenesisHash e prevHash-ul primei intrari
+var GenesisHash = strings.Repeat("0", 64)
+
+// Tipuri de intrari
+const (
+	TypeResult = "result" // rezultat verificare semnat, fara iesire (doar outputHash)
+	TypeAnchor = "anchor" // capul lantului confirmat de backend
+)
+
+// Entry e o intrare din jurnal. Pe disc, fiecare linie e
+// {"hash":"...","entry":{...}}, cu hash = SHA-256 peste octetii exacti ai
+// intrarii: verificarea nu depinde de re-serializare (campuri adaugate ulterior).
+type Entry struct {
+	Seq        uint64           `json:"seq"`
+	Time       string           `json:"time"`
+	PrevHash   string           `json:"prevHash"`
+	Type       string           `json:"type"`
+	ServerID   string           `json:"serverId,omitempty"`
+	AuditRunID string           `json:"auditRunId,omitempty"`
+	Check      *CheckRecord     `json:"check,omitempty"`
+	Result     *api.CheckResult `json:"result,omitempty"`
+	Anchor     *Anchor          `json:"anchor,omitempty"`
+	Hash       string           `json:"-"`
+}
+
+// record e o linie din fisierul jurnal
+type record struct {
+	Hash  string          `json:"hash"`
+	Entry json.RawMessage `json:"entry"`
+}
+
+// CheckRecord descrie ce a executat agentul: payload-ul semnat de backend
+// (daca exista) si amprenta comenzii/scriptului
+type CheckRecord struct {
+	CheckID          string `json:"checkId"`
+	CheckType        string `json:"checkType,omitempty"`
+	CommandSHA256    string `json:"commandSha256"`
+	Payload          string `json:"payload,omitempty"`
+	PayloadSignature string `json:"payloadSignature,omitempty"`
+}
+
+// Anchor inregistreaza capul lantului trimis si confirmat de backend
+type Anchor struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// Head e ultima intrare din jurnal
+type Head struct {
+	Seq      uint64
+	Hash     string
+	Anchored bool // ultima intrare e o ancora: nimic nou de ancorat
+}
+
+// Journal scrie intrari in fisierul jurnal; sigur pentru goroutine multiple
+type Journal struct {
+	mu   sync.Mutex
+	path string
+	head Head
+	torn int64 // octetii liniei incomplete eliminate la deschidere
+}
+
+// TornTailError e o ultima linie fara terminator: o scriere intrerupta
+// (oprire brusca) dupa Offset octeti ai unui lant altfel valid
+type TornTailError struct {
+	Seq    uint64 // intrarea incompleta
+	Offset int64  // lungimea partii valide
+}
+
+func (e *TornTailError) Error() string {
+	return fmt.Sprintf("intrarea %d: linie incompleta", e.Seq)
+}
+
+// Open deschide jurnalul, verificand lantul existent. O ultima linie
+// incompleta (scriere intrerupta) e mutata in <path>.torn si eliminata;
+// orice alta abatere e o eroare (fisierul nu e modificat, ramane proba).
+func Open(path string) (*Journal, error) {
+	j := &Journal{path: path, head: Head{Hash: GenesisHash}}
+	summary, err := Verify(path, nil)
+	var torn *TornTailError
+	if errors.As(err, &torn) {
+		if j.torn, err = truncateTorn(path, torn.Offset); err != nil {
+			return nil, fmt.Errorf("eliminare linie incompleta: %w", err)
+		}
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if summary.Entries > 0 {
+		j.head = Head{Seq: summary.HeadSeq, Hash: summary.HeadHash, Anchored: summary.HeadType == TypeAnchor}
+	}
+	return j, nil
+}
+
+// truncateTorn pastreaza linia incompleta in <path>.torn (proba) si scurteaza
+// jurnalul la partea valida; intoarce numarul de octeti eliminati
+func truncateTorn(path string, offset int64) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	tail := data[offset:]
+	f, err := os.OpenFile(path+".torn", os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return 0, err
+	}
+	_, err = f.Write(append(tail, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Truncate(path, offset); err != nil {
+		return 0, err
+	}
+	return int64(len(tail)), nil
+}
+
+// TornBytes intoarce cati octeti ai unei linii incomplete au fost eliminati
+// la deschidere (0 = jurnal intact)
+func (j *Journal) TornBytes() int64 {
+	return j.torn
+}
+
+// Head intoarce capul curent al lantului
+func (j *Journal) Head() Head {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.head
+}
+
+// Append completeaza seq, time, prevHash si hash, apoi scrie intrarea
+// (O_APPEND + fsync); intoarce intrarea scrisa
+func (j *Journal) Append(e Entry) (Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e.Seq = j.head.Seq + 1
+	e.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	e.PrevHash = j.head.Hash
+	data, err := json.Marshal(e)
+	if err != nil {
+		return e, err
+	}
+	e.Hash = hashBytes(data)
+	line := []byte(`{"hash":"` + e.Hash + `","entry":` + string(data) + `}`)
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return e, fmt.Errorf("director jurnal: %w", err)
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return e, fmt.Errorf("deschidere jurnal: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return e, fmt.Errorf("scriere jurnal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return e, fmt.Errorf("sincronizare jurnal: %w", err)
+	}
+
+	j.head = Head{Seq: e.Seq, Hash: e.Hash, Anchored: e.Type == TypeAnchor}
+	return e, nil
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Summary descrie un jurnal verificat
+type Summary struct {
+	Entries  uint64
+	Results  uint64
+	Anchors  uint64
+	HeadSeq  uint64
+	HeadHash string
+	HeadType string
+}
+
+// Verify valideaza lantul: secventa continua, prevHash, hash-ul fiecarei
+// intrari si ancorele (trebuie sa indice o intrare anterioara cu acelasi
+// hash). verifyResult, daca nu e nil, verifica si semnatura rezultatelor.
+func Verify(path string, verifyResult func(Entry) error) (Summary, error) {
+	summary := Summary{HeadHash: GenesisHash}
+	f, err := os.Open(path)
+	if err != nil {
+		return summary, err
+	}
+	defer f.Close()
+
+	hashes := make(map[uint64]string)
+	reader := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return summary, err
+		}
+		if err == io.EOF {
+			// Ultima linie fara terminator: scriere intrerupta sau trunchiere
+			return summary, &TornTailError{Seq: summary.HeadSeq + 1, Offset: offset}
+		}
+		offset += int64(len(line))
+
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return summary, fmt.Errorf("intrarea %d: JSON invalid: %w", summary.HeadSeq+1, err)
+		}
+		if hashBytes(rec.Entry) != rec.Hash {
+			return summary, fmt.Errorf("intrarea %d: hash invalid (continut modificat)", summary.HeadSeq+1)
+		}
+		var e Entry
+		if err := json.Unmarshal(rec.Entry, &e); err != nil {
+			return summary, fmt.Errorf("intrarea %d: JSON invalid: %w", summary.HeadSeq+1, err)
+		}
+		e.Hash = rec.Hash
+		if e.Seq != summary.HeadSeq+1 {
+			return summary, fmt.Errorf("intrarea %d: secventa %d (intrari lipsa sau reordonate)", summary.HeadSeq+1, e.Seq)
+		}
+		if e.PrevHash != summary.HeadHash {
+			return summary, fmt.Errorf("intrarea %d: prevHash nu corespunde intrarii anterioare", e.Seq)
+		}
+
+		switch e.Type {
+		case TypeResult:
+			if e.Result == nil {
+				return summary, fmt.Errorf("intrarea %d: rezultat lipsa", e.Seq)
+			}
+			if verifyResult != nil {
+				if err := verifyResult(e); err != nil {
+					return summary, fmt.Errorf("intrarea %d: %w", e.Seq, err)
+				}
+			}
+			summary.Results++
+		case TypeAnchor:
+			if e.Anchor == nil || hashes[e.Anchor.Seq] != e.Anchor.Hash {
+				return summary, fmt.Errorf("intrarea %d: ancora nu corespunde jurnalului", e.Seq)
+			}
+			summary.Anchors++
+		default:
+			return summary, fmt.Errorf("intrarea %d: tip necunoscut %q", e.Seq, e.Type)
+		}
+
+		hashes[e.Seq] = e.Hash
+		summary.Entries++
+		summary.HeadSeq, summary.HeadHash, summary.HeadType = e.Seq, e.Hash, e.Type
+	}
+	return summary, nil
+}
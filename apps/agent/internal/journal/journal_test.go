@@ -0,0 +1,145 @@
+package journal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bittrail-agent/internal/api"
+)
+
+func writeJournal(t *testing.T, n int) (string, *Journal) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.log")
+	j, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		_, err := j.Append(Entry{
+			Type:       TypeResult,
+			AuditRunID: "run-1",
+			Check:      &CheckRecord{CheckID: "c1", CommandSHA256: "ab"},
+			Result:     &api.CheckResult{AutomatedCheckID: "ac-1", Status: api.StatusPass, ExitCode: i},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path, j
+}
+
+func TestJournalChain(t *testing.T) {
+	path, j := writeJournal(t, 3)
+	head := j.Head()
+	if _, err := j.Append(Entry{Type: TypeAnchor, Anchor: &Anchor{Seq: head.Seq, Hash: head.Hash}}); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := Verify(path, nil)
+	if err != nil {
+		t.Fatalf("jurnal valid respins: %v", err)
+	}
+	if summary.Entries != 4 || summary.Results != 3 || summary.Anchors != 1 || summary.HeadType != TypeAnchor {
+		t.Errorf("sumar %+v", summary)
+	}
+
+	// Redeschiderea continua lantul
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := reopened.Head(); h.Seq != 4 || !h.Anchored {
+		t.Errorf("cap dupa redeschidere: %+v", h)
+	}
+	if _, err := reopened.Append(Entry{Type: TypeResult, Result: &api.CheckResult{Status: api.StatusFail}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(path, nil); err != nil {
+		t.Errorf("lant continuat respins: %v", err)
+	}
+}
+
+func TestJournalTamper(t *testing.T) {
+	path, _ := writeJournal(t, 3)
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(original, []byte("\n"))
+	lines = lines[:len(lines)-1] // ultimul element e gol
+
+	tests := map[string][]byte{
+		"rezultat modificat": bytes.Replace(original, []byte(`"status":"PASS"`), []byte(`"status":"FAIL"`), 1),
+		"intrare stearsa":    bytes.Join([][]byte{lines[0], lines[2]}, nil),
+		"intrari inversate":  bytes.Join([][]byte{lines[0], lines[2], lines[1]}, nil),
+		// O linie incompleta in mijlocul lantului nu provine dintr-o oprire brusca
+		"linie trunchiata": bytes.Join([][]byte{lines[0], lines[1][:len(lines[1])/2], []byte("\n"), lines[2]}, nil),
+		"ancora falsa": append(append([]byte{}, original...),
+			[]byte(`{"hash":"x","entry":{"seq":4,"type":"anchor","anchor":{"seq":2,"hash":"00"}}}`+"\n")...),
+	}
+	for name, content := range tests {
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Verify(path, nil); err == nil {
+			t.Errorf("%s: acceptat", name)
+		}
+		if _, err := Open(path); err == nil {
+			t.Errorf("%s: Open accepta lantul invalid", name)
+		}
+	}
+}
+
+func TestJournalOpenTruncatesTornTail(t *testing.T) {
+	path, _ := writeJournal(t, 3)
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Oprire brusca in timpul scrierii intrarii 3
+	lines := bytes.SplitAfter(original, []byte("\n"))
+	valid := bytes.Join(lines[:2], nil)
+	torn := lines[2][:len(lines[2])/2]
+	if err := os.WriteFile(path, append(append([]byte{}, valid...), torn...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var tornErr *TornTailError
+	if _, err := Verify(path, nil); !errors.As(err, &tornErr) || tornErr.Seq != 3 || tornErr.Offset != int64(len(valid)) {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatalf("linie incompleta refuzata: %v", err)
+	}
+	if j.TornBytes() != int64(len(torn)) || j.Head().Seq != 2 {
+		t.Errorf("eliminat %d octeti, cap %+v", j.TornBytes(), j.Head())
+	}
+	if saved, _ := os.ReadFile(path + ".torn"); !bytes.Equal(saved, append(torn, '\n')) {
+		t.Errorf("linia incompleta nu e pastrata: %q", saved)
+	}
+	if _, err := j.Append(Entry{Type: TypeResult, Result: &api.CheckResult{Status: api.StatusPass}}); err != nil {
+		t.Fatal(err)
+	}
+	if summary, err := Verify(path, nil); err != nil || summary.HeadSeq != 3 {
+		t.Errorf("lant continuat dupa reparare: %+v %v", summary, err)
+	}
+}
+
+func TestJournalVerifyResult(t *testing.T) {
+	path, _ := writeJournal(t, 2)
+	_, err := Verify(path, func(e Entry) error {
+		if e.Result.ExitCode == 1 {
+			return os.ErrInvalid
+		}
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "intrarea 2") {
+		t.Errorf("semnatura invalida: %v", err)
+	}
+}
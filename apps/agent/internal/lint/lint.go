@@ -0,0 +1,256 @@
+// Package lint verifica un sablon de audit inainte de publicare: structura
+// bittrail-template@1.0, valorile suportate de agent, politica de executie si
+// portabilitatea comenzilor. Nicio verificare nu e executata.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/collector"
+	"bittrail-agent/internal/policy"
+	"bittrail-agent/internal/template"
+)
+
+// Severitatea unei constatari: erorile fac sablonul inutilizabil (sau
+// verificarea esueaza mereu), avertismentele semnaleaza fragilitate
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Finding e o constatare; Location e calea JSON (controls[0].automatedChecks[1])
+type Finding struct {
+	Severity string `json:"severity"`
+	Location string `json:"location,omitempty"`
+	CheckID  string `json:"checkId,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (f Finding) String() string {
+	s := f.Location
+	if f.CheckID != "" {
+		s += " (" + f.CheckID + ")"
+	}
+	if s != "" {
+		s += ": "
+	}
+	return s + f.Severity + ": " + f.Message
+}
+
+// Unelte care nu exista pe toate distributiile suportate, cu alternativa
+// portabila; program e alternativa executabila care, folosita in aceeasi
+// comanda (ss || netstat), face din unealta veche doar un fallback
+var nonPortable = map[string]struct{ alt, program string }{
+	"netstat":     {"ss (iproute2)", "ss"},
+	"ifconfig":    {"ip addr", "ip"},
+	"route":       {"ip route", "ip"},
+	"arp":         {"ip neigh", "ip"},
+	"iwconfig":    {"iw", "iw"},
+	"service":     {"systemctl", "systemctl"},
+	"chkconfig":   {"systemctl is-enabled", "systemctl"},
+	"lsb_release": {"/etc/os-release", ""},
+	"which":       {"command -v", ""},
+}
+
+// Managerele de pachete, pe familii de distributii: o comanda care trateaza
+// o singura familie, fara platformScope, esueaza pe celelalte
+var packageManagers = map[string]string{
+	"apt": "deb", "apt-get": "deb", "apt-cache": "deb", "dpkg": "deb", "dpkg-query": "deb",
+	"rpm": "rpm", "yum": "rpm", "dnf": "rpm", "zypper": "rpm",
+	"apk": "apk", "pacman": "pacman",
+}
+
+// Programele testate inainte de folosire (command -v dpkg && ...) au deja o
+// ramura pentru lipsa lor
+var probe = regexp.MustCompile(`\b(?:command\s+-v|type|hash)\s+([\w.+-]+)`)
+
+// File verifica sablonul din fisier; eroarea e intoarsa doar daca fisierul nu
+// poate fi citit
+func File(path string, pol *policy.Policy) ([]Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Data(data, pol), nil
+}
+
+// Data verifica sablonul; pol e politica de executie a agentilor tinta
+func Data(data []byte, pol *policy.Policy) []Finding {
+	var findings []Finding
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return []Finding{{Severity: SeverityError, Message: fmt.Sprintf("JSON invalid: %v", err)}}
+	}
+	var tpl template.Template
+	if err := json.Unmarshal(data, &tpl); err != nil {
+		return []Finding{{Severity: SeverityError, Message: fmt.Sprintf("schema: %v", err)}}
+	}
+
+	// Campurile necunoscute sunt ignorate la import: de obicei o greseala de
+	// scriere (expectedResults, comparation) care schimba rezultatul
+	for _, loc := range unknownFields(raw, reflect.TypeOf(tpl), "") {
+		findings = append(findings, Finding{Severity: SeverityWarning, Location: loc, Message: "camp necunoscut, ignorat"})
+	}
+	for _, err := range tpl.Validate() {
+		findings = append(findings, Finding{Severity: SeverityError, Message: err.Error()})
+	}
+
+	for i, control := range tpl.Controls {
+		for j, c := range control.AutomatedChecks {
+			loc := fmt.Sprintf("controls[%d].automatedChecks[%d]", i, j)
+			add := func(severity, format string, args ...interface{}) {
+				findings = append(findings, Finding{Severity: severity, Location: loc, CheckID: c.CheckID, Message: fmt.Sprintf(format, args...)})
+			}
+
+			check := c.PendingCheck("LINT")
+			for _, err := range collector.ValidateCheck(check) {
+				add(SeverityError, "%v", err)
+			}
+			if pol != nil && (c.Command != "" || c.Script != "") {
+				if err := collector.CheckPolicy(pol, check); err != nil {
+					add(SeverityError, "respinsa de politica de executie: %v", err)
+				}
+			}
+			if c.WarnResult != "" && c.ExpectedResult == "" {
+				add(SeverityWarning, "warnResult fara expectedResult: pragul WARN nu e evaluat")
+			}
+
+			for _, warning := range portability(check) {
+				add(SeverityWarning, "%s", warning)
+			}
+		}
+	}
+	return findings
+}
+
+// HasErrors spune daca exista erori (sau si avertismente, in modul strict)
+func HasErrors(findings []Finding, strict bool) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError || strict {
+			return true
+		}
+	}
+	return false
+}
+
+// portability intoarce avertismentele pentru uneltele nedisponibile pe toate
+// distributiile, executate de comanda sau de scriptul shell al verificarii
+func portability(check api.PendingCheck) []string {
+	src := check.Command
+	switch strings.ToUpper(check.CheckType) {
+	case "COMMAND", "":
+	case "SCRIPT":
+		switch path.Base(check.Interpreter) {
+		case ".", "sh", "bash", "dash":
+		default:
+			return nil
+		}
+		src = check.Script
+	default:
+		return nil // verificare nativa: nu executa nimic
+	}
+	// Un script neparsabil e deja raportat de politica
+	programs, _ := policy.Programs(src)
+
+	used := make(map[string]bool)
+	families := make(map[string]bool)
+	for _, program := range programs {
+		used[program] = true
+		if family, ok := packageManagers[program]; ok {
+			families[family] = true
+		}
+	}
+	probed := make(map[string]bool)
+	for _, m := range probe.FindAllStringSubmatch(src, -1) {
+		probed[m[1]] = true
+	}
+
+	var warnings []string
+	for _, program := range programs {
+		if probed[program] {
+			continue
+		}
+		if tool, ok := nonPortable[program]; ok && (tool.program == "" || !used[tool.program]) {
+			warnings = append(warnings, fmt.Sprintf("%s nu e disponibil pe toate distributiile; foloseste %s", program, tool.alt))
+		} else if packageManagers[program] != "" && len(families) == 1 && len(check.PlatformScope) == 0 {
+			warnings = append(warnings, fmt.Sprintf("%s depinde de distributie: restrange cu platformScope sau foloseste checkType PACKAGE", program))
+		}
+	}
+	return warnings
+}
+
+// unknownFields intoarce caile JSON ale cheilor fara corespondent in structura
+// t; valorile json.RawMessage si map-urile au continut liber
+func unknownFields(v interface{}, t reflect.Type, loc string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		return nil
+	}
+
+	var out []string
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				fields[name] = t.Field(i).Type
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyLoc := key
+			if loc != "" {
+				keyLoc = loc + "." + key
+			}
+			ft, ok := fieldType(fields, key)
+			if !ok {
+				out = append(out, keyLoc)
+				continue
+			}
+			out = append(out, unknownFields(obj[key], ft, keyLoc)...)
+		}
+	case reflect.Slice:
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range list {
+			out = append(out, unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", loc, i))...)
+		}
+	}
+	return out
+}
+
+// fieldType cauta campul ca encoding/json: potrivire exacta, apoi fara
+// diferente de majuscule
+func fieldType(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if ft, ok := fields[key]; ok {
+		return ft, true
+	}
+	for name, ft := range fields {
+		if strings.EqualFold(name, key) {
+			return ft, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,112 @@
+package lint
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bittrail-agent/internal/policy"
+)
+
+func defaultPolicy(t *testing.T) *policy.Policy {
+	t.Helper()
+	pol, err := policy.Parse(policy.DefaultPolicyYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pol
+}
+
+func TestData(t *testing.T) {
+	const header = `{"$schema":"bittrail-template@1.0","metadata":{"name":"t","version":"1"},"controls":[{"controlId":"C1","title":"c","category":"x","severity":"HIGH","automatedChecks":[`
+	tests := []struct {
+		name     string
+		checks   string
+		errors   []string
+		warnings []string
+	}{
+		{"valid", `{"checkId":"a","command":"ss -lnt | grep -c ':22 '","expectedResult":"1","comparison":"NUM_GE"}`, nil, nil},
+		{"regex si comparatie", `{"checkId":"a","command":"true","expectedResult":"^(x","comparison":"REGEX"},
+			{"checkId":"b","command":"true","comparison":"LESS_THAN_OR_EQUAL","parser":"INT"}`,
+			[]string{"regex invalid", `comparison "LESS_THAN_OR_EQUAL"`, `parser "INT"`}, nil},
+		{"checkType", `{"checkId":"a","command":"true","checkType":"SHELL"}`, []string{`checkType "SHELL"`}, nil},
+		{"duplicat", `{"checkId":"a","command":"true"},{"checkId":"a","command":"true"}`, []string{`checkId "a" duplicat`}, nil},
+		{"politica", `{"checkId":"a","command":"rm -rf /var/log"}`, []string{"politica de executie"}, nil},
+		{"camp necunoscut", `{"checkId":"a","command":"true","expectedResults":"x"}`, nil,
+			[]string{"controls[0].automatedChecks[0].expectedResults"}},
+		{"neportabil", `{"checkId":"a","command":"netstat -lnt"},{"checkId":"b","command":"ss -lnt || netstat -lnt"}`, nil,
+			[]string{"netstat nu e disponibil"}},
+		{"manager pachete", `{"checkId":"a","command":"dpkg -l openssh-server"},
+			{"checkId":"b","command":"dpkg -l openssh-server","platformScope":["debian","ubuntu"]},
+			{"checkId":"c","command":"if command -v rpm >/dev/null; then rpm -q openssh; fi"},
+			{"checkId":"d","command":"dpkg -l ssh 2>/dev/null || rpm -q openssh"}`, nil,
+			[]string{"dpkg depinde de distributie"}},
+		{"nativ", `{"checkId":"a","checkType":"SYSCTL","command":"{\"key\":\"net.ipv4.ip_forward\",\"value\":\"0\",\"comparison\":\"LT\"}"},
+			{"checkId":"b","checkType":"SERVICE_STATE","command":"{\"active\":true}"},
+			{"checkId":"c","checkType":"PACKAGE","command":"{\"installed\":false}"},
+			{"checkId":"d","checkType":"PORT_LISTENING","command":"{\"listening\":false}"},
+			{"checkId":"e","checkType":"PORT_LISTENING","command":"{\"port\":22}"}`,
+			[]string{`comparison "LT"`, "unit lipsa", "name lipsa", "port invalid"}, nil},
+		{"warnResult", `{"checkId":"a","command":"true","warnResult":"x"}`, nil, []string{"warnResult fara expectedResult"}},
+	}
+
+	pol := defaultPolicy(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := Data([]byte(header+tt.checks+`]}]}`), pol)
+			var errs, warnings []Finding
+			for _, f := range findings {
+				if f.Severity == SeverityError {
+					errs = append(errs, f)
+				} else {
+					warnings = append(warnings, f)
+				}
+			}
+			check := func(kind string, got []Finding, want []string) {
+				if len(got) != len(want) {
+					t.Fatalf("%s: %v, asteptat %v", kind, got, want)
+				}
+				for i, w := range want {
+					if !strings.Contains(got[i].String(), w) {
+						t.Errorf("%s %q nu contine %q", kind, got[i], w)
+					}
+				}
+			}
+			check("erori", errs, tt.errors)
+			check("avertismente", warnings, tt.warnings)
+			if HasErrors(findings, false) != (len(tt.errors) > 0) || HasErrors(findings, true) != (len(findings) > 0) {
+				t.Error("HasErrors nu corespunde constatarilor")
+			}
+		})
+	}
+
+	if f := Data([]byte(`{"controls":`), pol); len(f) != 1 || !strings.Contains(f[0].Message, "JSON invalid") {
+		t.Errorf("JSON invalid: %v", f)
+	}
+	if f := Data([]byte(header+`{"checkId":"a","command":"true","normalize":"LOWER"}]}]}`), pol); len(f) != 1 || !strings.Contains(f[0].Message, "schema") {
+		t.Errorf("tip gresit: %v", f)
+	}
+}
+
+// Sabloanele livrate trebuie sa treaca de lint cu politica implicita
+func TestShippedTemplates(t *testing.T) {
+	paths, err := filepath.Glob("../../../../templates/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Skip("sabloanele repo-ului lipsesc")
+	}
+	pol := defaultPolicy(t)
+	for _, path := range paths {
+		findings, err := File(path, pol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range findings {
+			if f.Severity == SeverityError {
+				t.Errorf("%s: %s", path, f)
+			}
+		}
+	}
+}
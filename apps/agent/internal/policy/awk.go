@@ -0,0 +1,339 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Implementarile awk ale caror programe sunt verificate
+var awkPrograms = setOf("awk", "gawk", "mawk", "nawk")
+
+// Optiunile awk (gawk, mawk, busybox) relevante pentru gasirea programului
+var awkOptions = optionSpec{
+	values: setOf("-F", "-v", "-e", "--field-separator", "--assign", "--source"),
+	reject: setOf(
+		"-f", "--file", "-E", "--exec", // program din fisier, nu poate fi verificat
+		"-i", "--include", "-l", "--load", // biblioteci awk sau extensii native
+		"-d", "--dump-variables", "-o", "--pretty-print", "-p", "--profile", // scriu fisiere
+		"-D", "--debug", "-W", // depanator interactiv; -W exec/dump (mawk)
+	),
+}
+
+// checkAwkPrograms verifica programele awk (argumentele -e/--source sau primul
+// operand), ca checkSedScripts pentru sed
+func checkAwkPrograms(args []*syntax.Word, vars map[string][]string) *Violation {
+	explicit := false // programe date prin -e; operanzii sunt doar fisiere
+	var programs []*syntax.Word
+	var operand *syntax.Word
+
+	endOfOptions := false
+	for i := 0; i < len(args); i++ {
+		values, ok := wordValues(args[i], vars)
+		if !ok || len(values) == 0 {
+			return newViolation(args[i], "argument dinamic pentru awk")
+		}
+		lit := values[0]
+		if endOfOptions || !strings.HasPrefix(lit, "-") || lit == "-" {
+			if operand == nil {
+				operand = args[i]
+			}
+			continue
+		}
+		if len(values) > 1 {
+			return newViolation(args[i], "optiune awk dinamica")
+		}
+		if lit == "--" {
+			endOfOptions = true
+			continue
+		}
+
+		takesValue, reason := awkOptions.option(lit)
+		if reason != "" {
+			return newViolation(args[i], "awk: "+reason)
+		}
+		if program, ok := awkAttachedProgram(lit); ok {
+			explicit = true
+			if !takesValue {
+				if v := checkAwkProgram(args[i], program); v != nil {
+					return v
+				}
+			} else if i+1 < len(args) {
+				programs = append(programs, args[i+1])
+			}
+		}
+		if takesValue {
+			i++
+		}
+	}
+
+	if !explicit && operand != nil {
+		programs = append(programs, operand)
+	}
+	for _, w := range programs {
+		values, ok := wordValues(w, vars)
+		if !ok {
+			return newViolation(w, "program awk dinamic")
+		}
+		for _, program := range values {
+			if v := checkAwkProgram(w, program); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// awkAttachedProgram recunoaste optiunile care introduc un program (-e, --source)
+// si intoarce programul lipit de optiune (-eBEGIN{...}, --source=...), daca exista
+func awkAttachedProgram(lit string) (string, bool) {
+	if strings.HasPrefix(lit, "--") {
+		name, value, _ := strings.Cut(lit, "=")
+		return value, name == "--source"
+	}
+	for j := 1; j < len(lit); j++ {
+		if lit[j] == 'e' {
+			return lit[j+1:], true
+		}
+		if awkOptions.values["-"+lit[j:j+1]] {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func checkAwkProgram(w *syntax.Word, program string) *Violation {
+	if reason := awkUnsafe(program); reason != "" {
+		return newViolation(w, "awk: "+reason)
+	}
+	return nil
+}
+
+// Cuvinte cheie dupa care un / incepe o expresie regulata (print /re/)
+var awkRegexKeywords = setOf("print", "printf", "return", "case", "do", "else", "exit")
+
+// Tokenuri dupa care un sfarsit de linie nu incheie instructiunea
+var awkContinues = setOf(",", "&&", "||", "{", "?", ":", "do", "else")
+
+// Cuvinte cheie a caror conditie intre paranteze poate fi urmata de o
+// expresie regulata (if (x) /re/)
+var awkConditionKeywords = setOf("if", "while", "for")
+
+// awkUnsafe parcurge programul awk token cu token si intoarce motivul
+// respingerii pentru constructiile care scriu fisiere sau executa programe:
+// redirectarile print/printf (>, >>), orice pipe (|, |&, | getline),
+// system() si apelurile indirecte sau directivele gawk (@). Sirurile,
+// expresiile regulate si comentariile sunt sarite ca un " sau / din ele sa
+// nu deplaseze restul programului. Un program neparsabil e respins.
+func awkUnsafe(program string) string {
+	s := &sedScanner{src: program}
+	regexOK := true // un / incepe o expresie regulata, nu o impartire
+	prev := ""      // ultimul token semnificativ
+
+	// Instructiunea print/printf curenta: se incheie la ; sau la sfarsit de
+	// linie la acelasi nivel de paranteze, ori la } care inchide blocul ei
+	inPrint := false
+	printParens, printBraces := 0, 0
+
+	braces := 0
+	var parens []bool // paranteza deschisa; adevarat = conditie if/while/for
+
+	for !s.eof() {
+		c := s.next()
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			continue
+		case c == '\\':
+			// Continuare de linie
+			if !s.eof() && s.peek() == '\r' {
+				s.i++
+			}
+			if s.eof() || s.next() != '\n' {
+				return "program neparsabil (\\ in afara unui sir)"
+			}
+			continue
+		case c == '#':
+			s.skipUntil("\n")
+			continue
+		case c == '\n' || c == ';':
+			continued := c == '\n' && awkContinues[prev]
+			if inPrint && len(parens) == printParens && !continued {
+				inPrint = false
+			}
+			regexOK = true
+			if c == '\n' && continued {
+				continue // prev ramane operatorul care continua instructiunea
+			}
+			prev = string(c)
+			continue
+		case c == '"':
+			if !awkString(s) {
+				return "sir neterminat"
+			}
+			regexOK = false
+			prev = "\""
+			continue
+		case c == '/' && regexOK:
+			if !awkRegex(s) {
+				return "expresie regulata neterminata"
+			}
+			regexOK = false
+			prev = "/re/"
+			continue
+		case isAwkWordStart(c):
+			start := s.i - 1
+			for !s.eof() && (isAwkWordStart(s.peek()) || s.peek() >= '0' && s.peek() <= '9') {
+				s.i++
+			}
+			word := s.src[start:s.i]
+			switch word {
+			case "system":
+				return "system() executa programe"
+			case "print", "printf":
+				if !inPrint {
+					inPrint = true
+					printParens, printBraces = len(parens), braces
+				}
+			}
+			regexOK = awkRegexKeywords[word]
+			prev = word
+			continue
+		case c >= '0' && c <= '9' || c == '.' && !s.eof() && s.peek() >= '0' && s.peek() <= '9':
+			for !s.eof() && (isAwkWordStart(s.peek()) || s.peek() >= '0' && s.peek() <= '9' || s.peek() == '.') {
+				s.i++
+			}
+			regexOK = false
+			prev = "0"
+			continue
+		}
+
+		op := string(c)
+		switch c {
+		case '@':
+			return "apel indirect sau directiva gawk (@)"
+		case '|':
+			if s.eof() || s.peek() != '|' {
+				return "pipe catre sau dinspre un program"
+			}
+			s.i++
+			op = "||"
+		case '>':
+			if !s.eof() && s.peek() == '=' {
+				s.i++
+				op = ">="
+			} else if inPrint {
+				return "redirectare a iesirii print/printf"
+			}
+		case '&':
+			if s.eof() || s.peek() != '&' {
+				return "program neparsabil (&)"
+			}
+			s.i++
+			op = "&&"
+		case '+', '-':
+			if !s.eof() && s.peek() == c {
+				s.i++
+				op += op
+			}
+		case '(', '[':
+			parens = append(parens, c == '(' && awkConditionKeywords[prev])
+		case ')', ']':
+			if len(parens) == 0 {
+				return fmt.Sprintf("program neparsabil (%c)", c)
+			}
+			condition := parens[len(parens)-1]
+			parens = parens[:len(parens)-1]
+			regexOK = condition
+			prev = op
+			continue
+		case '{':
+			braces++
+		case '}':
+			braces--
+			if inPrint && braces < printBraces {
+				inPrint = false
+			}
+		case '!', '~', '<', '=', '*', '%', '^', '?', ':', ',', '$', '/':
+		default:
+			return fmt.Sprintf("program neparsabil (caracter %q)", c)
+		}
+		// Dupa ++/-- (de obicei postfix) un / e impartire
+		regexOK = op != "++" && op != "--"
+		prev = op
+	}
+
+	if len(parens) > 0 || braces != 0 {
+		return "program neparsabil (paranteze neechilibrate)"
+	}
+	return ""
+}
+
+func isAwkWordStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// awkString sare peste un sir "...", dupa ghilimelele de deschidere
+func awkString(s *sedScanner) bool {
+	for !s.eof() {
+		switch s.next() {
+		case '\\':
+			s.i++
+		case '"':
+			return true
+		case '\n':
+			return false
+		}
+	}
+	return false
+}
+
+// awkRegex sare peste o expresie regulata /.../, dupa / de deschidere; un /
+// dintr-o lista intre paranteze drepte ([/]) nu o incheie
+func awkRegex(s *sedScanner) bool {
+	for !s.eof() {
+		switch s.next() {
+		case '\\':
+			s.i++
+		case '/':
+			return true
+		case '\n':
+			return false
+		case '[':
+			if !awkBracket(s) {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// awkBracket sare peste o lista [...] (dupa [), inclusiv clasele [:alpha:]
+func awkBracket(s *sedScanner) bool {
+	if !s.eof() && s.peek() == '^' {
+		s.i++
+	}
+	if !s.eof() && s.peek() == ']' {
+		s.i++
+	}
+	for !s.eof() {
+		c := s.next()
+		switch {
+		case c == ']':
+			return true
+		case c == '\n':
+			return false
+		case c == '\\':
+			s.i++
+		case c == '[' && !s.eof() && strings.IndexByte(":.=", s.peek()) >= 0:
+			end := string(s.peek()) + "]"
+			s.i++
+			k := strings.Index(s.src[s.i:], end)
+			if k < 0 {
+				return false
+			}
+			s.i += k + len(end)
+		}
+	}
+	return false
+}
@@ -0,0 +1,936 @@
+package policy
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Politica implicita, folosita cand fisierul operatorului lipseste
+//
+//go:embed default-policy.yaml
+var DefaultPolicyYAML []byte
+
+// Directoare standard din care un program din allowlist poate fi invocat prin cale absoluta
+var standardBinDirs = []string{"/bin", "/sbin", "/usr/bin", "/usr/sbin", "/usr/local/bin", "/usr/local/sbin"}
+
+// Programe care executa la randul lor un alt program (primul operand),
+// cu optiunile lor care consuma o valoare sau sunt interzise
+var wrapperPrograms = map[string]optionSpec{
+	"command": {},
+	"builtin": {},
+	"nohup":   {},
+	"busybox": {},
+	"env": {
+		values: setOf("-u", "--unset", "-C", "--chdir"),
+		reject: setOf("-S", "--split-string"), // imparte un sir in comanda si argumente
+	},
+	"xargs": {values: setOf("-I", "-a", "-d", "-E", "-L", "-n", "-P", "-s",
+		"--arg-file", "--delimiter", "--max-args", "--max-procs", "--max-chars", "--process-slot-var")},
+	"nice":    {values: setOf("-n", "--adjustment")},
+	"timeout": {values: setOf("-s", "--signal", "-k", "--kill-after")},
+	"stdbuf":  {values: setOf("-i", "-o", "-e", "--input", "--output", "--error")},
+	"ionice": {
+		values: setOf("-c", "-n", "--class", "--classdata"),
+		reject: setOf("-p", "-P", "-u", "--pid", "--pgid", "--uid"), // modifica alte procese
+	},
+}
+
+// Variabile ce pot prefixa o comanda (LC_ALL=C sort) sau fi setate prin env;
+// orice alta variabila ar putea schimba comportamentul programului executat
+// (LD_PRELOAD, GIT_EXTERNAL_DIFF, PAGER, ...)
+var prefixEnv = map[string]bool{"LC_ALL": true, "LANG": true, "LANGUAGE": true, "TZ": true, "COLUMNS": true}
+
+// Variabile exportate catre orice program pornit din script; nu pot fi
+// reatribuite nici fara export (PATH=/tmp; cat)
+var protectedVars = map[string]bool{
+	"PATH": true, "HOME": true, "SHELL": true, "ENV": true, "BASH_ENV": true,
+	"SHELLOPTS": true, "BASHOPTS": true, "PS4": true,
+}
+
+var protectedVarPrefixes = []string{"LD_", "DYLD_", "BASH_FUNC_"}
+
+// Builtin-uri care atribuie variabile numite de argumentele lor
+var assigningBuiltins = setOf("read", "printf", "mapfile", "readarray", "getopts")
+
+// Builtin-uri de declarare (export, local -x); prin AST sunt DeclClause,
+// dar apelate printr-un wrapper (builtin local -x ...) devin CallExpr
+var declBuiltins = setOf("export", "local", "declare", "typeset", "readonly", "nameref")
+
+// optionSpec descrie optiunile unui program in stil getopt
+type optionSpec struct {
+	values map[string]bool // optiuni urmate de o valoare (-n 5, --max-args 5)
+	reject map[string]bool // optiuni nepermise
+	allow  map[string]bool // singurele optiuni permise, pe langa values; nil = orice optiune
+}
+
+func setOf(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// option analizeaza un argument ce incepe cu "-": intoarce daca urmatorul
+// argument e valoarea lui, sau motivul respingerii. Optiunile lungi pot fi
+// prescurtate (getopt_long), deci un prefix al unei optiuni cu valoare e ambiguu.
+func (s optionSpec) option(lit string) (takesValue bool, reason string) {
+	if s.allow != nil {
+		return s.allowedOption(lit)
+	}
+	if strings.HasPrefix(lit, "--") {
+		name, _, hasValue := strings.Cut(lit, "=")
+		for opt := range s.reject {
+			if strings.HasPrefix(opt, name) {
+				return false, fmt.Sprintf("optiune %s nepermisa", opt)
+			}
+		}
+		if s.values[name] {
+			return !hasValue, ""
+		}
+		for opt := range s.values {
+			if strings.HasPrefix(opt, name) {
+				return false, fmt.Sprintf("optiune prescurtata ambigua %s (foloseste %s)", name, opt)
+			}
+		}
+		return false, ""
+	}
+
+	// Grup de optiuni scurte (-0I): o optiune cu valoare incheie grupul
+	for j := 1; j < len(lit); j++ {
+		opt := "-" + lit[j:j+1]
+		if s.reject[opt] {
+			return false, fmt.Sprintf("optiune %s nepermisa", opt)
+		}
+		if s.values[opt] {
+			return j == len(lit)-1, ""
+		}
+	}
+	return false, ""
+}
+
+// allowedOption aplica allowlist-ul de optiuni. Optiunile lungi sunt acceptate
+// doar in forma completa: o prescurtare getopt_long poate desemna o optiune
+// nepermisa. Optiunile cu o liniuta sunt cautate intai intregi (ip -brief),
+// apoi ca grup de optiuni scurte (ss -lntp).
+func (s optionSpec) allowedOption(lit string) (takesValue bool, reason string) {
+	if strings.HasPrefix(lit, "--") {
+		name, _, hasValue := strings.Cut(lit, "=")
+		if s.values[name] {
+			return !hasValue, ""
+		}
+		if s.allow[name] && !hasValue {
+			return false, ""
+		}
+		return false, fmt.Sprintf("optiune %s nepermisa", name)
+	}
+
+	if s.values[lit] {
+		return true, ""
+	}
+	if s.allow[lit] {
+		return false, ""
+	}
+	for j := 1; j < len(lit); j++ {
+		opt := "-" + lit[j:j+1]
+		if s.values[opt] {
+			return j == len(lit)-1, ""
+		}
+		if !s.allow[opt] {
+			return false, fmt.Sprintf("optiune %s nepermisa", opt)
+		}
+	}
+	return false, ""
+}
+
+// Policy e allowlist-ul de executie controlat de operator
+type Policy struct {
+	Programs          []string               `yaml:"programs"`          // nume (ex: grep) sau cale absoluta
+	DenyArgs          map[string][]string    `yaml:"denyArgs"`          // program -> regex-uri interzise pe argumente
+	WriteTargets      []string               `yaml:"writeTargets"`      // tinte permise pentru >, >> (glob)
+	ReadTargets       []string               `yaml:"readTargets"`       // tinte permise pentru < (glob); gol = orice
+	SourceTargets     []string               `yaml:"sourceTargets"`     // fisiere permise pentru . / source (glob)
+	AllowSubstitution bool                   `yaml:"allowSubstitution"` // $(...), `...`, <(...)
+	AllowFunctions    bool                   `yaml:"allowFunctions"`    // definitii de functii in scripturi
+	Interpreters      map[string]string      `yaml:"interpreters"`      // interpretoare SCRIPT: nume -> cale absoluta
+	Operands          map[string]OperandRule `yaml:"operands"`          // reguli pe operanzi (verbe permise, numar maxim)
+
+	programs map[string]bool
+	denyArgs map[string][]*regexp.Regexp
+	operands map[string]operandRule
+}
+
+// OperandRule restrictioneaza optiunile si operanzii unui program (argumentele
+// care nu sunt optiuni)
+type OperandRule struct {
+	ValueOptions []string `yaml:"valueOptions"` // optiuni urmate de o valoare separata (ex: -t service)
+	Options      []string `yaml:"options"`      // singurele optiuni permise, pe langa valueOptions; gol = orice optiune
+	Verbs        []string `yaml:"verbs"`        // primul operand trebuie sa fie unul dintre acestea
+	Actions      []string `yaml:"actions"`      // al doilea operand, daca exista (ip route show)
+	Pattern      string   `yaml:"pattern"`      // regex pe fiecare operand
+	Min          int      `yaml:"min"`          // numar minim de operanzi
+	Max          int      `yaml:"max"`          // numar maxim de operanzi; 0 = nelimitat
+}
+
+type operandRule struct {
+	spec     optionSpec
+	verbs    map[string]bool
+	actions  map[string]bool
+	pattern  *regexp.Regexp
+	min, max int
+}
+
+// restricted indica daca operandul de pe pozitia pos (de la 1) e supus unei
+// reguli, deci nu poate fi dinamic; inaintea lui "--", un argument dinamic
+// poate fi si o optiune din afara allowlist-ului
+func (r operandRule) restricted(pos int, endOfOptions bool) bool {
+	return (r.spec.allow != nil && !endOfOptions) ||
+		(pos == 1 && len(r.verbs) > 0) || (pos == 2 && len(r.actions) > 0) ||
+		r.pattern != nil || r.max > 0
+}
+
+// Violation descrie nodul AST respins
+type Violation struct {
+	Line   uint
+	Col    uint
+	Node   string
+	Reason string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%d:%d %q: %s", v.Line, v.Col, v.Node, v.Reason)
+}
+
+// Load incarca politica din fisier; daca fisierul lipseste, foloseste politica implicita
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Parse(DefaultPolicyYAML)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodeaza si compileaza politica YAML
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("politica executie invalida: %w", err)
+	}
+
+	p.programs = make(map[string]bool)
+	for _, prog := range p.Programs {
+		p.programs[prog] = true
+	}
+
+	p.denyArgs = make(map[string][]*regexp.Regexp)
+	for prog, exprs := range p.DenyArgs {
+		for _, expr := range exprs {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("politica executie invalida: denyArgs %s: %w", prog, err)
+			}
+			p.denyArgs[prog] = append(p.denyArgs[prog], re)
+		}
+	}
+
+	p.operands = make(map[string]operandRule)
+	for prog, rule := range p.Operands {
+		compiled := operandRule{
+			spec:    optionSpec{values: setOf(rule.ValueOptions...)},
+			verbs:   setOf(rule.Verbs...),
+			actions: setOf(rule.Actions...),
+			min:     rule.Min,
+			max:     rule.Max,
+		}
+		if len(rule.Options) > 0 {
+			compiled.spec.allow = setOf(rule.Options...)
+		}
+		if rule.Pattern != "" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("politica executie invalida: operands %s: %w", prog, err)
+			}
+			compiled.pattern = re
+		}
+		p.operands[prog] = compiled
+	}
+
+	for name, bin := range p.Interpreters {
+		if !path.IsAbs(bin) {
+			return nil, fmt.Errorf("politica executie invalida: interpretor %s: cale relativa %q", name, bin)
+		}
+	}
+
+	for _, group := range [][]string{p.WriteTargets, p.ReadTargets, p.SourceTargets} {
+		for _, pattern := range group {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("politica executie invalida: glob %q: %w", pattern, err)
+			}
+		}
+	}
+	return &p, nil
+}
+
+// Interpreter intoarce calea interpretorului permis (gol = sh)
+func (p *Policy) Interpreter(name string) (string, error) {
+	if name == "" {
+		name = "sh"
+	}
+	bin, ok := p.Interpreters[name]
+	if !ok {
+		return "", fmt.Errorf("interpretor %q nu e in allowlist", name)
+	}
+	return bin, nil
+}
+
+// Check parseaza scriptul in AST si verifica fiecare program invocat,
+// fiecare redirectare si fiecare substitutie. Intoarce primul nod respins.
+func (p *Policy) Check(script string) error {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(script), "")
+	if err != nil {
+		return &Violation{Node: firstLine(script), Reason: fmt.Sprintf("script neparsabil: %v", err)}
+	}
+
+	// Functiile definite in script pot fi apelate (corpul lor e verificat oricum)
+	functions := make(map[string]bool)
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if fn, ok := node.(*syntax.FuncDecl); ok {
+			functions[fn.Name.Value] = true
+		}
+		return true
+	})
+
+	vars := collectVars(file)
+
+	var violation *Violation
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if violation != nil {
+			return false
+		}
+		switch n := node.(type) {
+		case *syntax.CallExpr:
+			violation = p.checkCall(n, functions, vars)
+		case *syntax.DeclClause:
+			violation = p.checkDecl(n)
+		case *syntax.Assign:
+			if n.Name != nil && isProtectedVar(n.Name.Value) {
+				violation = newViolation(n, fmt.Sprintf("atribuire variabila protejata %s", n.Name.Value))
+			}
+		case *syntax.WordIter:
+			if isProtectedVar(n.Name.Value) {
+				violation = newViolation(n.Name, fmt.Sprintf("atribuire variabila protejata %s", n.Name.Value))
+			}
+		case *syntax.Redirect:
+			violation = p.checkRedirect(n)
+		case *syntax.CmdSubst:
+			if !p.AllowSubstitution {
+				violation = newViolation(n, "substitutie de comanda nepermisa")
+			}
+		case *syntax.ProcSubst:
+			if !p.AllowSubstitution {
+				violation = newViolation(n, "substitutie de proces nepermisa")
+			}
+		case *syntax.FuncDecl:
+			if !p.AllowFunctions {
+				violation = newViolation(n, "definitie de functie nepermisa")
+			}
+		case *syntax.CoprocClause:
+			violation = newViolation(n, "coproc nepermis")
+		}
+		return violation == nil
+	})
+
+	if violation != nil {
+		return violation
+	}
+	return nil
+}
+
+// Programs intoarce programele invocate de script (inclusiv cele executate prin
+// wrappere ca timeout sau xargs), in ordinea aparitiei; numele dinamice sunt ignorate
+func Programs(script string) ([]string, error) {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(script), "")
+	if err != nil {
+		return nil, fmt.Errorf("script neparsabil: %w", err)
+	}
+
+	var programs []string
+	seen := make(map[string]bool)
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok {
+			return true
+		}
+		args := call.Args
+		for len(args) > 0 {
+			name, ok := staticWord(args[0])
+			if !ok {
+				break
+			}
+			base := path.Base(name)
+			if !seen[base] {
+				seen[base] = true
+				programs = append(programs, base)
+			}
+			if _, ok := wrapperPrograms[base]; !ok {
+				break
+			}
+			args, _ = wrappedProgram(base, args[1:])
+		}
+		return true
+	})
+	return programs, nil
+}
+
+func (p *Policy) checkCall(call *syntax.CallExpr, functions map[string]bool, vars map[string][]string) *Violation {
+	if len(call.Args) == 0 {
+		return nil // doar atribuiri (VAR=...)
+	}
+
+	// VAR=... cmd exporta variabila doar catre cmd
+	for _, assign := range call.Assigns {
+		if assign.Name == nil || !prefixEnv[assign.Name.Value] {
+			return newViolation(assign, "variabila de mediu nepermisa ca prefix de comanda")
+		}
+	}
+
+	args := call.Args
+	for len(args) > 0 {
+		name, ok := staticWord(args[0])
+		if !ok {
+			return newViolation(args[0], "nume de program dinamic")
+		}
+		if functions[name] && !strings.Contains(name, "/") {
+			return nil
+		}
+		if !p.programAllowed(name) {
+			return newViolation(args[0], fmt.Sprintf("program %q nu e in allowlist", name))
+		}
+
+		base := path.Base(name)
+		if declBuiltins[base] {
+			return newViolation(args[0], fmt.Sprintf("%s apelat indirect", base))
+		}
+		own := args[1:]
+		var next []*syntax.Word
+		if _, ok := wrapperPrograms[base]; ok {
+			var v *Violation
+			next, v = wrappedProgram(base, args[1:])
+			if v != nil {
+				return v
+			}
+			own = args[1 : len(args)-len(next)]
+		}
+
+		// Scripturile incarcate in shell-ul curent trebuie sa fie in sourceTargets
+		if base == "." || base == "source" {
+			if len(own) == 0 {
+				return nil
+			}
+			target, ok := staticWord(own[0])
+			if !ok || strings.Contains(target, "..") || !matchesGlob(p.SourceTargets, target) {
+				return newViolation(own[0], fmt.Sprintf("fisier %q nepermis pentru %s", target, base))
+			}
+		}
+
+		// Argumentele proprii ale programului (fara cele ale programului executat de wrapper)
+		for _, arg := range own {
+			if len(p.denyArgs[base]) == 0 {
+				continue
+			}
+			values, ok := wordValues(arg, vars)
+			if !ok {
+				// Un argument dinamic ar putea deveni o optiune interzisa la executie
+				return newViolation(arg, fmt.Sprintf("argument dinamic pentru %s (program cu denyArgs)", base))
+			}
+			for _, lit := range values {
+				for _, re := range p.denyArgs[base] {
+					if re.MatchString(lit) {
+						return newViolation(arg, fmt.Sprintf("argument interzis pentru %s (%s)", base, re.String()))
+					}
+				}
+			}
+		}
+
+		if assigningBuiltins[base] {
+			for i, arg := range own {
+				lit, ok := staticWord(arg)
+				if !ok {
+					continue
+				}
+				if base == "printf" {
+					// printf atribuie doar variabila lui -v (-v VAR sau -vVAR)
+					switch {
+					case strings.HasPrefix(lit, "-v") && len(lit) > 2:
+						lit = lit[2:]
+					case i == 0 || !isLit(own[i-1], "-v"):
+						continue
+					}
+				}
+				if isProtectedVar(lit) {
+					return newViolation(arg, fmt.Sprintf("atribuire variabila protejata %s", lit))
+				}
+			}
+		}
+
+		if v := p.checkOperands(base, args[0], own, vars); v != nil {
+			return v
+		}
+		if base == "sed" {
+			if v := checkSedScripts(own, vars); v != nil {
+				return v
+			}
+		}
+		if awkPrograms[base] {
+			if v := checkAwkPrograms(own, vars); v != nil {
+				return v
+			}
+		}
+
+		// xargs adauga argumente din stdin, deci denyArgs si regulile pe operanzi nu pot fi verificate
+		if base == "xargs" && len(next) > 0 {
+			wrapped, _ := staticWord(next[0])
+			wrapped = path.Base(wrapped)
+			if _, ok := p.operands[wrapped]; ok || len(p.denyArgs[wrapped]) > 0 || wrapped == "sed" || awkPrograms[wrapped] {
+				return newViolation(next[0], fmt.Sprintf("%s nu poate fi executat prin xargs (program cu restrictii pe argumente)", wrapped))
+			}
+		}
+
+		args = next
+	}
+	return nil
+}
+
+// wrappedProgram intoarce argumentele incepand cu programul executat de wrapper
+// (gol daca wrapper-ul nu executa nimic, ex: command -v). Valorile optiunilor
+// (xargs -I cat) nu sunt confundate cu programul executat.
+func wrappedProgram(wrapper string, args []*syntax.Word) ([]*syntax.Word, *Violation) {
+	spec := wrapperPrograms[wrapper]
+	skipOperand := 0
+	if wrapper == "timeout" {
+		skipOperand = 1 // durata
+	}
+
+	endOfOptions := false
+	for i := 0; i < len(args); i++ {
+		lit, ok := staticWord(args[i])
+		if !ok {
+			return nil, newViolation(args[i], fmt.Sprintf("argument dinamic pentru %s", wrapper))
+		}
+		if !endOfOptions && lit == "--" {
+			endOfOptions = true
+			continue
+		}
+		if !endOfOptions && strings.HasPrefix(lit, "-") && lit != "-" {
+			if wrapper == "command" && (lit == "-v" || lit == "-V") {
+				return nil, nil // doar interogare, nu executa
+			}
+			takesValue, reason := spec.option(lit)
+			if reason != "" {
+				return nil, newViolation(args[i], fmt.Sprintf("%s: %s", wrapper, reason))
+			}
+			if takesValue {
+				i++
+			}
+			continue
+		}
+		if wrapper == "env" && strings.Contains(lit, "=") {
+			name, _, _ := strings.Cut(lit, "=")
+			if !prefixEnv[name] {
+				return nil, newViolation(args[i], fmt.Sprintf("env: variabila %s nepermisa", name))
+			}
+			continue
+		}
+		if skipOperand > 0 {
+			skipOperand--
+			continue
+		}
+		return args[i:], nil
+	}
+	return nil, nil
+}
+
+// checkDecl verifica declaratiile (local, export, ...): builtin-ul trebuie sa
+// fie permis, iar optiunile nu pot exporta (-x) sau crea referinte (-n)
+func (p *Policy) checkDecl(decl *syntax.DeclClause) *Violation {
+	if !p.programs[decl.Variant.Value] {
+		return newViolation(decl, fmt.Sprintf("program %q nu e in allowlist", decl.Variant.Value))
+	}
+	for _, assign := range decl.Args {
+		if !assign.Naked || assign.Value == nil {
+			continue
+		}
+		lit, ok := staticWord(assign.Value)
+		if !ok {
+			return newViolation(assign, fmt.Sprintf("argument dinamic pentru %s", decl.Variant.Value))
+		}
+		if (strings.HasPrefix(lit, "-") || strings.HasPrefix(lit, "+")) && strings.ContainsAny(lit, "xn") {
+			return newViolation(assign, fmt.Sprintf("optiune %s nepermisa pentru %s", lit, decl.Variant.Value))
+		}
+	}
+	return nil
+}
+
+func isProtectedVar(name string) bool {
+	if protectedVars[name] {
+		return true
+	}
+	for _, prefix := range protectedVarPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOperands aplica regulile pe optiuni si operanzi: optiunile permise,
+// verbul si actiunea permise (systemctl status, ip route show), forma
+// operanzilor (sysctl cheie, fara =) si numarul lor (uniq IN, fara fisierul
+// de iesire OUT; chronyc fara verb citeste comenzi din stdin)
+func (p *Policy) checkOperands(base string, cmd *syntax.Word, args []*syntax.Word, vars map[string][]string) *Violation {
+	rule, ok := p.operands[base]
+	if !ok {
+		return nil
+	}
+
+	count := 0
+	endOfOptions := false
+	for i := 0; i < len(args); i++ {
+		values, ok := wordValues(args[i], vars)
+		if !ok {
+			// Dupa verb, operanzii dinamici (ex: unitati) sunt permisi daca nu exista alte reguli
+			if rule.restricted(count+1, endOfOptions) {
+				return newViolation(args[i], fmt.Sprintf("argument dinamic pentru %s (program cu reguli pe operanzi)", base))
+			}
+			continue
+		}
+		lit := values[0]
+		if len(values) == 1 && !endOfOptions && lit == "--" {
+			endOfOptions = true
+			continue
+		}
+		if len(values) == 1 && !endOfOptions && strings.HasPrefix(lit, "-") && lit != "-" {
+			takesValue, reason := rule.spec.option(lit)
+			if reason != "" {
+				return newViolation(args[i], fmt.Sprintf("%s: %s", base, reason))
+			}
+			if takesValue {
+				i++
+			}
+			continue
+		}
+
+		// Valorile posibile ale unei variabile (for s in a b) sunt verificate pe aceeasi pozitie
+		count++
+		for _, lit := range values {
+			if !endOfOptions && strings.HasPrefix(lit, "-") && lit != "-" {
+				return newViolation(args[i], fmt.Sprintf("optiune dinamica pentru %s", base))
+			}
+			if v := rule.checkOperand(base, args[i], count, lit); v != nil {
+				return v
+			}
+		}
+		if len(values) > 1 && rule.max > 0 {
+			return newViolation(args[i], fmt.Sprintf("argument dinamic pentru %s (program cu reguli pe operanzi)", base))
+		}
+	}
+	if count < rule.min {
+		return newViolation(cmd, fmt.Sprintf("%s cere cel putin %d operanzi", base, rule.min))
+	}
+	return nil
+}
+
+func (r operandRule) checkOperand(base string, w *syntax.Word, pos int, lit string) *Violation {
+	if pos == 1 && len(r.verbs) > 0 && !r.verbs[lit] {
+		return newViolation(w, fmt.Sprintf("subcomanda %q nepermisa pentru %s", lit, base))
+	}
+	if pos == 2 && len(r.actions) > 0 && !r.actions[lit] {
+		return newViolation(w, fmt.Sprintf("actiune %q nepermisa pentru %s", lit, base))
+	}
+	if r.pattern != nil && !r.pattern.MatchString(lit) {
+		return newViolation(w, fmt.Sprintf("operand %q nepermis pentru %s (%s)", lit, base, r.pattern.String()))
+	}
+	if r.max > 0 && pos > r.max {
+		return newViolation(w, fmt.Sprintf("%s accepta cel mult %d operanzi", base, r.max))
+	}
+	return nil
+}
+
+func (p *Policy) programAllowed(name string) bool {
+	if !strings.Contains(name, "/") {
+		return p.programs[name]
+	}
+	if p.programs[name] {
+		return true
+	}
+	dir, base := path.Split(name)
+	dir = strings.TrimSuffix(dir, "/")
+	for _, d := range standardBinDirs {
+		if dir == d {
+			return p.programs[base]
+		}
+	}
+	return false
+}
+
+func (p *Policy) checkRedirect(r *syntax.Redirect) *Violation {
+	switch r.Op {
+	case syntax.Hdoc, syntax.DashHdoc, syntax.WordHdoc:
+		return nil
+	case syntax.DplIn, syntax.DplOut:
+		target, ok := staticWord(r.Word)
+		if ok && (target == "-" || isDigits(target)) {
+			return nil
+		}
+		return newViolation(r, "duplicare descriptor invalida")
+	}
+
+	if r.Op == syntax.RdrIn && len(p.ReadTargets) == 0 {
+		return nil
+	}
+
+	target, ok := staticWord(r.Word)
+	if !ok {
+		return newViolation(r, "tinta redirectare dinamica")
+	}
+
+	if r.Op == syntax.RdrIn {
+		if matchesGlob(p.ReadTargets, target) {
+			return nil
+		}
+		return newViolation(r, fmt.Sprintf("citire din %q nepermisa", target))
+	}
+
+	// >, >>, &>, >|, <> scriu in tinta
+	if matchesGlob(p.WriteTargets, target) {
+		return nil
+	}
+	return newViolation(r, fmt.Sprintf("scriere in %q nepermisa", target))
+}
+
+// collectVars strange valorile posibile ale variabilelor din script. O
+// variabila are valori cunoscute doar daca e atribuita exclusiv din
+// literali (VAR=lit, for VAR in lit...); altfel valoarea ei e nil.
+func collectVars(file *syntax.File) map[string][]string {
+	vars := make(map[string][]string)
+	unknown := make(map[string]bool)
+	add := func(name string, value string, ok bool) {
+		if !ok {
+			unknown[name] = true
+			return
+		}
+		vars[name] = append(vars[name], value)
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Assign:
+			if n.Name == nil {
+				return true
+			}
+			if n.Append || n.Array != nil || n.Index != nil || n.Naked {
+				unknown[n.Name.Value] = true
+				return true
+			}
+			if n.Value == nil {
+				add(n.Name.Value, "", true)
+				return true
+			}
+			value, ok := staticWord(n.Value)
+			add(n.Name.Value, value, ok)
+		case *syntax.WordIter:
+			if !n.InPos.IsValid() {
+				unknown[n.Name.Value] = true // for VAR; itereaza parametrii pozitionali
+				return true
+			}
+			for _, item := range n.Items {
+				value, ok := staticWord(item)
+				add(n.Name.Value, value, ok)
+			}
+		case *syntax.CallExpr:
+			// read, mapfile, getopts, printf -v etc. atribuie valori necunoscute
+			for _, arg := range n.Args {
+				if lit, ok := staticWord(arg); ok && isName(lit) {
+					unknown[lit] = true
+				}
+			}
+		}
+		return true
+	})
+
+	for name := range unknown {
+		delete(vars, name)
+	}
+	return vars
+}
+
+// wordValues intoarce valorile posibile ale unui argument: literalul insusi
+// sau valorile unei variabile cunoscute ("$VAR", $VAR, ${VAR})
+func wordValues(w *syntax.Word, vars map[string][]string) ([]string, bool) {
+	if lit, ok := staticWord(w); ok {
+		return []string{lit}, true
+	}
+	if len(w.Parts) != 1 {
+		return nil, false
+	}
+
+	part := w.Parts[0]
+	quoted := false
+	if dq, ok := part.(*syntax.DblQuoted); ok {
+		if len(dq.Parts) != 1 {
+			return nil, false
+		}
+		part = dq.Parts[0]
+		quoted = true
+	}
+	pe, ok := part.(*syntax.ParamExp)
+	if !ok || pe.Param == nil || pe.Excl || pe.Length || pe.Width || pe.Index != nil ||
+		pe.Slice != nil || pe.Repl != nil || pe.Names != 0 || pe.Exp != nil {
+		return nil, false
+	}
+	values, ok := vars[pe.Param.Value]
+	if !ok {
+		return nil, false
+	}
+	if quoted {
+		return values, true
+	}
+
+	// Fara ghilimele valoarea e impartita in cuvinte
+	var fields []string
+	for _, v := range values {
+		fields = append(fields, strings.Fields(v)...)
+	}
+	return fields, true
+}
+
+func isLit(w *syntax.Word, want string) bool {
+	lit, ok := staticWord(w)
+	return ok && lit == want
+}
+
+func isName(s string) bool {
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		return false
+	}
+	for _, c := range s {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// staticWord intoarce valoarea unui cuvant fara expansiuni (dupa eliminarea ghilimelelor)
+func staticWord(w *syntax.Word) (string, bool) {
+	if w == nil {
+		return "", false
+	}
+	var b strings.Builder
+	for _, part := range w.Parts {
+		switch x := part.(type) {
+		case *syntax.Lit:
+			b.WriteString(unescapeLit(x.Value))
+		case *syntax.SglQuoted:
+			if x.Dollar {
+				return "", false
+			}
+			b.WriteString(x.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range x.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				b.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// unescapeLit elimina backslash-urile din literalii neghilimelati (r\m -> rm)
+func unescapeLit(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == '\n' {
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func matchesGlob(patterns []string, target string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// newViolation construieste violarea cu textul sursa exact al nodului
+func newViolation(node syntax.Node, reason string) *Violation {
+	pos := node.Pos()
+	return &Violation{Line: pos.Line(), Col: pos.Col(), Node: nodeText(node), Reason: reason}
+}
+
+func nodeText(node syntax.Node) string {
+	var b strings.Builder
+	printer := syntax.NewPrinter(syntax.SingleLine(true))
+	switch n := node.(type) {
+	case *syntax.Redirect:
+		// Printer nu accepta Redirect direct; il afisam prin comanda-gazda minima
+		b.WriteString(n.Op.String())
+		if n.Word != nil {
+			printer.Print(&b, n.Word)
+		}
+	default:
+		if err := printer.Print(&b, node); err != nil {
+			return fmt.Sprintf("%T", node)
+		}
+	}
+	return firstLine(b.String())
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > 120 {
+		s = s[:120] + "..."
+	}
+	return s
+}
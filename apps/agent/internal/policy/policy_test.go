@@ -0,0 +1,350 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	p, err := Parse(DefaultPolicyYAML)
+	if err != nil {
+		t.Fatalf("politica implicita: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		allow  bool
+	}{
+		// Verificari uzuale
+		{"grep config", `grep -E '^PermitRootLogin' /etc/ssh/sshd_config`, true},
+		{"sed print", `sed -n 's/^PASS_MAX_DAYS[[:space:]]*//p' /etc/login.defs`, true},
+		{"sed -e", `sed -n -e '/^#/d' -e 's/a/b/gp' /etc/fstab`, true},
+		{"sed read", `sed -n '1r /etc/hostname' /etc/issue`, true},
+		{"sed address", `sed -n '/^\[main\]/,/^\[/{/^dns=/p}' /etc/NetworkManager/NetworkManager.conf`, true},
+		{"awk shadow", `awk -F: '($2 == "" ) { print $1 " are parola goala" }' /etc/shadow`, true},
+		{"awk string cu >", `awk '{ printf "%s -> %s\n", $1, $2 }' /etc/passwd`, true},
+		{"sort uniq", `cut -d: -f3 /etc/passwd | sort -n | uniq -d`, true},
+		{"uniq un operand", `uniq -c -f 1 /etc/group`, true},
+		{"systemctl is-active", `systemctl is-active sshd`, true},
+		{"systemctl optiuni", `systemctl --no-pager -t service --state running list-units`, true},
+		{"systemctl show", `systemctl show -p ActiveState --value auditd`, true},
+		{"systemctl bucla", "for s in sshd auditd; do systemctl is-enabled \"$s\"; done", true},
+		{"xargs grep", `find /etc -name '*.conf' | xargs -I{} grep -l foo {}`, true},
+		{"xargs -n", `echo a b | xargs -n 1 echo`, true},
+		{"git grep", `git -C /srv/app grep -n password`, true},
+
+		// sed: scriere si executie
+		{"sed e", `sed 'e touch /x' /etc/hostname`, false},
+		{"sed e dupa adresa", `sed -n '1e id' /etc/hostname`, false},
+		{"sed s///e", `sed 's/.*/id/e' /etc/hostname`, false},
+		{"sed s///w", `sed 's/a/b/w /etc/passwd' /etc/hostname`, false},
+		{"sed s///gw", `sed -e 's|a|b|gw /etc/passwd' /etc/hostname`, false},
+		{"sed w", `sed -n '/root/w /tmp/x' /etc/passwd`, false},
+		{"sed W dupa ;", `sed -n 'p;W /tmp/x' /etc/passwd`, false},
+		{"sed -e lipit", `sed -nes/a/b/w/tmp/x /etc/passwd`, false},
+		{"sed --expression", `sed --expression='1w /tmp/x' /etc/passwd`, false},
+		{"sed --in", `sed --in s/a/b/ /etc/passwd`, false},
+		{"sed --in-place", `sed --in-place=.bak s/a/b/ /etc/passwd`, false},
+		{"sed -i", `sed -i s/a/b/ /etc/passwd`, false},
+		{"sed -f", `sed -f /tmp/script /etc/passwd`, false},
+		{"sed variabila", "x='1w /tmp/x'; sed -n \"$x\" /etc/passwd", false},
+		{"sed prin xargs", `echo /etc/passwd | xargs sed -n p`, false},
+
+		// sort: fisier de iesire si program de compresie
+		{"sort -o", `sort -o /etc/passwd /etc/group`, false},
+		{"sort -uo", `sort -uo /etc/passwd /etc/group`, false},
+		{"sort --output", `sort --output=/etc/passwd /etc/group`, false},
+		{"sort --out", `sort --out /etc/passwd /etc/group`, false},
+		{"sort --compress-program", `sort --compress-program=sh /etc/group`, false},
+		{"sort --compress", `sort -S 1 --compress sh /etc/group`, false},
+
+		// uniq: al doilea operand e fisierul de iesire
+		{"uniq doi operanzi", `uniq /etc/group /etc/passwd`, false},
+		{"uniq optiuni si doi operanzi", `uniq -f 1 -c in /etc/passwd`, false},
+
+		// awk: iesire catre variabile
+		{"awk pipe variabila", `awk 'BEGIN { c = "touch /x"; print "" | c }'`, false},
+		{"awk redirect variabila", `awk 'BEGIN { f = "/etc/passwd"; print "" > f }'`, false},
+		{"awk redirect sir", `awk '{ print > "/tmp/x" }' /etc/passwd`, false},
+		{"awk printf pipe", `awk '{ printf "%s", $0 | "sh" }'`, false},
+
+		// git: pager si programe externe
+		{"git grep -O", `git grep -O'touch /x' foo`, false},
+		{"git grep --open-files-in-pager", `git grep --open-files-in-pager=sh foo`, false},
+		{"git grep --op", `git grep --op=sh foo`, false},
+		{"git diff --ext-diff", `git diff --ext-diff`, false},
+
+		// rpm: macro-uri %(...) executa comenzi
+		{"rpm --eval", `rpm --eval '%(id)'`, false},
+		{"rpm -E", `rpm -E '%(id)'`, false},
+		{"rpm --define", `rpm -q --define '_dbpath %(id)' bash`, false},
+		{"rpm --pipe", `rpm -qa --pipe sh`, false},
+		{"rpm -qa", `rpm -qa`, true},
+
+		// git: programe externe prin mediu sau optiuni
+		{"git GIT_EXTERNAL_DIFF prefix", `GIT_EXTERNAL_DIFF='touch /tmp/pwn;' git diff --no-index a b`, false},
+		{"git GIT_EXTERNAL_DIFF export", `export GIT_EXTERNAL_DIFF=id; git diff --no-index a b`, false},
+		{"git ls-remote --upload-pack", `git ls-remote --upload-pack='touch /tmp/x' .`, false},
+		{"git archive --exec", `git archive --remote=. --exec=id HEAD`, false},
+		{"git --config-env", `git --config-env=core.pager=HOME log`, false},
+		{"git --receive-pack", `git send-pack --receive-pack=id . HEAD`, false},
+		{"git --version", `git --version`, true},
+
+		// mediu: prefixe, export, allexport si variabile protejate
+		{"LD_PRELOAD prefix", `LD_PRELOAD=/lib/x.so cat /etc/passwd`, false},
+		{"LC_ALL prefix", `LC_ALL=C sort /etc/passwd`, true},
+		{"declare -x", `declare -x GIT_EXTERNAL_DIFF=id`, false},
+		{"typeset -x", `typeset -x GIT_EXTERNAL_DIFF=id`, false},
+		{"local -x", `f() { local -x GIT_EXTERNAL_DIFF=id; git diff; }; f`, false},
+		{"local -n", `f() { local -n r=PATH; r=/tmp; cat; }; f`, false},
+		{"local", `f() { local n=1; echo "$n"; }; f`, true},
+		{"builtin local", `f() { builtin local -x GIT_EXTERNAL_DIFF=id; git diff; }; f`, false},
+		{"set -a", `set -a; GIT_EXTERNAL_DIFF=id; git diff`, false},
+		{"set -o allexport", `set -o allexport; GIT_EXTERNAL_DIFF=id; git diff`, false},
+		{"set -k", `set -k; git diff GIT_EXTERNAL_DIFF=id`, false},
+		{"PATH", `PATH=/tmp; cat /etc/passwd`, false},
+		{"HOME", `HOME=/tmp; git log`, false},
+		{"for PATH", `for PATH in /tmp; do cat /etc/passwd; done`, false},
+		{"read PATH", `read PATH < /etc/hostname; cat /etc/passwd`, false},
+		{"printf -v PATH", `printf -v PATH /tmp; cat /etc/passwd`, false},
+		{"variabila locala", `GIT_DIRS=$(find / -maxdepth 4 -name .git | wc -l); echo "$GIT_DIRS"`, true},
+
+		// awk: coprocese si apeluri indirecte
+		{"awk |&", `awk 'BEGIN{"id" |& getline x}'`, false},
+		{"awk apel indirect", `awk 'BEGIN{f="system"; @f("id")}'`, false},
+		{"awk -f", `awk -f /tmp/x /etc/passwd`, false},
+		{"awk -i inplace", `awk -i inplace '{print}' /etc/passwd`, false},
+		{"awk \" in regex", `awk 'BEGIN{print /"/ > "/tmp/pwn"}'`, false},
+		{"awk -e", `awk -e 'BEGIN{print 1 >> "/tmp/x"}'`, false},
+		{"awk --source", `awk --source='BEGIN{system("id")}'`, false},
+		{"awk -d", `awk -d/tmp/x 'BEGIN{}'`, false},
+		{"awk --profile", `awk --profile=/tmp/x 'BEGIN{}'`, false},
+		{"awk prin xargs", `echo /etc/passwd | xargs awk '{print}'`, false},
+		{"awk comparatie", `awk -F: '$3 >= 1000 && $3 > 0 {print $1}' /etc/passwd`, true},
+		{"awk regex cu \"", `awk '/"/ {n++} END {print n}' /etc/passwd`, true},
+
+		// wrapper-e: valorile optiunilor nu sunt programul executat
+		{"xargs -I program", `echo x | xargs -I cat rm -rf cat`, false},
+		{"xargs -Icat lipit", `echo x | xargs -Icat rm cat`, false},
+		{"xargs -0I", `echo x | xargs -0I cat rm cat`, false},
+		{"xargs --delimiter", `echo x | xargs --delimiter cat rm`, false},
+		{"xargs optiune prescurtata", `echo x | xargs --max cat rm`, false},
+
+		// systemctl: doar verbe de citire
+		{"systemctl force-reload", `systemctl force-reload sshd`, false},
+		{"systemctl try-reload-or-restart", `systemctl try-reload-or-restart sshd`, false},
+		{"systemctl hybrid-sleep", `systemctl hybrid-sleep`, false},
+		{"systemctl soft-reboot", `systemctl soft-reboot`, false},
+		{"systemctl verb dupa optiune cu valoare", `systemctl -t service stop sshd`, false},
+		{"systemctl verb dinamic", "v=stop; systemctl $v sshd", false},
+		{"systemctl -H", `systemctl -H root@host is-active sshd`, false},
+		{"systemctl --host", `systemctl --host=root@host status`, false},
+		{"systemctl --ho prescurtat", `systemctl --ho root@host status`, false},
+		{"systemctl optiune dinamica", "h=-Hhost; systemctl $h status", false},
+
+		// sysctl, ss, chronyc, ip: doar optiuni si subcomenzi de citire
+		{"sysctl cheie", `sysctl -n net.ipv4.ip_forward`, true},
+		{"sysctl -a", `sysctl -a --pattern '^net\.'`, true},
+		{"sysctl -f", `sysctl -f /tmp/x.conf`, false},
+		{"sysctl -p", `sysctl -p`, false},
+		{"sysctl --system", `sysctl --system`, false},
+		{"sysctl -w", `sysctl -w net.ipv4.ip_forward=1`, false},
+		{"sysctl cheie=valoare", `sysctl net.ipv4.ip_forward=1`, false},
+		{"ss -lntup", `ss -lntup`, true},
+		{"ss filtru", `ss -tn state established '( dport = :22 )'`, true},
+		{"ss -K", `ss -K dst 10.0.0.1`, false},
+		{"ss -tK", `ss -tK`, false},
+		{"ss --kill", `ss --kill`, false},
+		{"ss -D", `ss -D /tmp/x`, false},
+		{"chronyc tracking", `chronyc -n tracking`, true},
+		{"chronyc sources -v", `chronyc sources -v`, true},
+		{"chronyc makestep", `chronyc makestep`, false},
+		{"chronyc settime", `chronyc settime 12:00`, false},
+		{"chronyc offline", `chronyc offline`, false},
+		{"chronyc -m", `chronyc -m tracking makestep`, false},
+		{"chronyc -h", `chronyc -h 10.0.0.1 tracking`, false},
+		{"chronyc stdin", `echo makestep | chronyc`, false},
+		{"ip -br addr", `ip -br addr`, true},
+		{"ip route show", `ip -4 route show default`, true},
+		{"ip xfrm deleteall", `ip xfrm state deleteall`, false},
+		{"ip link set", `ip link set eth0 down`, false},
+		{"ip netns exec", `ip netns exec x id`, false},
+		{"ip -batch", `ip -batch /tmp/x`, false},
+		{"ip -force", `ip -force -b /tmp/x`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Check(tt.script)
+			if tt.allow && err != nil {
+				t.Errorf("respins: %v", err)
+			}
+			if !tt.allow && err == nil {
+				t.Errorf("permis: %s", tt.script)
+			}
+		})
+	}
+}
+
+func TestWrappedProgram(t *testing.T) {
+	p, err := Parse([]byte(`
+programs: [cat, echo, ss, env, nice, timeout, stdbuf, ionice, xargs, command]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		script string
+		allow  bool
+	}{
+		{`timeout -s KILL 5 ss -tlnp`, true},
+		{`timeout --kill-after=1 5 ss -tlnp`, true},
+		{`nice -n 5 cat /etc/hostname`, true},
+		{`nice -5 cat /etc/hostname`, true},
+		{`env -u HOME LC_ALL=C cat /etc/hostname`, true},
+		{`stdbuf -o L cat /etc/hostname`, true},
+		{`ionice -c 3 cat /etc/hostname`, true},
+		{`command -v rm`, true},
+		{`xargs -I{} -n 1 echo {}`, true},
+		{`xargs -- cat`, true},
+
+		{`timeout -k 1 5 rm -rf /`, false},
+		{`timeout -s cat 5 rm /x`, false},
+		{`nice -n cat rm /x`, false},
+		{`nice --adjustment cat rm /x`, false},
+		{`env -u cat rm /x`, false},
+		{`env -S 'rm -rf /'`, false},
+		{`env -iS 'rm -rf /'`, false},
+		{`env --split-string='rm -rf /'`, false},
+		{`env --sp='rm -rf /'`, false},
+		{`env LD_PRELOAD=/lib/x.so cat /etc/passwd`, false},
+		{`env -u HOME GIT_EXTERNAL_DIFF=id cat /etc/passwd`, false},
+		{`stdbuf -o cat rm /x`, false},
+		{`ionice -p 1`, false},
+		{`xargs -I cat rm -rf cat`, false},
+		{`xargs -Icat rm cat`, false},
+		{`xargs -0I cat rm cat`, false},
+		{`xargs -a cat rm`, false},
+		{`xargs --delimiter cat rm`, false},
+		{`xargs --max cat rm`, false},
+		{`command cat rm`, true}, // cat executa rm ca fisier, nu ca program
+		{`command rm /x`, false},
+	}
+	for _, tt := range tests {
+		err := p.Check(tt.script)
+		if tt.allow && err != nil {
+			t.Errorf("%s respins: %v", tt.script, err)
+		}
+		if !tt.allow && err == nil {
+			t.Errorf("%s permis", tt.script)
+		}
+	}
+}
+
+func TestSedUnsafeCommand(t *testing.T) {
+	tests := []struct {
+		script string
+		safe   bool
+	}{
+		{`p`, true},
+		{`s/a/b/g`, true},
+		{`s/a\/w/b/2p`, true},
+		{`s|w|e|`, true},
+		{`/^#/d;/^$/d`, true},
+		{`$!N;P;D`, true},
+		{`1~2p`, true},
+		{`/start/,+3p`, true},
+		{`\%x%p`, true},
+		{`a\` + "\n" + `w text`, true},
+		{`y/abc/xyz/`, true},
+		{`:a;N;ba`, true},
+		{`w /tmp/x`, false},
+		{`1,5W /tmp/x`, false},
+		{`/x/!e`, false},
+		{`s/a/b/e`, false},
+		{`s/a/b/gpw /tmp/x`, false},
+		{`{p;w /tmp/x` + "\n" + `}`, false},
+		{`s/a/b`, false},
+		{`k`, false},
+	}
+	for _, tt := range tests {
+		reason := sedUnsafeCommand(tt.script)
+		if tt.safe && reason != "" {
+			t.Errorf("%q respins: %s", tt.script, reason)
+		}
+		if !tt.safe && reason == "" {
+			t.Errorf("%q permis", tt.script)
+		}
+	}
+}
+
+func TestAwkUnsafe(t *testing.T) {
+	tests := []struct {
+		program string
+		safe    bool
+	}{
+		{`{print $1}`, true},
+		{`NR>1 {gsub(/%/,"",$5); print $5}`, true},
+		{`($2 == "") {print $1}`, true},
+		{`$3 > 999 { n++ } END { print n / 2 }`, true},
+		{`{ printf "%s -> %s\n", $1, $2 }`, true},
+		{`/[/"]/ { print }`, true},
+		{`/^[[:space:]]*#/ { next } { print }`, true},
+		{`{ if ($1 ~ /x/) print "a"; x = $2 > 3 }`, true},
+		{`{ while ((getline line < "/etc/hosts") > 0) n++ }`, true},
+		{"# comentariu cu \" si >\n{ print }", true},
+
+		{`{ print > "/tmp/x" }`, false},
+		{`{ print $1 >> "/tmp/x" }`, false},
+		{`{ printf("%s", $0) > "/tmp/x" }`, false},
+		{`BEGIN { print /"/ > "/tmp/pwn" }`, false},
+		{`BEGIN { if (1) /"/; print 1 > "/tmp/x"; x = "/" }`, false},
+		{"BEGIN { print 1,\n > \"/tmp/x\" }", false},
+		{`{ print | "sh" }`, false},
+		{`BEGIN { "id" | getline x }`, false},
+		{`BEGIN { "id" |& getline x }`, false},
+		{`BEGIN { system("id") }`, false},
+		{`BEGIN { system ("id") }`, false},
+		{`BEGIN { f = "system"; @f("id") }`, false},
+		{`@include "x"`, false},
+		{`BEGIN { x = "a }`, false},
+		{`BEGIN { x = /a }`, false},
+		{`BEGIN { print (1 }`, false},
+	}
+	for _, tt := range tests {
+		reason := awkUnsafe(tt.program)
+		if tt.safe && reason != "" {
+			t.Errorf("%q respins: %s", tt.program, reason)
+		}
+		if !tt.safe && reason == "" {
+			t.Errorf("%q permis", tt.program)
+		}
+	}
+}
+
+func TestPrograms(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{`ss -lnt | grep :22`, "ss grep"},
+		{`timeout 5 /usr/bin/netstat -lnt || ss -lnt`, "timeout netstat ss"},
+		{`if command -v dpkg >/dev/null; then dpkg -l; fi`, "command dpkg"},
+		{`X=$(ifconfig); echo "$X"`, "ifconfig echo"},
+		{`"$TOOL" --version`, ""},
+	}
+	for _, tt := range tests {
+		got, err := Programs(tt.script)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.script, err)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: %q, asteptat %q", tt.script, got, tt.want)
+		}
+	}
+	if _, err := Programs(`if then`); err == nil {
+		t.Error("script neparsabil acceptat")
+	}
+}
@@ -0,0 +1,296 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Optiunile sed relevante pentru gasirea scripturilor
+var sedOptions = optionSpec{
+	values: setOf("-e", "-f", "-l", "--expression", "--file", "--line-length"),
+	reject: setOf("-f", "--file"), // script din fisier, nu poate fi verificat
+}
+
+// checkSedScripts verifica scripturile sed (argumentele -e sau primul operand):
+// comenzile w/W si e, precum si flag-urile w/e ale lui s, scriu fisiere sau
+// executa programe si sunt respinse
+func checkSedScripts(args []*syntax.Word, vars map[string][]string) *Violation {
+	explicit := false // scripturi date prin -e; operanzii sunt doar fisiere
+	var scripts []*syntax.Word
+	var operand *syntax.Word
+
+	endOfOptions := false
+	for i := 0; i < len(args); i++ {
+		values, ok := wordValues(args[i], vars)
+		if !ok || len(values) == 0 {
+			return newViolation(args[i], "argument dinamic pentru sed")
+		}
+		lit := values[0]
+		if endOfOptions || !strings.HasPrefix(lit, "-") || lit == "-" {
+			if operand == nil {
+				operand = args[i]
+			}
+			continue
+		}
+		if len(values) > 1 {
+			return newViolation(args[i], "optiune sed dinamica")
+		}
+		if lit == "--" {
+			endOfOptions = true
+			continue
+		}
+
+		takesValue, reason := sedOptions.option(lit)
+		if reason != "" {
+			return newViolation(args[i], "sed: "+reason)
+		}
+		if sedScriptOption(lit) {
+			explicit = true
+			if !takesValue {
+				if v := checkSedScript(args[i], sedAttachedScript(lit)); v != nil {
+					return v
+				}
+			} else if i+1 < len(args) {
+				scripts = append(scripts, args[i+1])
+			}
+		}
+		if takesValue {
+			i++
+		}
+	}
+
+	if !explicit && operand != nil {
+		scripts = append(scripts, operand)
+	}
+	for _, w := range scripts {
+		values, ok := wordValues(w, vars)
+		if !ok {
+			return newViolation(w, "script sed dinamic")
+		}
+		for _, script := range values {
+			if v := checkSedScript(w, script); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// sedScriptOption indica optiunile care introduc un script (-e, -ne, --expression)
+func sedScriptOption(lit string) bool {
+	if strings.HasPrefix(lit, "--") {
+		name, _, _ := strings.Cut(lit, "=")
+		return len(name) > 2 && strings.HasPrefix("--expression", name)
+	}
+	for j := 1; j < len(lit); j++ {
+		if lit[j] == 'e' {
+			return true
+		}
+		if sedOptions.values["-"+lit[j:j+1]] {
+			return false
+		}
+	}
+	return false
+}
+
+// sedAttachedScript intoarce scriptul lipit de optiune (-es/a/b/, --expression=s/a/b/)
+func sedAttachedScript(lit string) string {
+	if strings.HasPrefix(lit, "--") {
+		_, value, _ := strings.Cut(lit, "=")
+		return value
+	}
+	return lit[strings.IndexByte(lit, 'e')+1:]
+}
+
+func checkSedScript(w *syntax.Word, script string) *Violation {
+	if reason := sedUnsafeCommand(script); reason != "" {
+		return newViolation(w, "sed: "+reason)
+	}
+	return nil
+}
+
+// sedUnsafeCommand parcurge scriptul sed comanda cu comanda si intoarce
+// motivul respingerii pentru comenzile care scriu fisiere sau executa
+// programe. Un script neparsabil e respins, ca sa nu ascunda astfel de comenzi.
+func sedUnsafeCommand(script string) string {
+	s := &sedScanner{src: script}
+	for {
+		s.skip(" \t\n;")
+		if s.eof() {
+			return ""
+		}
+		if s.peek() == '#' {
+			s.skipUntil("\n")
+			continue
+		}
+		if !s.address() || s.eof() {
+			return "script neparsabil (adresa)"
+		}
+
+		cmd := s.next()
+		switch cmd {
+		case '{', '}', '=', 'd', 'D', 'g', 'G', 'h', 'H', 'n', 'N', 'p', 'P', 'x', 'z', 'F':
+		case 'l', 'q', 'Q', 'L':
+			s.skip(" \t")
+			s.skip("0123456789")
+		case ':', 'b', 't', 'T', 'v':
+			s.skipUntil(";\n")
+		case 'a', 'i', 'c':
+			s.text()
+		case 'r', 'R':
+			s.skipUntil("\n") // citire fisier
+		case 'w', 'W':
+			return fmt.Sprintf("comanda %c scrie in fisier", cmd)
+		case 'e':
+			return "comanda e executa programe"
+		case 's':
+			if !s.delimitedParts(2) {
+				return "script neparsabil (s)"
+			}
+			for !s.eof() {
+				c := s.peek()
+				if c == 'w' {
+					return "flag-ul w al comenzii s scrie in fisier"
+				}
+				if c == 'e' {
+					return "flag-ul e al comenzii s executa programe"
+				}
+				if !strings.ContainsRune("gpiImM0123456789", rune(c)) {
+					break
+				}
+				s.i++
+			}
+		case 'y':
+			if !s.delimitedParts(2) {
+				return "script neparsabil (y)"
+			}
+		default:
+			return fmt.Sprintf("comanda necunoscuta %q", cmd)
+		}
+	}
+}
+
+type sedScanner struct {
+	src string
+	i   int
+}
+
+func (s *sedScanner) eof() bool  { return s.i >= len(s.src) }
+func (s *sedScanner) peek() byte { return s.src[s.i] }
+
+func (s *sedScanner) next() byte {
+	c := s.src[s.i]
+	s.i++
+	return c
+}
+
+func (s *sedScanner) skip(chars string) {
+	for !s.eof() && strings.IndexByte(chars, s.peek()) >= 0 {
+		s.i++
+	}
+}
+
+func (s *sedScanner) skipUntil(chars string) {
+	for !s.eof() && strings.IndexByte(chars, s.peek()) < 0 {
+		s.i++
+	}
+}
+
+// address sare peste adresele unei comenzi (1, $, /re/, \%re%, 1~2, a,b, a,+N, !)
+func (s *sedScanner) address() bool {
+	if !s.addressPart() {
+		return false
+	}
+	s.skip(" \t")
+	if !s.eof() && s.peek() == ',' {
+		s.i++
+		s.skip(" \t")
+		if !s.eof() && (s.peek() == '+' || s.peek() == '~') {
+			s.i++
+			s.skip("0123456789")
+		} else if !s.addressPart() {
+			return false
+		}
+	}
+	s.skip(" \t!")
+	return true
+}
+
+func (s *sedScanner) addressPart() bool {
+	if s.eof() {
+		return true
+	}
+	switch c := s.peek(); {
+	case c >= '0' && c <= '9':
+		s.skip("0123456789")
+		if !s.eof() && s.peek() == '~' {
+			s.i++
+			s.skip("0123456789")
+		}
+	case c == '$':
+		s.i++
+	case c == '/' || c == '\\':
+		s.i++
+		delim := c
+		if c == '\\' {
+			if s.eof() {
+				return false
+			}
+			delim = s.next()
+		}
+		if !s.delimited(delim) {
+			return false
+		}
+		s.skip("IM")
+	}
+	return true
+}
+
+// delimitedParts citeste delimitatorul si n parti terminate de el (s/re/repl/)
+func (s *sedScanner) delimitedParts(n int) bool {
+	if s.eof() {
+		return false
+	}
+	delim := s.next()
+	if delim == '\\' || delim == '\n' {
+		return false
+	}
+	for ; n > 0; n-- {
+		if !s.delimited(delim) {
+			return false
+		}
+	}
+	return true
+}
+
+// delimited sare pana dupa delimitatorul neescapat
+func (s *sedScanner) delimited(delim byte) bool {
+	for !s.eof() {
+		c := s.next()
+		switch {
+		case c == '\\':
+			s.i++
+		case c == delim:
+			return true
+		case c == '\n':
+			return false
+		}
+	}
+	return false
+}
+
+// text sare peste textul comenzilor a/i/c, pana la un sfarsit de linie neescapat
+func (s *sedScanner) text() {
+	for !s.eof() {
+		c := s.next()
+		if c == '\\' {
+			s.i++
+			continue
+		}
+		if c == '\n' {
+			return
+		}
+	}
+}
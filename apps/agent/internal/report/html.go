@@ -0,0 +1,75 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Raport HTML autonom: CSS inline, fara resurse externe, de atasat la tichete
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html lang="ro">
+<head>
+<meta charset="utf-8">
+<title>{{.Template.Name}} - {{.Host}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2937; }
+h1 { margin-bottom: 0.2em; }
+.meta { color: #6b7280; margin-bottom: 1.5em; }
+.summary { display: flex; gap: 1em; flex-wrap: wrap; margin-bottom: 2em; }
+.card { border: 1px solid #e5e7eb; border-radius: 6px; padding: 0.8em 1.2em; min-width: 8em; }
+.card b { display: block; font-size: 1.6em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+th, td { border-bottom: 1px solid #e5e7eb; padding: 0.4em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f9fafb; }
+pre { margin: 0; white-space: pre-wrap; word-break: break-all; font-size: 0.85em; max-height: 12em; overflow: auto; }
+details { margin-bottom: 0.6em; }
+summary { cursor: pointer; }
+.status { font-weight: 600; padding: 0.1em 0.5em; border-radius: 4px; font-size: 0.85em; }
+.compliant, .pass { background: #dcfce7; color: #166534; }
+.partially_compliant, .warn { background: #fef9c3; color: #854d0e; }
+.non_compliant, .fail { background: #fee2e2; color: #991b1b; }
+.error, .blocked, .skipped { background: #ffedd5; color: #9a3412; }
+.not_applicable, .manual { background: #f3f4f6; color: #4b5563; }
+.waived { background: #e0e7ff; color: #3730a3; }
+</style>
+</head>
+<body>
+<h1>{{.Template.Name}} <small>v{{.Template.Version}}</small></h1>
+<div class="meta">Host: {{.Host}} &middot; {{.StartedAt.Format "2006-01-02 15:04:05 MST"}} &rarr; {{.FinishedAt.Format "15:04:05"}}</div>
+
+<div class="summary">
+<div class="card">Status<b><span class="status {{lower .Summary.Status}}">{{.Summary.Status}}</span></b></div>
+<div class="card">Conformitate<b>{{printf "%.2f" .Summary.CompliancePercent}}%</b></div>
+<div class="card">PASS<b>{{.Summary.Passed}}</b>{{if .Summary.Warned}}din care WARN: {{.Summary.Warned}}{{end}}</div>
+<div class="card">FAIL<b>{{.Summary.Failed}}</b>critice: {{.Summary.CriticalFails}}</div>
+<div class="card">Erori<b>{{.Summary.Errored}}</b></div>
+<div class="card">N/A<b>{{.Summary.NotApplicable}}</b></div>
+{{if .Summary.Waived}}<div class="card">Exceptii<b>{{.Summary.Waived}}</b>risc acceptat</div>
+{{end}}<div class="card">Manuale<b>{{.Summary.Manual}}</b>neevaluate offline</div>
+</div>
+
+<table>
+<tr><th>Control</th><th>Titlu</th><th>Categorie</th><th>Severitate</th><th>Status</th><th>Conformitate</th></tr>
+{{range .Controls}}<tr><td><a href="#{{.ControlID}}">{{.ControlID}}</a></td><td>{{.Title}}</td><td>{{.Category}}</td><td>{{.Severity}}</td><td><span class="status {{lower .Status}}">{{.Status}}</span></td><td>{{printf "%.2f" .CompliancePercent}}%</td></tr>
+{{end}}</table>
+
+{{range .Controls}}{{if .Checks}}<details id="{{.ControlID}}"{{if eq .Status "FAIL" "ERROR"}} open{{end}}>
+<summary><b>{{.ControlID}}</b> {{.Title}} <span class="status {{lower .Status}}">{{.Status}}</span></summary>
+{{if .Rationale}}<p>{{.Rationale}}</p>{{end}}
+<table>
+<tr><th>Verificare</th><th>Status</th><th>Asteptat</th><th>Iesire</th><th>Detalii</th></tr>
+{{range .Checks}}<tr><td><b>{{.CheckID}}</b><br>{{.Title}}</td><td><span class="status {{lower .Result.Status}}">{{.Result.Status}}</span></td><td><pre>{{.Expected}}</pre></td><td><pre>{{.Result.Output}}</pre></td><td>{{.Result.ReasonCode}}{{if .Result.ErrorMessage}}<pre>{{.Result.ErrorMessage}}</pre>{{end}}{{if .Result.Stderr}}<pre>{{.Result.Stderr}}</pre>{{end}}{{with .Result.Waiver}}<p>Exceptie {{.ID}}{{if .Expired}} <span class="status fail">EXPIRATA</span>{{else}} (evaluat {{.EvaluatedStatus}}){{end}}: {{.Justification}}<br>aprobat de {{.Approver}}, expira {{.ExpiresAt}}</p>{{end}}</td></tr>
+{{end}}</table>
+</details>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML scrie raportul ca pagina HTML autonoma
+func WriteHTML(w io.Writer, r *Report) error {
+	return htmlReport.Execute(w, r)
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/journal"
+	"bittrail-agent/internal/template"
+)
+
+// FromJournal construieste raportul unei rulari din intrarile jurnalului de
+// executii; sablonul leaga verificarile (checkId) de controale. auditRunID gol
+// inseamna ultima rulare din jurnal. Jurnalul nu pastreaza iesirea
+// verificarilor (doar outputHash), deci raportul nu o contine. Intoarce si
+// verificarile rularii care nu apar in sablon.
+func FromJournal(tpl *template.Template, entries []journal.Entry, auditRunID string) (*Report, []string, error) {
+	if auditRunID == "" {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Type == journal.TypeResult {
+				auditRunID = entries[i].AuditRunID
+				break
+			}
+		}
+		if auditRunID == "" {
+			return nil, nil, fmt.Errorf("jurnalul nu contine rezultate")
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, control := range tpl.Controls {
+		for _, check := range control.AutomatedChecks {
+			known[check.CheckID] = true
+		}
+	}
+
+	// O verificare executata de mai multe ori in aceeasi rulare (ad-hoc,
+	// reluare): ultima executie e cea raportata
+	results := make(map[string]api.CheckResult)
+	var unknown []string
+	var serverID, host string
+	var started, finished time.Time
+	for _, e := range entries {
+		if e.Type != journal.TypeResult || e.AuditRunID != auditRunID || e.Result == nil {
+			continue
+		}
+		checkID := e.Result.CheckID
+		if e.Check != nil && e.Check.CheckID != "" {
+			checkID = e.Check.CheckID
+		}
+		if !known[checkID] {
+			unknown = append(unknown, checkID)
+			continue
+		}
+		results[checkID] = *e.Result
+
+		serverID = e.ServerID
+		if host == "" {
+			host = e.Result.ExecHostname
+		}
+		if t, err := time.Parse(time.RFC3339Nano, e.Time); err == nil {
+			if started.IsZero() || t.Before(started) {
+				started = t
+			}
+			if t.After(finished) {
+				finished = t
+			}
+		}
+	}
+	if len(results) == 0 {
+		return nil, unknown, fmt.Errorf("rularea %s nu are rezultate pentru verificarile sablonului", auditRunID)
+	}
+
+	// Controalele fara niciun rezultat nu au facut parte din rulare (excluse
+	// in backend): raportul le omite, nu le marcheaza neexecutate
+	evaluated := *tpl
+	evaluated.Controls = nil
+	for _, control := range tpl.Controls {
+		for _, check := range control.AutomatedChecks {
+			if _, ok := results[check.CheckID]; ok {
+				evaluated.Controls = append(evaluated.Controls, control)
+				break
+			}
+		}
+	}
+
+	r := Build(&evaluated, results, host, started, finished)
+	r.ServerID, r.AuditRunID = serverID, auditRunID
+	return r, unknown, nil
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"path/filepath"
+	"testing"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/journal"
+)
+
+func TestFromJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	j, err := journal.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendResult := func(run, checkID, status string) {
+		t.Helper()
+		_, err := j.Append(journal.Entry{
+			Type:       journal.TypeResult,
+			ServerID:   "srv-1",
+			AuditRunID: run,
+			Check:      &journal.CheckRecord{CheckID: checkID},
+			Result:     &api.CheckResult{AutomatedCheckID: "db-" + checkID, CheckID: checkID, Status: status, ExecHostname: "host-1"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendResult("run-1", "C1.a", "FAIL")
+	appendResult("run-2", "C1.a", "FAIL")
+	appendResult("run-2", "C1.a", "PASS") // re-executata: ultima executie conteaza
+	appendResult("run-2", "X.1", "PASS")
+	appendResult("run-2", "C1.b", "PASS")
+
+	var entries []journal.Entry
+	if _, err := journal.Verify(path, func(e journal.Entry) error {
+		entries = append(entries, e)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, unknown, err := FromJournal(testTemplate("HIGH"), entries, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AuditRunID != "run-2" || r.ServerID != "srv-1" || r.Host != "host-1" {
+		t.Errorf("rulare: %s %s %s", r.AuditRunID, r.ServerID, r.Host)
+	}
+	if len(unknown) != 1 || unknown[0] != "X.1" {
+		t.Errorf("verificari in afara sablonului: %v", unknown)
+	}
+	if len(r.Controls) != 1 {
+		t.Errorf("controale raportate: %d, asteptat doar C1 (singurul din rulare)", len(r.Controls))
+	}
+	if r.Controls[0].Status != ControlPass || r.StartedAt.IsZero() || r.FinishedAt.Before(r.StartedAt) {
+		t.Errorf("C1: %s, %s - %s", r.Controls[0].Status, r.StartedAt, r.FinishedAt)
+	}
+
+	if _, _, err := FromJournal(testTemplate("HIGH"), entries, "run-3"); err == nil {
+		t.Error("rulare inexistenta acceptata")
+	}
+}
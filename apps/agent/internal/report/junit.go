@@ -0,0 +1,135 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"bittrail-agent/internal/api"
+)
+
+// Structura JUnit XML citita de sistemele CI (Jenkins, GitLab, GitHub Actions):
+// un testsuite per control, un testcase per verificare automata
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Hostname   string          `xml:"hostname,attr,omitempty"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit scrie raportul ca JUnit XML. FAIL devine failure, ERROR si
+// BLOCKED devin error, SKIPPED, NOT_APPLICABLE si WAIVED devin skipped; PASS si
+// WARN trec.
+func WriteJUnit(w io.Writer, r *Report) error {
+	suites := junitSuites{
+		Name: fmt.Sprintf("%s %s", r.Template.Name, r.Template.Version),
+		Time: fmt.Sprintf("%.3f", r.FinishedAt.Sub(r.StartedAt).Seconds()),
+	}
+	for _, control := range r.Controls {
+		suite := junitSuite{
+			Name:      fmt.Sprintf("%s %s", control.ControlID, control.Title),
+			Hostname:  r.Host,
+			Timestamp: r.StartedAt.UTC().Format("2006-01-02T15:04:05"),
+			Properties: []junitProperty{
+				{Name: "category", Value: control.Category},
+				{Name: "severity", Value: control.Severity},
+				{Name: "status", Value: control.Status},
+			},
+		}
+		for _, check := range control.Checks {
+			tc := junitCase{
+				Name:      fmt.Sprintf("%s %s", check.CheckID, check.Title),
+				ClassName: control.ControlID,
+				SystemOut: check.Result.Output,
+				SystemErr: check.Result.Stderr,
+			}
+			msg := &junitMessage{Message: check.Result.ErrorMessage, Type: check.Result.Status}
+			if check.Result.ReasonCode != "" {
+				msg.Type = check.Result.ReasonCode
+			}
+			switch check.Result.Status {
+			case api.StatusPass, api.StatusWarn:
+			case api.StatusFail:
+				if msg.Message == "" {
+					msg.Message = "rezultat diferit de cel asteptat"
+				}
+				msg.Text = fmt.Sprintf("asteptat: %s\nobtinut: %s", check.Expected, check.Result.Output)
+				tc.Failure = msg
+				suite.Failures++
+			case api.StatusSkipped, api.StatusNotApplicable:
+				tc.Skipped = msg
+				suite.Skipped++
+			case api.StatusWaived:
+				w := check.Result.Waiver
+				tc.Skipped = &junitMessage{Type: api.StatusWaived}
+				if w != nil {
+					tc.Skipped.Message = fmt.Sprintf("exceptie %s (%s, expira %s): %s", w.ID, w.Approver, w.ExpiresAt, w.Justification)
+				}
+				suite.Skipped++
+			default:
+				tc.Error = msg
+				suite.Errors++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+		if suite.Tests == 0 {
+			// Controale doar cu verificari manuale: nu apar ca teste goale
+			continue
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,323 @@
+package report
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Versiunea modelului OSCAL emis (assessment-results)
+const oscalVersion = "1.1.2"
+
+// oscalNS e spatiul de nume al proprietatilor specifice BitTrail
+const oscalNS = "urn:bittrail:oscal"
+
+// Documentul OSCAL assessment-results, restrans la campurile emise: un
+// rezultat per raport, o observatie per verificare automata, un finding per
+// control evaluat. Numele campurilor sunt cele din modelul JSON OSCAL.
+
+type oscalDocument struct {
+	AssessmentResults oscalAssessmentResults `json:"assessment-results"`
+}
+
+type oscalAssessmentResults struct {
+	UUID     string        `json:"uuid"`
+	Metadata oscalMetadata `json:"metadata"`
+	ImportAP oscalLink     `json:"import-ap"`
+	Results  []oscalResult `json:"results"`
+}
+
+type oscalMetadata struct {
+	Title        string      `json:"title"`
+	LastModified string      `json:"last-modified"`
+	Version      string      `json:"version"`
+	OSCALVersion string      `json:"oscal-version"`
+	Props        []oscalProp `json:"props,omitempty"`
+}
+
+type oscalLink struct {
+	Href string `json:"href"`
+}
+
+type oscalProp struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	NS    string `json:"ns,omitempty"`
+}
+
+type oscalResult struct {
+	UUID             string                `json:"uuid"`
+	Title            string                `json:"title"`
+	Description      string                `json:"description"`
+	Start            string                `json:"start"`
+	End              string                `json:"end"`
+	Props            []oscalProp           `json:"props,omitempty"`
+	LocalDefinitions oscalLocalDefinitions `json:"local-definitions"`
+	ReviewedControls oscalReviewedControls `json:"reviewed-controls"`
+	Observations     []oscalObservation    `json:"observations,omitempty"`
+	Findings         []oscalFinding        `json:"findings,omitempty"`
+}
+
+type oscalLocalDefinitions struct {
+	InventoryItems []oscalInventoryItem `json:"inventory-items"`
+}
+
+type oscalInventoryItem struct {
+	UUID        string      `json:"uuid"`
+	Description string      `json:"description"`
+	Props       []oscalProp `json:"props,omitempty"`
+}
+
+type oscalReviewedControls struct {
+	ControlSelections []oscalControlSelection `json:"control-selections"`
+}
+
+type oscalControlSelection struct {
+	IncludeControls []oscalSelectControl `json:"include-controls,omitempty"`
+}
+
+type oscalSelectControl struct {
+	ControlID string `json:"control-id"`
+}
+
+type oscalObservation struct {
+	UUID             string          `json:"uuid"`
+	Title            string          `json:"title"`
+	Description      string          `json:"description"`
+	Props            []oscalProp     `json:"props,omitempty"`
+	Methods          []string        `json:"methods"`
+	Subjects         []oscalSubject  `json:"subjects"`
+	RelevantEvidence []oscalEvidence `json:"relevant-evidence"`
+	Collected        string          `json:"collected"`
+}
+
+type oscalSubject struct {
+	SubjectUUID string `json:"subject-uuid"`
+	Type        string `json:"type"`
+}
+
+type oscalEvidence struct {
+	Description string      `json:"description"`
+	Props       []oscalProp `json:"props,omitempty"`
+}
+
+type oscalFinding struct {
+	UUID                string                    `json:"uuid"`
+	Title               string                    `json:"title"`
+	Description         string                    `json:"description"`
+	Props               []oscalProp               `json:"props,omitempty"`
+	Target              oscalTarget               `json:"target"`
+	RelatedObservations []oscalRelatedObservation `json:"related-observations,omitempty"`
+}
+
+type oscalTarget struct {
+	Type     string      `json:"type"`
+	TargetID string      `json:"target-id"`
+	Status   oscalStatus `json:"status"`
+}
+
+type oscalStatus struct {
+	State  string `json:"state"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type oscalRelatedObservation struct {
+	ObservationUUID string `json:"observation-uuid"`
+}
+
+// WriteOSCAL scrie raportul ca OSCAL assessment-results. UUID-urile sunt
+// derivate din server, rulare, moment si identificatori, deci acelasi
+// raport produce acelasi document.
+func WriteOSCAL(w io.Writer, r *Report) error {
+	ids := oscalIDs{seed: strings.Join([]string{r.ServerID, r.AuditRunID, r.Host, r.Template.Name, r.Template.Version,
+		r.StartedAt.UTC().Format(time.RFC3339Nano)}, "\n")}
+	hostUUID := ids.uuid("host")
+
+	result := oscalResult{
+		UUID:        ids.uuid("result"),
+		Title:       fmt.Sprintf("%s - %s", r.Template.Name, r.Host),
+		Description: fmt.Sprintf("Evaluare automata BitTrail: %s", r.Summary.Status),
+		Start:       oscalTime(r.StartedAt),
+		End:         oscalTime(r.FinishedAt),
+		Props: []oscalProp{
+			{Name: "overall-status", Value: r.Summary.Status, NS: oscalNS},
+			{Name: "compliance-percent", Value: strconv.FormatFloat(r.Summary.CompliancePercent, 'f', 2, 64), NS: oscalNS},
+		},
+		LocalDefinitions: oscalLocalDefinitions{InventoryItems: []oscalInventoryItem{{
+			UUID:        hostUUID,
+			Description: "Server evaluat de agentul BitTrail",
+			Props:       nonEmptyProps(oscalProp{Name: "hostname", Value: r.Host, NS: oscalNS}, oscalProp{Name: "asset-id", Value: r.ServerID}),
+		}}},
+	}
+
+	var reviewed []oscalSelectControl
+	for _, control := range r.Controls {
+		controlID := oscalControlID(control.ControlID)
+		var related []oscalRelatedObservation
+		for _, check := range control.Checks {
+			obs := oscalCheckObservation(ids, hostUUID, r, check)
+			result.Observations = append(result.Observations, obs)
+			related = append(related, oscalRelatedObservation{ObservationUUID: obs.UUID})
+		}
+
+		state, reason, ok := oscalControlState(control.Status)
+		if !ok {
+			// Neevaluat (doar manual sau NOT_APPLICABLE): fara finding
+			continue
+		}
+		reviewed = append(reviewed, oscalSelectControl{ControlID: controlID})
+		result.Findings = append(result.Findings, oscalFinding{
+			UUID:        ids.uuid("finding/" + control.ControlID),
+			Title:       fmt.Sprintf("%s %s", control.ControlID, control.Title),
+			Description: fmt.Sprintf("Control %s: %s (%.2f%% verificari conforme)", control.ControlID, control.Status, control.CompliancePercent),
+			Props: nonEmptyProps(
+				oscalProp{Name: "control-status", Value: control.Status, NS: oscalNS},
+				oscalProp{Name: "severity", Value: control.Severity, NS: oscalNS},
+				oscalProp{Name: "category", Value: control.Category, NS: oscalNS},
+			),
+			Target: oscalTarget{
+				Type:     "objective-id",
+				TargetID: controlID + "_obj",
+				Status:   oscalStatus{State: state, Reason: reason},
+			},
+			RelatedObservations: related,
+		})
+	}
+	result.ReviewedControls.ControlSelections = []oscalControlSelection{{IncludeControls: reviewed}}
+
+	doc := oscalDocument{AssessmentResults: oscalAssessmentResults{
+		UUID: ids.uuid("assessment-results"),
+		Metadata: oscalMetadata{
+			Title:        fmt.Sprintf("Rezultate evaluare: %s", r.Template.Name),
+			LastModified: oscalTime(r.FinishedAt),
+			Version:      r.Template.Version,
+			OSCALVersion: oscalVersion,
+			Props:        nonEmptyProps(oscalProp{Name: "template-type", Value: r.Template.Type, NS: oscalNS}),
+		},
+		ImportAP: oscalLink{Href: "urn:bittrail:template:" + url.PathEscape(r.Template.Name) + ":" + url.PathEscape(r.Template.Version)},
+		Results:  []oscalResult{result},
+	}}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// oscalCheckObservation descrie o verificare: amprenta iesirii, momentul si
+// gazda executiei, iar ca dovada semnatura agentului pe rezultat
+func oscalCheckObservation(ids oscalIDs, hostUUID string, r *Report, check CheckReport) oscalObservation {
+	res := check.Result
+	collected := res.ExecTimestamp
+	if collected == "" {
+		collected = oscalTime(r.FinishedAt)
+	}
+
+	evidence := oscalEvidence{
+		Description: "Rezultat nesemnat",
+		Props: nonEmptyProps(
+			oscalProp{Name: "output-hash", Value: res.OutputHash, NS: oscalNS},
+			oscalProp{Name: "exec-timestamp", Value: res.ExecTimestamp, NS: oscalNS},
+			oscalProp{Name: "exec-hostname", Value: res.ExecHostname, NS: oscalNS},
+			oscalProp{Name: "exec-user", Value: res.ExecUser, NS: oscalNS},
+		),
+	}
+	if res.Signature != "" {
+		// Semnatura acopera structura canonica (server, rulare, verificare, hash)
+		evidence.Description = "Rezultat semnat de agentul BitTrail"
+		evidence.Props = append(evidence.Props, nonEmptyProps(
+			oscalProp{Name: "signature", Value: res.Signature, NS: oscalNS},
+			oscalProp{Name: "signature-alg", Value: res.SignatureAlg, NS: oscalNS},
+			oscalProp{Name: "signature-version", Value: strconv.Itoa(res.SignatureVersion), NS: oscalNS},
+			oscalProp{Name: "server-id", Value: r.ServerID, NS: oscalNS},
+			oscalProp{Name: "audit-run-id", Value: r.AuditRunID, NS: oscalNS},
+		)...)
+	}
+
+	description := check.Title
+	if description == "" {
+		description = check.CheckID
+	}
+	props := nonEmptyProps(
+		oscalProp{Name: "check-status", Value: res.Status, NS: oscalNS},
+		oscalProp{Name: "reason-code", Value: res.ReasonCode, NS: oscalNS},
+		oscalProp{Name: "exit-code", Value: strconv.Itoa(res.ExitCode), NS: oscalNS},
+	)
+	if w := res.Waiver; w != nil {
+		// Exceptia aprobata (risc acceptat) si statusul evaluat al verificarii
+		props = append(props, nonEmptyProps(
+			oscalProp{Name: "waiver-id", Value: w.ID, NS: oscalNS},
+			oscalProp{Name: "waiver-approver", Value: w.Approver, NS: oscalNS},
+			oscalProp{Name: "waiver-expires", Value: w.ExpiresAt, NS: oscalNS},
+			oscalProp{Name: "waiver-expired", Value: strconv.FormatBool(w.Expired), NS: oscalNS},
+			oscalProp{Name: "evaluated-status", Value: w.EvaluatedStatus, NS: oscalNS},
+		)...)
+	}
+	return oscalObservation{
+		UUID:             ids.uuid("observation/" + check.CheckID),
+		Title:            check.CheckID,
+		Description:      description,
+		Props:            props,
+		Methods:          []string{"TEST"},
+		Subjects:         []oscalSubject{{SubjectUUID: hostUUID, Type: "inventory-item"}},
+		RelevantEvidence: []oscalEvidence{evidence},
+		Collected:        collected,
+	}
+}
+
+// oscalControlState mapeaza statusul controlului pe starea OSCAL; ok fals
+// pentru controalele neevaluate
+func oscalControlState(status string) (state, reason string, ok bool) {
+	switch status {
+	case ControlPass, ControlWarn:
+		return "satisfied", "pass", true
+	case ControlFail:
+		return "not-satisfied", "fail", true
+	case ControlError:
+		return "not-satisfied", "other", true
+	default:
+		return "", "", false
+	}
+}
+
+// oscalControlID aduce identificatorul la forma din cataloagele OSCAL (ac-2)
+func oscalControlID(controlID string) string {
+	return strings.ToLower(strings.ReplaceAll(controlID, " ", "-"))
+}
+
+func oscalTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// nonEmptyProps omite proprietatile fara valoare (OSCAL nu accepta valori goale)
+func nonEmptyProps(props ...oscalProp) []oscalProp {
+	var out []oscalProp
+	for _, p := range props {
+		if strings.TrimSpace(p.Value) != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// oscalIDs genereaza UUID-uri deterministe (versiunea 5, SHA-1) pe raport
+type oscalIDs struct {
+	seed string
+}
+
+// oscalUUIDNamespace e spatiul de nume UUID al documentelor BitTrail
+var oscalUUIDNamespace = [16]byte{0x6b, 0x1f, 0x3c, 0x52, 0x9d, 0x4e, 0x4a, 0x8b, 0xa1, 0x07, 0x2e, 0x5c, 0x90, 0x3d, 0x71, 0xb4}
+
+func (ids oscalIDs) uuid(name string) string {
+	h := sha1.New()
+	h.Write(oscalUUIDNamespace[:])
+	h.Write([]byte(ids.seed + "\n" + name))
+	sum := h.Sum(nil)
+	sum[6] = (sum[6] & 0x0f) | 0x50 // versiunea 5
+	sum[8] = (sum[8] & 0x3f) | 0x80 // varianta RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
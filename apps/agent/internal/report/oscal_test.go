@@ -0,0 +1,83 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var uuidV5 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestWriteOSCAL(t *testing.T) {
+	statuses := map[string]string{"C1.a": "PASS", "C1.b": "FAIL", "C2.a": "NOT_APPLICABLE", "C2.b": "NOT_APPLICABLE", "C2.c": "NOT_APPLICABLE"}
+	res := results(statuses)
+	signed := res["C1.a"]
+	signed.OutputHash, signed.Signature, signed.SignatureAlg, signed.SignatureVersion = "abc", "c2ln", "RSA-PSS-SHA256", 2
+	res["C1.a"] = signed
+
+	started := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	r := Build(testTemplate("HIGH"), res, "host-1", started, started.Add(time.Minute))
+	r.ServerID, r.AuditRunID = "srv-1", "LOCAL"
+
+	var first, second bytes.Buffer
+	if err := WriteOSCAL(&first, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteOSCAL(&second, r); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Error("documentul OSCAL nu e determinist")
+	}
+
+	var doc oscalDocument
+	if err := json.Unmarshal(first.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	ar := doc.AssessmentResults
+	if ar.Metadata.OSCALVersion != oscalVersion || len(ar.Results) != 1 || ar.ImportAP.Href == "" {
+		t.Fatalf("document: %+v", ar.Metadata)
+	}
+	result := ar.Results[0]
+
+	// C2 (doar NOT_APPLICABLE) si C3 (doar manual) nu au finding
+	if len(result.Observations) != 5 || len(result.Findings) != 1 {
+		t.Fatalf("%d observatii, %d findings", len(result.Observations), len(result.Findings))
+	}
+	finding := result.Findings[0]
+	if finding.Target.TargetID != "c1_obj" || finding.Target.Status.State != "not-satisfied" || len(finding.RelatedObservations) != 2 {
+		t.Errorf("finding: %+v", finding)
+	}
+	if ids := result.ReviewedControls.ControlSelections[0].IncludeControls; len(ids) != 1 || ids[0].ControlID != "c1" {
+		t.Errorf("controale evaluate: %+v", ids)
+	}
+
+	seen := map[string]bool{ar.UUID: true, result.UUID: true}
+	for _, obs := range result.Observations {
+		if !uuidV5.MatchString(obs.UUID) || seen[obs.UUID] {
+			t.Errorf("UUID invalid sau duplicat: %s", obs.UUID)
+		}
+		seen[obs.UUID] = true
+		if len(obs.Subjects) != 1 || obs.Subjects[0].SubjectUUID != result.LocalDefinitions.InventoryItems[0].UUID {
+			t.Errorf("%s: gazda lipsa din subiecti", obs.Title)
+		}
+	}
+
+	evidence := props(result.Observations[0].RelevantEvidence[0].Props)
+	if evidence["signature"] != "c2ln" || evidence["output-hash"] != "abc" || evidence["server-id"] != "srv-1" || evidence["audit-run-id"] != "LOCAL" {
+		t.Errorf("dovada semnata: %v", evidence)
+	}
+	if unsigned := props(result.Observations[1].RelevantEvidence[0].Props); unsigned["signature"] != "" {
+		t.Errorf("semnatura pe rezultat nesemnat: %v", unsigned)
+	}
+}
+
+func props(list []oscalProp) map[string]string {
+	out := make(map[string]string)
+	for _, p := range list {
+		out[p.Name] = p.Value
+	}
+	return out
+}
@@ -0,0 +1,230 @@
+// Package report construieste raportul unui audit local (comanda audit) din
+// sablon si rezultatele verificarilor, cu scorurile calculate ca in backend.
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/template"
+)
+
+// Statusuri generale (aceleasi valori ca scoring-ul din backend)
+const (
+	Compliant          = "COMPLIANT"
+	PartiallyCompliant = "PARTIALLY_COMPLIANT"
+	NonCompliant       = "NON_COMPLIANT"
+)
+
+// Statusuri control
+const (
+	ControlPass          = "PASS"
+	ControlWarn          = "WARN"
+	ControlFail          = "FAIL"
+	ControlError         = "ERROR"          // cel putin o verificare neevaluata (eroare, blocata, sarita)
+	ControlNotApplicable = "NOT_APPLICABLE" // toate verificarile automate NOT_APPLICABLE
+	ControlWaived        = "WAIVED"         // verificarile evaluate sunt toate acoperite de exceptii
+	ControlManual        = "MANUAL"         // doar verificari manuale, neevaluate offline
+)
+
+type Report struct {
+	Template   TemplateInfo    `json:"template"`
+	Host       string          `json:"host"`
+	ServerID   string          `json:"serverId,omitempty"` // semnatura rezultatelor e legata de server si rulare
+	AuditRunID string          `json:"auditRunId,omitempty"`
+	StartedAt  time.Time       `json:"startedAt"`
+	FinishedAt time.Time       `json:"finishedAt"`
+	Summary    Summary         `json:"summary"`
+	Controls   []ControlReport `json:"controls"`
+}
+
+type TemplateInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Type    string `json:"type,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+// Summary e scorul general. Verificarile manuale sunt doar numarate: offline
+// nu exista sarcini de auditor, deci nu blocheaza conformitatea.
+type Summary struct {
+	Status            string  `json:"status"`
+	CompliancePercent float64 `json:"compliancePercent"`
+	Total             int     `json:"total"`  // verificari automate evaluate (fara NOT_APPLICABLE si WAIVED)
+	Passed            int     `json:"passed"` // PASS si WARN, ca in backend
+	Warned            int     `json:"warned"`
+	Failed            int     `json:"failed"`
+	Errored           int     `json:"errored"`
+	NotApplicable     int     `json:"notApplicable"`
+	Waived            int     `json:"waived"`
+	CriticalFails     int     `json:"criticalFails"`
+	Manual            int     `json:"manual"`
+}
+
+type ControlReport struct {
+	ControlID         string        `json:"controlId"`
+	Title             string        `json:"title"`
+	Category          string        `json:"category"`
+	Severity          string        `json:"severity"`
+	Description       string        `json:"description,omitempty"`
+	Rationale         string        `json:"rationale,omitempty"`
+	Status            string        `json:"status"`
+	CompliancePercent float64       `json:"compliancePercent"`
+	Checks            []CheckReport `json:"checks"`
+	ManualChecks      int           `json:"manualChecks"`
+}
+
+type CheckReport struct {
+	CheckID     string          `json:"checkId"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	Expected    string          `json:"expected,omitempty"`
+	Result      api.CheckResult `json:"result"`
+}
+
+// Build calculeaza raportul; results e indexat pe checkId (collector.RunTemplate)
+func Build(tpl *template.Template, results map[string]api.CheckResult, host string, started, finished time.Time) *Report {
+	r := &Report{
+		Template: TemplateInfo{
+			Name:    tpl.Metadata.Name,
+			Version: tpl.Metadata.Version,
+			Type:    tpl.Metadata.Type,
+			Source:  tpl.Metadata.Source,
+		},
+		Host:       host,
+		StartedAt:  started,
+		FinishedAt: finished,
+		Controls:   make([]ControlReport, 0, len(tpl.Controls)),
+	}
+
+	s := &r.Summary
+	for _, control := range tpl.Controls {
+		cr := ControlReport{
+			ControlID:    control.ControlID,
+			Title:        control.Title,
+			Category:     control.Category,
+			Severity:     control.Severity,
+			Description:  control.Description,
+			Rationale:    control.Rationale,
+			ManualChecks: len(control.ManualChecks),
+			Checks:       make([]CheckReport, 0, len(control.AutomatedChecks)),
+		}
+		s.Manual += cr.ManualChecks
+
+		var c counts
+		for _, check := range control.AutomatedChecks {
+			result, ok := results[check.CheckID]
+			if !ok {
+				result = api.CheckResult{
+					AutomatedCheckID: check.CheckID,
+					CheckID:          check.CheckID,
+					Status:           api.StatusSkipped,
+					ErrorMessage:     "verificare neexecutata",
+				}
+			}
+			cr.Checks = append(cr.Checks, CheckReport{
+				CheckID:     check.CheckID,
+				Title:       check.Title,
+				Description: check.Description,
+				Expected:    check.ExpectedResult,
+				Result:      result,
+			})
+			c.add(result.Status)
+			if result.Status == api.StatusFail && control.Severity == "CRITICAL" {
+				s.CriticalFails++
+			}
+		}
+		cr.Status, cr.CompliancePercent = c.controlStatus(len(control.AutomatedChecks)), c.percent()
+
+		s.Total += c.total
+		s.Passed += c.passed
+		s.Warned += c.warned
+		s.Failed += c.failed
+		s.Errored += c.errored
+		s.NotApplicable += c.notApplicable
+		s.Waived += c.waived
+		r.Controls = append(r.Controls, cr)
+	}
+
+	all := counts{total: s.Total, passed: s.Passed, failed: s.Failed}
+	s.CompliancePercent = all.percent()
+
+	// Regulile din scoring.service.js (fara cele pentru sarcini manuale); pragul
+	// de 80% se compara pe procentul nerotunjit
+	switch {
+	case s.CriticalFails > 0:
+		s.Status = NonCompliant
+	case s.Failed > 0 && s.Passed*5 >= s.Total*4:
+		s.Status = PartiallyCompliant
+	case s.Failed > 0:
+		s.Status = NonCompliant
+	default:
+		s.Status = Compliant
+	}
+	return r
+}
+
+// counts numara rezultatele unui control; NOT_APPLICABLE si WAIVED (risc
+// acceptat) sunt excluse din total, WARN e conform (prag soft), iar restul
+// statusurilor nu sunt conforme
+type counts struct {
+	total, passed, warned, failed, errored, notApplicable, waived int
+}
+
+func (c *counts) add(status string) {
+	switch status {
+	case api.StatusNotApplicable:
+		c.notApplicable++
+		return
+	case api.StatusWaived:
+		c.waived++
+		return
+	case api.StatusPass:
+		c.passed++
+	case api.StatusWarn:
+		c.passed++
+		c.warned++
+	case api.StatusFail:
+		c.failed++
+	default:
+		c.errored++
+	}
+	c.total++
+}
+
+// percent intoarce procentul conform, rotunjit la doua zecimale (100 fara verificari)
+func (c counts) percent() float64 {
+	if c.total == 0 {
+		return 100
+	}
+	return math.Round(float64(c.passed)/float64(c.total)*10000) / 100
+}
+
+func (c counts) controlStatus(automated int) string {
+	switch {
+	case automated == 0:
+		return ControlManual
+	case c.total == 0 && c.waived > 0:
+		return ControlWaived
+	case c.total == 0:
+		return ControlNotApplicable
+	case c.failed > 0:
+		return ControlFail
+	case c.errored > 0:
+		return ControlError
+	case c.warned > 0:
+		return ControlWarn
+	default:
+		return ControlPass
+	}
+}
+
+// WriteJSON scrie raportul ca JSON indentat
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
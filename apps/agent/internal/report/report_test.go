@@ -0,0 +1,116 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/template"
+)
+
+func testTemplate(severity string) *template.Template {
+	return &template.Template{
+		Metadata: template.Metadata{Name: "Test", Version: "1.0"},
+		Controls: []template.Control{
+			{ControlID: "C1", Title: "Unu", Severity: severity, AutomatedChecks: []template.AutomatedCheck{
+				{CheckID: "C1.a"}, {CheckID: "C1.b"},
+			}},
+			{ControlID: "C2", Title: "Doi", Severity: "LOW", AutomatedChecks: []template.AutomatedCheck{
+				{CheckID: "C2.a"}, {CheckID: "C2.b"}, {CheckID: "C2.c"},
+			}},
+			{ControlID: "C3", Title: "Manual", Severity: "LOW", ManualChecks: []template.ManualCheck{{CheckID: "C3.m"}}},
+		},
+	}
+}
+
+func results(statuses map[string]string) map[string]api.CheckResult {
+	out := make(map[string]api.CheckResult)
+	for id, status := range statuses {
+		out[id] = api.CheckResult{AutomatedCheckID: id, CheckID: id, Status: status}
+	}
+	return out
+}
+
+func TestBuildScoring(t *testing.T) {
+	allPass := map[string]string{"C1.a": "PASS", "C1.b": "WARN", "C2.a": "PASS", "C2.b": "PASS", "C2.c": "NOT_APPLICABLE"}
+	tests := []struct {
+		name     string
+		severity string
+		statuses map[string]string
+		status   string
+		percent  float64
+	}{
+		{"conform", "HIGH", allPass, Compliant, 100},
+		{"partial", "HIGH", map[string]string{"C1.a": "PASS", "C1.b": "PASS", "C2.a": "PASS", "C2.b": "PASS", "C2.c": "FAIL"}, PartiallyCompliant, 80},
+		{"sub prag", "HIGH", map[string]string{"C1.a": "PASS", "C1.b": "ERROR", "C2.a": "PASS", "C2.b": "PASS", "C2.c": "FAIL"}, NonCompliant, 60},
+		{"esec critic", "CRITICAL", map[string]string{"C1.a": "FAIL", "C1.b": "PASS", "C2.a": "PASS", "C2.b": "PASS", "C2.c": "PASS"}, NonCompliant, 80},
+		{"erori fara esec", "HIGH", map[string]string{"C1.a": "BLOCKED", "C1.b": "PASS", "C2.a": "PASS", "C2.b": "PASS", "C2.c": "PASS"}, Compliant, 80},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Build(testTemplate(tt.severity), results(tt.statuses), "host", time.Now(), time.Now())
+			if r.Summary.Status != tt.status || r.Summary.CompliancePercent != tt.percent {
+				t.Errorf("%s %.2f, asteptat %s %.2f (%+v)", r.Summary.Status, r.Summary.CompliancePercent, tt.status, tt.percent, r.Summary)
+			}
+			if r.Summary.Manual != 1 || r.Controls[2].Status != ControlManual {
+				t.Errorf("verificari manuale: %+v", r.Controls[2])
+			}
+		})
+	}
+
+	r := Build(testTemplate("HIGH"), results(allPass), "host", time.Now(), time.Now())
+	if r.Controls[0].Status != ControlWarn || r.Controls[1].Status != ControlPass || r.Summary.NotApplicable != 1 {
+		t.Errorf("statusuri control: %s %s, N/A %d", r.Controls[0].Status, r.Controls[1].Status, r.Summary.NotApplicable)
+	}
+
+	// Exceptii: excluse din scor, controlul complet acoperit e WAIVED
+	r = Build(testTemplate("CRITICAL"), results(map[string]string{"C1.a": "WAIVED", "C1.b": "WAIVED", "C2.a": "PASS", "C2.b": "WAIVED", "C2.c": "PASS"}), "host", time.Now(), time.Now())
+	if r.Summary.Status != Compliant || r.Summary.Total != 2 || r.Summary.Waived != 3 || r.Controls[0].Status != ControlWaived || r.Controls[1].Status != ControlPass {
+		t.Errorf("exceptii: %+v, controale %s %s", r.Summary, r.Controls[0].Status, r.Controls[1].Status)
+	}
+
+	// Verificare fara rezultat: raportata, nu ignorata
+	r = Build(testTemplate("HIGH"), results(map[string]string{"C1.a": "PASS"}), "host", time.Now(), time.Now())
+	if r.Controls[1].Checks[0].Result.Status != api.StatusSkipped || r.Summary.Errored != 4 {
+		t.Errorf("verificari lipsa: %+v", r.Summary)
+	}
+}
+
+func TestWriters(t *testing.T) {
+	statuses := map[string]string{"C1.a": "PASS", "C1.b": "FAIL", "C2.a": "ERROR", "C2.b": "NOT_APPLICABLE", "C2.c": "WARN"}
+	r := Build(testTemplate("HIGH"), results(statuses), "host-1", time.Now(), time.Now())
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var suites junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("JUnit invalid: %v\n%s", err, buf.String())
+	}
+	if suites.Tests != 5 || suites.Failures != 1 || suites.Errors != 1 || suites.Skipped != 1 || len(suites.Suites) != 2 {
+		t.Errorf("JUnit: %d teste, %d esecuri, %d erori, %d sarite, %d suite",
+			suites.Tests, suites.Failures, suites.Errors, suites.Skipped, len(suites.Suites))
+	}
+
+	buf.Reset()
+	r.Controls[0].Checks[0].Result.Output = "<script>alert(1)</script>"
+	if err := WriteHTML(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "<script>") || !strings.Contains(buf.String(), "host-1") {
+		t.Error("HTML: iesirea verificarilor nu e escapata")
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"status": "NON_COMPLIANT"`) {
+		t.Errorf("JSON: %s", buf.String())
+	}
+}
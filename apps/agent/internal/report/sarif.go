@@ -0,0 +1,265 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"bittrail-agent/internal/api"
+)
+
+// Documentul SARIF 2.1.0, restrans la campurile emise: o regula per control
+// cu verificari automate, un rezultat per verificare FAIL sau neevaluata
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool              `json:"tool"`
+	Results    []sarifResult          `json:"results"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	FullDescription      *sarifMessage          `json:"fullDescription,omitempty"`
+	Help                 *sarifMessage          `json:"help,omitempty"`
+	DefaultConfiguration sarifConfiguration     `json:"defaultConfiguration"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Kind                string                 `json:"kind"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints"`
+	Suppressions        []sarifSuppression     `json:"suppressions,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+// sarifSuppression e exceptia aprobata (waiver) a unui rezultat: alerta ramane
+// in document, dar tool-urile de code scanning o trateaza ca acceptata
+type sarifSuppression struct {
+	Kind          string                 `json:"kind"`
+	Status        string                 `json:"status"`
+	Justification string                 `json:"justification,omitempty"`
+	Properties    map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// Scorurile security-severity (0-10) folosite de tool-urile de code scanning
+// pentru a ordona alertele
+var sarifSecuritySeverity = map[string]string{
+	"CRITICAL": "9.5",
+	"HIGH":     "8.0",
+	"MEDIUM":   "5.5",
+	"LOW":      "3.0",
+	"INFO":     "0.0",
+}
+
+// WriteSARIF scrie verificarile FAIL si neevaluate ca SARIF 2.1.0. Documentul
+// nu contine momente de timp sau iesirea comenzilor (volatila): aceleasi
+// statusuri produc acelasi document, deci diff-urile intre rulari arata doar
+// schimbarile de conformitate. Verificarile acoperite de o exceptie activa
+// apar cu statusul evaluat si suppressions (risc acceptat).
+func WriteSARIF(w io.Writer, r *Report) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{Name: "bittrail-agent", Rules: []sarifRule{}}},
+		// results gol (nu null) cand totul e conform: rulare fara alerte
+		Results: []sarifResult{},
+		Properties: map[string]interface{}{
+			"template":        r.Template.Name,
+			"templateVersion": r.Template.Version,
+			"host":            r.Host,
+		},
+	}
+
+	for _, control := range r.Controls {
+		if len(control.Checks) == 0 {
+			continue
+		}
+		ruleIndex := len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifControlRule(control))
+
+		for _, check := range control.Checks {
+			res := check.Result
+			status := res.Status
+			if status == api.StatusWaived && res.Waiver != nil {
+				// Exceptie activa: alerta pentru statusul evaluat, suprimata
+				status = res.Waiver.EvaluatedStatus
+			}
+			var kind, level, text string
+			switch status {
+			case api.StatusPass, api.StatusWarn, api.StatusNotApplicable, api.StatusWaived:
+				continue
+			case api.StatusFail:
+				kind, level = "fail", sarifLevel(control.Severity)
+				text = fmt.Sprintf("%s %s: FAIL (asteptat: %s)", check.CheckID, check.Title, check.Expected)
+				if res.ErrorMessage != "" {
+					text += ". " + res.ErrorMessage
+				}
+			default:
+				// Neevaluata: nu e un esec, dar controlul ramane neverificat
+				kind, level = "review", "warning"
+				text = fmt.Sprintf("%s %s: %s", check.CheckID, check.Title, status)
+				if res.ReasonCode != "" {
+					text += " (" + res.ReasonCode + ")"
+				}
+				if res.ErrorMessage != "" {
+					text += ": " + res.ErrorMessage
+				}
+			}
+
+			fingerprint := sha256.Sum256([]byte(r.Host + "\n" + control.ControlID + "\n" + check.CheckID))
+			result := sarifResult{
+				RuleID:    control.ControlID,
+				RuleIndex: ruleIndex,
+				Kind:      kind,
+				Level:     level,
+				Message:   sarifMessage{Text: text},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: r.Host}},
+					LogicalLocations: []sarifLogicalLocation{{
+						Name:               check.CheckID,
+						FullyQualifiedName: r.Host + "/" + control.ControlID + "/" + check.CheckID,
+						Kind:               "object",
+					}},
+				}},
+				PartialFingerprints: map[string]string{"bittrailCheck/v1": hex.EncodeToString(fingerprint[:])},
+				Properties: sarifProperties(map[string]interface{}{
+					"checkId":    check.CheckID,
+					"status":     res.Status,
+					"reasonCode": res.ReasonCode,
+					"exitCode":   res.ExitCode,
+				}),
+			}
+			if w := res.Waiver; w != nil {
+				result.Properties["waiverId"] = w.ID
+				if w.Expired {
+					result.Properties["waiverExpired"] = true
+				} else {
+					result.Suppressions = []sarifSuppression{{
+						Kind:          "external",
+						Status:        "accepted",
+						Justification: w.Justification,
+						Properties: map[string]interface{}{
+							"waiverId":  w.ID,
+							"approver":  w.Approver,
+							"expiresAt": w.ExpiresAt,
+						},
+					}}
+				}
+			}
+			run.Results = append(run.Results, result)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+// sarifControlRule descrie controlul ca regula: titlul, motivatia si severitatea
+func sarifControlRule(control ControlReport) sarifRule {
+	rule := sarifRule{
+		ID:                   control.ControlID,
+		ShortDescription:     sarifMessage{Text: control.Title},
+		DefaultConfiguration: sarifConfiguration{Level: sarifLevel(control.Severity)},
+		Properties: sarifProperties(map[string]interface{}{
+			"severity":          control.Severity,
+			"security-severity": sarifSecuritySeverity[control.Severity],
+			"tags":              sarifTags(control.Category),
+		}),
+	}
+	if control.Description != "" {
+		rule.FullDescription = &sarifMessage{Text: control.Description}
+	}
+	if control.Rationale != "" {
+		rule.Help = &sarifMessage{Text: control.Rationale}
+		if rule.FullDescription == nil {
+			rule.FullDescription = &sarifMessage{Text: control.Rationale}
+		}
+	}
+	return rule
+}
+
+// sarifLevel mapeaza severitatea controlului pe nivelul SARIF
+func sarifLevel(severity string) string {
+	switch severity {
+	case "CRITICAL", "HIGH":
+		return "error"
+	case "MEDIUM":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func sarifTags(category string) []string {
+	tags := []string{"compliance"}
+	if category != "" {
+		tags = append(tags, category)
+	}
+	return tags
+}
+
+// sarifProperties omite valorile goale, ca documentul sa ramana compact
+func sarifProperties(props map[string]interface{}) map[string]interface{} {
+	for key, value := range props {
+		if s, ok := value.(string); ok && strings.TrimSpace(s) == "" {
+			delete(props, key)
+		}
+	}
+	return props
+}
@@ -0,0 +1,99 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"bittrail-agent/internal/api"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	statuses := map[string]string{"C1.a": "PASS", "C1.b": "FAIL", "C2.a": "BLOCKED", "C2.b": "NOT_APPLICABLE", "C2.c": "WARN"}
+	tpl := testTemplate("CRITICAL")
+	tpl.Controls[0].Rationale = "motivatie"
+
+	// Momente diferite, aceleasi rezultate: acelasi document
+	var outputs []string
+	for _, started := range []time.Time{time.Now(), time.Now().Add(time.Hour)} {
+		var buf bytes.Buffer
+		r := Build(tpl, results(statuses), "host-1", started, started.Add(time.Minute))
+		if err := WriteSARIF(&buf, r); err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, buf.String())
+	}
+	if outputs[0] != outputs[1] {
+		t.Error("iesirea SARIF depinde de momentul rularii")
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(outputs[0]), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("document: %+v", log)
+	}
+	run := log.Runs[0]
+
+	// C3 are doar verificari manuale: fara regula
+	rules := run.Tool.Driver.Rules
+	if len(rules) != 2 || rules[0].ID != "C1" || rules[0].DefaultConfiguration.Level != "error" || rules[0].Help == nil {
+		t.Fatalf("reguli: %+v", rules)
+	}
+	if rules[1].DefaultConfiguration.Level != "note" {
+		t.Errorf("nivel LOW: %s", rules[1].DefaultConfiguration.Level)
+	}
+
+	if len(run.Results) != 2 {
+		t.Fatalf("rezultate: %+v (doar FAIL si neevaluate)", run.Results)
+	}
+	failed, blocked := run.Results[0], run.Results[1]
+	if failed.RuleID != "C1" || failed.RuleIndex != 0 || failed.Kind != "fail" || failed.Level != "error" {
+		t.Errorf("FAIL: %+v", failed)
+	}
+	if blocked.RuleID != "C2" || blocked.RuleIndex != 1 || blocked.Kind != "review" {
+		t.Errorf("BLOCKED: %+v", blocked)
+	}
+	if loc := failed.Locations[0]; loc.PhysicalLocation.ArtifactLocation.URI != "host-1" || loc.LogicalLocations[0].FullyQualifiedName != "host-1/C1/C1.b" {
+		t.Errorf("locatie: %+v", loc)
+	}
+	if failed.PartialFingerprints["bittrailCheck/v1"] == blocked.PartialFingerprints["bittrailCheck/v1"] {
+		t.Error("amprente identice pentru verificari diferite")
+	}
+}
+
+func TestWriteSARIFWaived(t *testing.T) {
+	res := results(map[string]string{"C1.a": "WAIVED", "C1.b": "WAIVED", "C2.a": "FAIL"})
+	waived := res["C1.a"]
+	waived.Waiver = &api.WaiverInfo{ID: "W-1", Justification: "legacy", Approver: "secops", ExpiresAt: "2026-12-31", EvaluatedStatus: "FAIL"}
+	res["C1.a"] = waived
+	passing := res["C1.b"]
+	passing.Waiver = &api.WaiverInfo{ID: "W-1", EvaluatedStatus: "PASS"}
+	res["C1.b"] = passing
+	expired := res["C2.a"]
+	expired.Waiver = &api.WaiverInfo{ID: "W-2", EvaluatedStatus: "FAIL", Expired: true}
+	res["C2.a"] = expired
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, Build(testTemplate("HIGH"), res, "host-1", time.Now(), time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	// C2.b si C2.c fara rezultat (SKIPPED) raman neevaluate
+	results := log.Runs[0].Results
+	if len(results) != 4 {
+		t.Fatalf("rezultate: %+v", results)
+	}
+	suppressed, failed := results[0], results[1]
+	if suppressed.Kind != "fail" || len(suppressed.Suppressions) != 1 || suppressed.Suppressions[0].Justification != "legacy" {
+		t.Errorf("exceptie activa: %+v", suppressed)
+	}
+	if len(failed.Suppressions) != 0 || failed.Properties["waiverExpired"] != true {
+		t.Errorf("exceptie expirata: %+v", failed)
+	}
+}
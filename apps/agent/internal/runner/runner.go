@@ -42,7 +42,7 @@ func Run(configPath string) error {
 	// Initializare colectori
 	metricsCollector := collector.NewMetricsCollector()
 	inventoryCollector := collector.NewInventoryCollector()
-	auditRunner := collector.NewAuditRunner(client, cfg.KeyFile, cfg.BackendKeyFile)
+	auditRunner := collector.NewAuditRunner(client, cfg)
 
 	// Canal oprire (graceful shutdown)
 	stopChan := make(chan os.Signal, 1)
@@ -52,10 +52,14 @@ func Run(configPath string) error {
 	metricsTicker := time.NewTicker(time.Duration(cfg.MetricsInterval) * time.Second)
 	inventoryTicker := time.NewTicker(time.Duration(cfg.InventoryInterval) * time.Second)
 	auditTicker := time.NewTicker(time.Duration(cfg.AuditCheckInterval) * time.Second)
+	anchorTicker := time.NewTicker(time.Duration(cfg.JournalAnchorInterval) * time.Second)
+	continuousTicker := time.NewTicker(time.Duration(cfg.ContinuousCheckInterval) * time.Second)
 
 	defer metricsTicker.Stop()
 	defer inventoryTicker.Stop()
 	defer auditTicker.Stop()
+	defer anchorTicker.Stop()
+	defer continuousTicker.Stop()
 
 	// Colectare initiala
 	go func() {
@@ -92,10 +96,27 @@ func Run(configPath string) error {
 			}
 
 		case <-auditTicker.C:
-			// Verificare audituri in asteptare
-			if err := auditRunner.CheckAndRun(); err != nil {
-				log.Printf("Error running audit checks: %v", err)
-			}
+			// Verificare audituri in asteptare, in afara buclei principale
+			// pentru ca metricile si inventarul sa continue
+			go func() {
+				if err := auditRunner.CheckAndRun(); err != nil {
+					log.Printf("Error running audit checks: %v", err)
+				}
+			}()
+
+		case <-anchorTicker.C:
+			go func() {
+				if err := auditRunner.AnchorJournal(); err != nil {
+					log.Printf("Error anchoring execution journal: %v", err)
+				}
+			}()
+
+		case <-continuousTicker.C:
+			go func() {
+				if err := auditRunner.RunContinuous(); err != nil {
+					log.Printf("Error running continuous evaluation: %v", err)
+				}
+			}()
 
 		case <-stopChan:
 			log.Println("Shutting down agent...")
@@ -0,0 +1,8 @@
+//go:build unix
+
+package sandbox
+
+import "syscall"
+
+// Credential e utilizatorul (uid, gid, grupuri) sub care ruleaza o comanda
+type Credential = syscall.Credential
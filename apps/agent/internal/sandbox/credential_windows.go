@@ -0,0 +1,9 @@
+package sandbox
+
+// Credential are campurile syscall.Credential, care nu exista pe Windows;
+// agentul nu schimba utilizatorul verificarilor pe Windows
+type Credential struct {
+	Uid    uint32
+	Gid    uint32
+	Groups []uint32
+}
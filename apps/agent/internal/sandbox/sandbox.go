@@ -0,0 +1,105 @@
+package sandbox
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// HelperCommand e argumentul cu care agentul se re-executa in sandbox.
+// Procesul ajutator configureaza izolarea si apoi face exec comenzii verificarii.
+const HelperCommand = "__sandbox-exec"
+
+// Moduri sandbox
+const (
+	ModeOff        = "off"
+	ModeBestEffort = "best-effort" // sandbox daca e disponibil, altfel executie directa (rezultate UNSANDBOXED)
+	ModeStrict     = "strict"      // verificarile nu ruleaza fara sandbox (implicit)
+)
+
+// Cod iesire al procesului ajutator cand izolarea esueaza
+const helperFailureExit = 125
+
+// Config descrie izolarea si limitele de resurse pentru verificari
+type Config struct {
+	Mode       string
+	CgroupRoot string // gol = checks/ sub cgroup-ul delegat al agentului (Delegate=yes)
+	CPUPercent int    // procent dintr-un CPU
+	MemoryMB   int
+	PidsMax    int
+
+	// Verificarile sunt observatii read-only ale gazdei (ss -lntup, pgrep,
+	// systemctl is-active), deci pastreaza implicit reteaua, procesele si
+	// /run ale gazdei; operatorul le poate izola daca nu are astfel de verificari
+	IsolateNetwork bool // namespace de retea propriu
+	IsolatePID     bool // namespace PID propriu, cu /proc propriu
+	IsolateRun     bool // /run si /var/run goale
+}
+
+// Options sunt optiunile per verificare
+type Options struct {
+	Credential *Credential // utilizatorul comenzii; nil = root
+}
+
+// Sandbox izoleaza verificarile: mount namespace cu sistem de fisiere
+// read-only, /tmp privat si /dev minimal, capabilitati reduse la
+// CAP_DAC_READ_SEARCH si limite cgroup v2 pentru CPU/memorie/procese.
+// Namespace-urile de retea si PID si /run gol sunt optionale (Config).
+type Sandbox struct {
+	cfg  Config
+	self string // binarul agentului, re-executat ca proces ajutator
+}
+
+// New pregateste sandbox-ul si verifica disponibilitatea lui.
+// Intoarce nil, nil daca sandbox-ul e dezactivat sau indisponibil in modul
+// best-effort; in modul strict indisponibilitatea e o eroare.
+func New(cfg Config) (*Sandbox, error) {
+	mode := strings.ToLower(cfg.Mode)
+	if mode == "" {
+		mode = ModeStrict
+	}
+	if mode == ModeOff {
+		return nil, nil
+	}
+	if mode != ModeBestEffort && mode != ModeStrict {
+		return nil, fmt.Errorf("sandbox_mode invalid: %s", cfg.Mode)
+	}
+	cfg.Mode = mode
+
+	sb, err := newPlatformSandbox(cfg)
+	if err == nil {
+		err = sb.probe()
+	}
+	if err != nil {
+		if mode == ModeStrict {
+			return nil, fmt.Errorf("sandbox indisponibil: %w", err)
+		}
+		log.Printf("SECURITY ALERT: Check sandbox unavailable: %v. Running checks without isolation (results marked UNSANDBOXED).", err)
+		return nil, nil
+	}
+
+	log.Printf("Check sandbox enabled (mode %s)", mode)
+	return sb, nil
+}
+
+// probe ruleaza /bin/true prin procesul ajutator
+func (s *Sandbox) probe() error {
+	cmd := exec.Command("/bin/true")
+	cleanup, err := s.Wrap(cmd, Options{})
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// IsHelperFailure indica faptul ca procesul ajutator n-a putut configura izolarea
+func IsHelperFailure(exitCode int) bool {
+	return exitCode == helperFailureExit
+}
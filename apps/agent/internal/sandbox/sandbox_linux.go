@@ -0,0 +1,564 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Capabilitati pastrate in sandbox (doar citire)
+var keptCaps = map[int]bool{
+	unix.CAP_DAC_READ_SEARCH: true,
+}
+
+// Directoare montate ca tmpfs privat in sandbox
+var privateTmpDirs = []string{"/tmp", "/var/tmp", "/dev/shm"}
+
+// Directoare cu socket-uri ale gazdei (systemd, D-Bus, docker), ascunse sub
+// un tmpfs gol cu IsolateRun
+var hiddenRunDirs = []string{"/run", "/var/run"}
+
+// Dispozitivele din /dev-ul minimal al sandbox-ului
+var sandboxDevices = []struct {
+	name         string
+	major, minor uint32
+}{
+	{"null", 1, 3},
+	{"zero", 1, 5},
+	{"full", 1, 7},
+	{"random", 1, 8},
+	{"urandom", 1, 9},
+}
+
+var cgroupSeq atomic.Uint64
+
+func newPlatformSandbox(cfg Config) (*Sandbox, error) {
+	if os.Geteuid() != 0 {
+		return nil, fmt.Errorf("necesita root")
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("binar agent: %w", err)
+	}
+
+	root, err := setupCgroupRoot(cfg.CgroupRoot)
+	if err != nil {
+		if cfg.Mode == ModeStrict {
+			return nil, fmt.Errorf("cgroup v2: %w", err)
+		}
+		log.Printf("WARNING: Check resource limits disabled (cgroup v2: %v)", err)
+	}
+	cfg.CgroupRoot = root
+
+	return &Sandbox{cfg: cfg, self: self}, nil
+}
+
+// Controllerele delegate cgroup-urilor verificarilor
+const cgroupControllers = "+cpu +memory +pids"
+
+// setupCgroupRoot pregateste cgroup-ul parinte al verificarilor. Fara o cale
+// configurata, foloseste cgroup-ul agentului delegat de systemd (Delegate=yes):
+// agentul se muta in frunza agent/, iar verificarile primesc cgroup-uri sub
+// checks/, fara a atinge arborele gestionat de systemd. O cale explicita
+// trebuie sa aiba deja controllerele activate de parinte.
+func setupCgroupRoot(root string) (string, error) {
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err != nil {
+		return "", fmt.Errorf("cgroup v2 nemontat")
+	}
+	if root != "" {
+		if err := os.MkdirAll(root, 0755); err != nil {
+			return "", err
+		}
+		if err := enableControllers(root); err != nil {
+			return "", err
+		}
+		return root, nil
+	}
+
+	own, err := ownCgroup()
+	if err != nil {
+		return "", err
+	}
+	// Dupa o reinitializare agentul e deja in frunza agent/
+	if filepath.Base(own) == "agent" {
+		own = filepath.Dir(own)
+	}
+	if own == "/sys/fs/cgroup" {
+		return "", fmt.Errorf("agentul ruleaza in cgroup-ul radacina (lipseste Delegate=yes in unitatea systemd?)")
+	}
+
+	// Regula "no internal processes": procesele din cgroup-ul serviciului se
+	// muta intr-o frunza inainte de activarea controllerelor pentru copii
+	leaf := filepath.Join(own, "agent")
+	if err := os.MkdirAll(leaf, 0755); err != nil {
+		return "", fmt.Errorf("cgroup agent (lipseste Delegate=yes?): %w", err)
+	}
+	procs, err := os.ReadFile(filepath.Join(own, "cgroup.procs"))
+	if err != nil {
+		return "", err
+	}
+	for _, pid := range strings.Fields(string(procs)) {
+		err := os.WriteFile(filepath.Join(leaf, "cgroup.procs"), []byte(pid), 0644)
+		if err != nil && !errors.Is(err, unix.ESRCH) {
+			return "", fmt.Errorf("mutare proces %s in %s: %w", pid, leaf, err)
+		}
+	}
+	if err := enableControllers(own); err != nil {
+		return "", err
+	}
+
+	checks := filepath.Join(own, "checks")
+	if err := os.MkdirAll(checks, 0755); err != nil {
+		return "", err
+	}
+	if err := enableControllers(checks); err != nil {
+		return "", err
+	}
+	return checks, nil
+}
+
+func enableControllers(dir string) error {
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte(cgroupControllers), 0644); err != nil {
+		return fmt.Errorf("activare controllere in %s: %w", dir, err)
+	}
+	return nil
+}
+
+// ownCgroup intoarce directorul cgroup v2 al procesului agent
+func ownCgroup() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rel, ok := strings.CutPrefix(line, "0::"); ok {
+			return filepath.Join("/sys/fs/cgroup", rel), nil
+		}
+	}
+	return "", fmt.Errorf("cgroup v2 al agentului negasit in /proc/self/cgroup")
+}
+
+// newCheckCgroup creeaza un cgroup cu limite pentru o singura verificare
+func (s *Sandbox) newCheckCgroup() (string, error) {
+	if s.cfg.CgroupRoot == "" {
+		return "", nil
+	}
+	dir := filepath.Join(s.cfg.CgroupRoot, fmt.Sprintf("check-%d-%d", os.Getpid(), cgroupSeq.Add(1)))
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return "", err
+	}
+
+	limits := map[string]string{}
+	if s.cfg.CPUPercent > 0 {
+		limits["cpu.max"] = fmt.Sprintf("%d 100000", s.cfg.CPUPercent*1000)
+	}
+	if s.cfg.MemoryMB > 0 {
+		limits["memory.max"] = strconv.Itoa(s.cfg.MemoryMB * 1024 * 1024)
+		limits["memory.swap.max"] = "0"
+	}
+	if s.cfg.PidsMax > 0 {
+		limits["pids.max"] = strconv.Itoa(s.cfg.PidsMax)
+	}
+	for file, value := range limits {
+		err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644)
+		if err != nil && !(file == "memory.swap.max" && os.IsNotExist(err)) {
+			os.Remove(dir)
+			return "", fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return dir, nil
+}
+
+// removeCgroup opreste procesele ramase si sterge cgroup-ul verificarii
+func removeCgroup(dir string) {
+	if dir == "" {
+		return
+	}
+	os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0644)
+	for i := 0; i < 50; i++ {
+		if err := os.Remove(dir); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Wrap rescrie cmd astfel incat sa ruleze prin procesul ajutator in
+// namespace-uri noi. Functia intoarsa elibereaza cgroup-ul verificarii.
+func (s *Sandbox) Wrap(cmd *exec.Cmd, opts Options) (func(), error) {
+	cgroup, err := s.newCheckCgroup()
+	if err != nil {
+		return func() {}, fmt.Errorf("cgroup verificare: %w", err)
+	}
+
+	path := cmd.Path
+	if cmd.Err != nil {
+		removeCgroup(cgroup)
+		return func() {}, cmd.Err
+	}
+
+	args := []string{s.self, HelperCommand}
+	if cgroup != "" {
+		args = append(args, "--cgroup", cgroup)
+	}
+	if opts.Credential != nil {
+		args = append(args,
+			"--uid", strconv.FormatUint(uint64(opts.Credential.Uid), 10),
+			"--gid", strconv.FormatUint(uint64(opts.Credential.Gid), 10))
+	}
+	if s.cfg.IsolateRun {
+		args = append(args, "--private-run")
+	}
+	args = append(args, "--")
+	args = append(args, path)
+	args = append(args, cmd.Args[1:]...)
+	cmd.Path = s.self
+	cmd.Args = args
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNS | syscall.CLONE_NEWIPC
+	if s.cfg.IsolateNetwork {
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+	if s.cfg.IsolatePID {
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWPID
+	}
+
+	return func() { removeCgroup(cgroup) }, nil
+}
+
+// RunHelper ruleaza in procesul ajutator (deja in namespace-urile noi):
+// intra in cgroup, remonteaza read-only, monteaza /dev si /tmp private (si
+// /proc, /run cand sunt izolate), reduce capabilitatile, schimba utilizatorul
+// si porneste comanda.
+// Intr-un namespace PID nou ramane procesul init al comenzii. Nu revine.
+func RunHelper(args []string) {
+	if err := runHelper(args); err != nil {
+		fmt.Fprintf(os.Stderr, "bittrail-sandbox: %v\n", err)
+		os.Exit(helperFailureExit)
+	}
+}
+
+func runHelper(args []string) error {
+	// Bounding set si no_new_privs sunt per thread; exec se face de pe acelasi thread
+	runtime.LockOSThread()
+
+	var cgroup string
+	uid, gid := -1, -1
+	privateRun := false
+	for len(args) > 0 && args[0] != "--" {
+		if args[0] == "--private-run" {
+			privateRun = true
+			args = args[1:]
+			continue
+		}
+		if len(args) < 2 {
+			return fmt.Errorf("%s fara valoare", args[0])
+		}
+		switch args[0] {
+		case "--cgroup":
+			cgroup = args[1]
+		case "--uid", "--gid":
+			id, err := strconv.Atoi(args[1])
+			if err != nil || id < 0 {
+				return fmt.Errorf("%s invalid: %s", args[0], args[1])
+			}
+			if args[0] == "--uid" {
+				uid = id
+			} else {
+				gid = id
+			}
+		default:
+			return fmt.Errorf("argument necunoscut: %s", args[0])
+		}
+		args = args[2:]
+	}
+	if (uid < 0) != (gid < 0) {
+		return fmt.Errorf("--uid si --gid trebuie date impreuna")
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("comanda lipsa")
+	}
+	argv := args[1:]
+
+	if cgroup != "" {
+		pid := []byte(strconv.Itoa(os.Getpid()))
+		if err := os.WriteFile(filepath.Join(cgroup, "cgroup.procs"), pid, 0644); err != nil {
+			return fmt.Errorf("intrare in cgroup: %w", err)
+		}
+	}
+
+	// Fara propagare a montarilor catre gazda
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("mount private: %w", err)
+	}
+	if err := remountReadOnly(); err != nil {
+		return fmt.Errorf("remount read-only: %w", err)
+	}
+
+	// Namespace PID nou: /proc propriu, read-only (fara scrieri in /proc/sys)
+	newPID := os.Getpid() == 1
+	if newPID {
+		if err := unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC|unix.MS_RDONLY, ""); err != nil {
+			return fmt.Errorf("mount /proc: %w", err)
+		}
+	}
+	if err := mountMinimalDev(); err != nil {
+		return fmt.Errorf("/dev: %w", err)
+	}
+	if privateRun {
+		for _, dir := range hiddenRunDirs {
+			if info, err := os.Lstat(dir); err != nil || !info.IsDir() {
+				continue // ex: /var/run -> /run
+			}
+			if err := unix.Mount("tmpfs", dir, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC|unix.MS_RDONLY, "mode=0755,size=16k"); err != nil {
+				return fmt.Errorf("tmpfs %s: %w", dir, err)
+			}
+		}
+	}
+
+	for _, dir := range privateTmpDirs {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		if err := unix.Mount("tmpfs", dir, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777,size=64m"); err != nil {
+			return fmt.Errorf("tmpfs %s: %w", dir, err)
+		}
+	}
+
+	if err := dropCapabilities(); err != nil {
+		return fmt.Errorf("capabilitati: %w", err)
+	}
+	if uid >= 0 {
+		if err := switchUser(uid, gid); err != nil {
+			return fmt.Errorf("schimbare utilizator: %w", err)
+		}
+	}
+
+	if !newPID {
+		return unix.Exec(argv[0], argv, os.Environ())
+	}
+	return runInit(argv)
+}
+
+// runInit porneste comanda ca proces copil si ramane init-ul namespace-ului:
+// un PID 1 ignora semnalele fara handler, deci semnalele primite sunt
+// transmise tuturor proceselor din namespace. La iesirea comenzii, kernel-ul
+// opreste procesele ramase in namespace.
+func runInit(argv []string) error {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+
+	signals := make(chan os.Signal, 4)
+	signal.Notify(signals, unix.SIGTERM, unix.SIGINT, unix.SIGHUP, unix.SIGQUIT)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		for sig := range signals {
+			unix.Kill(-1, sig.(syscall.Signal))
+		}
+	}()
+
+	err := cmd.Wait()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			os.Exit(128 + int(status.Signal()))
+		}
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
+
+// mountMinimalDev inlocuieste /dev cu un tmpfs read-only ce contine doar
+// null, zero, full, random si urandom; dispozitivele gazdei (discuri, tty)
+// nu mai sunt accesibile
+func mountMinimalDev() error {
+	if err := unix.Mount("tmpfs", "/dev", "tmpfs", unix.MS_NOSUID|unix.MS_NOEXEC, "mode=0755,size=64k"); err != nil {
+		return err
+	}
+	for _, d := range sandboxDevices {
+		path := filepath.Join("/dev", d.name)
+		if err := unix.Mknod(path, unix.S_IFCHR|0666, int(unix.Mkdev(d.major, d.minor))); err != nil {
+			return fmt.Errorf("mknod %s: %w", path, err)
+		}
+		if err := os.Chmod(path, 0666); err != nil {
+			return err
+		}
+	}
+	for name, target := range map[string]string{
+		"fd":     "/proc/self/fd",
+		"stdin":  "/proc/self/fd/0",
+		"stdout": "/proc/self/fd/1",
+		"stderr": "/proc/self/fd/2",
+	} {
+		if err := os.Symlink(target, filepath.Join("/dev", name)); err != nil {
+			return err
+		}
+	}
+	if err := os.Mkdir("/dev/shm", 01777); err != nil {
+		return err
+	}
+	return unix.Mount("", "/dev", "", unix.MS_REMOUNT|unix.MS_BIND|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NOEXEC, "")
+}
+
+// remountReadOnly face read-only intreg arborele de montare
+func remountReadOnly() error {
+	attr := &unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY}
+	err := unix.MountSetattr(-1, "/", unix.AT_RECURSIVE, attr)
+	if err == nil {
+		return nil
+	}
+	if err != unix.ENOSYS {
+		return err
+	}
+
+	// Kernel < 5.12: bind-remount pentru fiecare punct de montare
+	mounts, err := mountPoints()
+	if err != nil {
+		return err
+	}
+	for _, mp := range mounts {
+		var st unix.Statfs_t
+		if err := unix.Statfs(mp, &st); err != nil {
+			continue
+		}
+		flags := uintptr(unix.MS_BIND|unix.MS_REMOUNT|unix.MS_RDONLY) | mountFlags(int64(st.Flags))
+		if err := unix.Mount("", mp, "", flags, ""); err != nil {
+			return fmt.Errorf("%s: %w", mp, err)
+		}
+	}
+	return nil
+}
+
+// mountFlags pastreaza flag-urile blocate (nosuid, nodev, noexec, ...)
+func mountFlags(statfsFlags int64) uintptr {
+	var flags uintptr
+	for st, ms := range map[int64]uintptr{
+		unix.ST_NOSUID:      unix.MS_NOSUID,
+		unix.ST_NODEV:       unix.MS_NODEV,
+		unix.ST_NOEXEC:      unix.MS_NOEXEC,
+		unix.ST_NOATIME:     unix.MS_NOATIME,
+		unix.ST_NODIRATIME:  unix.MS_NODIRATIME,
+		unix.ST_RELATIME:    unix.MS_RELATIME,
+		unix.ST_SYNCHRONOUS: unix.MS_SYNCHRONOUS,
+	} {
+		if statfsFlags&st != 0 {
+			flags |= ms
+		}
+	}
+	return flags
+}
+
+func mountPoints() ([]string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 4 {
+			mounts = append(mounts, unescapeMountPath(fields[4]))
+		}
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountPath decodeaza secventele octale din mountinfo (ex: \040)
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// dropCapabilities limiteaza bounding set-ul la keptCaps si seteaza
+// no_new_privs; dupa exec, root primeste doar capabilitatile pastrate
+func dropCapabilities() error {
+	lastCap := unix.CAP_LAST_CAP
+	if data, err := os.ReadFile("/proc/sys/kernel/cap_last_cap"); err == nil {
+		if v, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			lastCap = v
+		}
+	}
+
+	for c := 0; c <= lastCap; c++ {
+		if keptCaps[c] {
+			continue
+		}
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil && err != unix.EINVAL {
+			return fmt.Errorf("drop cap %d: %w", c, err)
+		}
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil && err != unix.EINVAL {
+		return fmt.Errorf("ambient: %w", err)
+	}
+
+	// Setul mostenibil e golit, ca nicio capabilitate sa nu treaca prin exec
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	var data [2]unix.CapUserData
+	if err := unix.Capget(&hdr, &data[0]); err != nil {
+		return fmt.Errorf("capget: %w", err)
+	}
+	data[0].Inheritable, data[1].Inheritable = 0, 0
+	if err := unix.Capset(&hdr, &data[0]); err != nil {
+		return fmt.Errorf("capset: %w", err)
+	}
+	return unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0)
+}
+
+// switchUser trece la uid/gid fara grupuri suplimentare; dupa setresuid
+// catre un uid nenul toate capabilitatile ramase sunt pierdute
+func switchUser(uid, gid int) error {
+	if err := unix.Setgroups(nil); err != nil {
+		return fmt.Errorf("setgroups: %w", err)
+	}
+	if err := unix.Setresgid(gid, gid, gid); err != nil {
+		return fmt.Errorf("setresgid: %w", err)
+	}
+	if err := unix.Setresuid(uid, uid, uid); err != nil {
+		return fmt.Errorf("setresuid: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// Binarul de test se re-executa ca proces ajutator, ca agentul
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == HelperCommand {
+		RunHelper(os.Args[2:])
+	}
+	os.Exit(m.Run())
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       Config
+		opts      Options
+		wantFlags uintptr
+		noFlags   uintptr
+		wantArgs  []string
+	}{
+		{
+			name:      "implicit: retea, PID si /run ale gazdei",
+			wantFlags: syscall.CLONE_NEWNS | syscall.CLONE_NEWIPC,
+			noFlags:   syscall.CLONE_NEWNET | syscall.CLONE_NEWPID,
+			wantArgs:  []string{"/agent", HelperCommand, "--", "/bin/cat", "/etc/hostname"},
+		},
+		{
+			name:      "izolare retea",
+			cfg:       Config{IsolateNetwork: true},
+			wantFlags: syscall.CLONE_NEWNS | syscall.CLONE_NEWIPC | syscall.CLONE_NEWNET,
+			noFlags:   syscall.CLONE_NEWPID,
+			wantArgs:  []string{"/agent", HelperCommand, "--", "/bin/cat", "/etc/hostname"},
+		},
+		{
+			name:      "izolare PID si /run",
+			cfg:       Config{IsolatePID: true, IsolateRun: true},
+			wantFlags: syscall.CLONE_NEWNS | syscall.CLONE_NEWIPC | syscall.CLONE_NEWPID,
+			noFlags:   syscall.CLONE_NEWNET,
+			wantArgs:  []string{"/agent", HelperCommand, "--private-run", "--", "/bin/cat", "/etc/hostname"},
+		},
+		{
+			name:      "utilizator",
+			opts:      Options{Credential: &syscall.Credential{Uid: 65534, Gid: 65533}},
+			wantFlags: syscall.CLONE_NEWNS | syscall.CLONE_NEWIPC,
+			wantArgs:  []string{"/agent", HelperCommand, "--uid", "65534", "--gid", "65533", "--", "/bin/cat", "/etc/hostname"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Sandbox{cfg: tt.cfg, self: "/agent"}
+			cmd := exec.Command("/bin/cat", "/etc/hostname")
+			cleanup, err := s.Wrap(cmd, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cleanup()
+
+			if cmd.Path != "/agent" || !slices.Equal(cmd.Args, tt.wantArgs) {
+				t.Errorf("argv = %s %v, asteptat %v", cmd.Path, cmd.Args, tt.wantArgs)
+			}
+			flags := cmd.SysProcAttr.Cloneflags
+			if flags&tt.wantFlags != tt.wantFlags {
+				t.Errorf("Cloneflags %#x fara %#x", flags, tt.wantFlags)
+			}
+			if flags&tt.noFlags != 0 {
+				t.Errorf("Cloneflags %#x contine %#x", flags, flags&tt.noFlags)
+			}
+			if cmd.SysProcAttr.Credential != nil {
+				t.Error("utilizatorul trebuie schimbat in procesul ajutator, nu la clone")
+			}
+		})
+	}
+}
+
+func TestWrapLookupError(t *testing.T) {
+	s := &Sandbox{self: "/agent"}
+	cmd := exec.Command("bittrail-program-inexistent")
+	if _, err := s.Wrap(cmd, Options{}); err == nil {
+		t.Error("comanda inexistenta acceptata")
+	}
+}
+
+func TestRunHelperRejectsBadArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"argument necunoscut", []string{"--net", "x", "--", "/bin/true"}, "necunoscut"},
+		{"valoare lipsa", []string{"--cgroup"}, "fara valoare"},
+		{"uid invalid", []string{"--uid", "nobody", "--gid", "0", "--", "/bin/true"}, "--uid invalid"},
+		{"gid negativ", []string{"--uid", "0", "--gid", "-1", "--", "/bin/true"}, "--gid invalid"},
+		{"uid fara gid", []string{"--uid", "65534", "--", "/bin/true"}, "impreuna"},
+		{"comanda lipsa", []string{"--"}, "comanda lipsa"},
+		{"fara argumente", nil, "comanda lipsa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runHelper(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, asteptat %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// sandboxed ruleaza comanda prin procesul ajutator (binarul de test), fara cgroup
+func sandboxed(t *testing.T, cfg Config, name string, args ...string) (string, error) {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("necesita root")
+	}
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Sandbox{cfg: cfg, self: self}
+	cmd := exec.Command(name, args...)
+	cleanup, err := s.Wrap(cmd, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	out, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok && IsHelperFailure(exitErr.ExitCode()) {
+		t.Skipf("izolare indisponibila in acest mediu: %s", out)
+	}
+	return string(out), err
+}
+
+func TestSandboxRootFilesystemReadOnly(t *testing.T) {
+	marker := filepath.Join("/", "bittrail-sandbox-test")
+	defer os.Remove(marker)
+
+	out, err := sandboxed(t, Config{}, "/bin/sh", "-c", "echo x > "+marker)
+	if err == nil {
+		t.Errorf("scriere in / permisa in sandbox: %s", out)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("fisierul scris in sandbox a ajuns pe gazda")
+	}
+}
+
+func TestSandboxPrivateTmp(t *testing.T) {
+	marker := filepath.Join(os.TempDir(), "bittrail-sandbox-tmp-test")
+	if !strings.HasPrefix(marker, "/tmp/") {
+		t.Skip("TMPDIR in afara /tmp")
+	}
+	defer os.Remove(marker)
+
+	// /tmp e un tmpfs privat: scrierea reuseste, dar nu ajunge pe gazda
+	if out, err := sandboxed(t, Config{}, "/bin/sh", "-c", "echo x > "+marker); err != nil {
+		t.Fatalf("scriere in /tmp privat: %v: %s", err, out)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("/tmp al sandbox-ului nu e privat")
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+func newPlatformSandbox(cfg Config) (*Sandbox, error) {
+	return nil, fmt.Errorf("disponibil doar pe Linux")
+}
+
+// Wrap nu e suportat in afara Linux
+func (s *Sandbox) Wrap(cmd *exec.Cmd, opts Options) (func(), error) {
+	return func() {}, fmt.Errorf("sandbox disponibil doar pe Linux")
+}
+
+// RunHelper nu e suportat in afara Linux
+func RunHelper(args []string) {
+	fmt.Fprintln(os.Stderr, "bittrail-sandbox: disponibil doar pe Linux")
+	os.Exit(helperFailureExit)
+}
@@ -0,0 +1,190 @@
+// Package schedule interpreteaza expresiile cron (5 campuri: minut, ora, zi
+// din luna, luna, zi din saptamana) folosite pentru evaluarea continua.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule e o expresie cron compilata; campurile sunt multimi de biti
+type Schedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minut", min: 0, max: 59}
+	hourField   = field{name: "ora", min: 0, max: 23}
+	domField    = field{name: "zi din luna", min: 1, max: 31}
+	monthField  = field{name: "luna", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 e tot duminica, ca in cron-ul clasic
+	dowField = field{name: "zi din saptamana", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Prescurtari acceptate
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse compileaza expresia: liste (1,15), intervale (1-5), pasi (*/10,
+// 8-18/2), nume de luni si zile (jan, mon) si prescurtarile @daily etc.
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expresie cron %q: asteptat 5 campuri, gasit %d", expr, len(fields))
+	}
+
+	s := &Schedule{expr: expr}
+	var err error
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, fmt.Errorf("expresie cron %q: %w", expr, err)
+	}
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, fmt.Errorf("expresie cron %q: %w", expr, err)
+	}
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, fmt.Errorf("expresie cron %q: %w", expr, err)
+	}
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, fmt.Errorf("expresie cron %q: %w", expr, err)
+	}
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, fmt.Errorf("expresie cron %q: %w", expr, err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 -> 0
+	}
+	s.domRestricted = !strings.HasPrefix(fields[2], "*")
+	s.dowRestricted = !strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// parse intoarce multimea de valori a campului
+func (f field) parse(spec string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepSpec)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("%s: pas invalid %q", f.name, stepSpec)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch {
+		case rangeSpec == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(rangeSpec, "-"):
+			a, b, _ := strings.Cut(rangeSpec, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s: interval invers %q", f.name, rangeSpec)
+			}
+		default:
+			v, err := f.value(rangeSpec)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if hasStep {
+				hi = f.max // 5/15 = de la 5, din 15 in 15
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: valoare invalida %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %d in afara intervalului %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next intoarce primul moment (la minut exact) strict dupa t care respecta
+// expresia, in fusul orar al lui t; zero daca nu exista in urmatorii 5 ani
+// (ex: 30 februarie)
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches aplica regula cron: cand ambele zile sunt restranse, ajunge
+// potrivirea uneia dintre ele
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,71 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"* * * *", "5 campuri"},
+		{"60 * * * *", "minut: 60 in afara"},
+		{"* 24 * * *", "ora: 24"},
+		{"* * 0 * *", "zi din luna: 0"},
+		{"* * * 13 *", "luna: 13"},
+		{"* * * * 8", "zi din saptamana: 8"},
+		{"*/0 * * * *", "pas invalid"},
+		{"5-1 * * * *", "interval invers"},
+		{"x * * * *", "valoare invalida"},
+		{"@every 5m", "5 campuri"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, asteptat %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// 2026-10-18 e duminica
+	from := time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"* * * * *", "2026-10-18 10:08"},
+		{"*/15 * * * *", "2026-10-18 10:15"},
+		{"5/20 * * * *", "2026-10-18 10:25"},
+		{"0 * * * *", "2026-10-18 11:00"},
+		{"@hourly", "2026-10-18 11:00"},
+		{"@daily", "2026-10-19 00:00"},
+		{"30 2 * * *", "2026-10-19 02:30"},
+		{"0 8-18/2 * * *", "2026-10-18 12:00"},
+		{"0 9 * * mon-fri", "2026-10-19 09:00"},
+		{"0 9 * * 7", "2026-10-25 09:00"},
+		{"0 0 1 * *", "2026-11-01 00:00"},
+		{"0 0 1 jan *", "2027-01-01 00:00"},
+		{"0 0 29 2 *", "2028-02-29 00:00"},
+		// zi din luna SAU zi din saptamana, cand ambele sunt restranse
+		{"0 0 1 * mon", "2026-10-19 00:00"},
+		{"0 0 1,15 * *", "2026-11-01 00:00"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		if got := s.Next(from).Format("2006-01-02 15:04"); got != tt.want {
+			t.Errorf("%q: Next = %s, asteptat %s", tt.expr, got, tt.want)
+		}
+	}
+
+	s, _ := Parse("0 0 30 2 *")
+	if got := s.Next(from); !got.IsZero() {
+		t.Errorf("30 februarie: Next = %v, asteptat zero", got)
+	}
+}
@@ -0,0 +1,213 @@
+// Package template citeste sabloanele de audit in formatul bittrail-template@1.0,
+// acelasi format importat de backend, pentru rularea locala (offline).
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"bittrail-agent/internal/api"
+)
+
+// SchemaPrefix e prefixul campului $schema acceptat
+const SchemaPrefix = "bittrail-template@1."
+
+// Severitati acceptate (ca in validarea backend-ului)
+var severities = map[string]bool{"CRITICAL": true, "HIGH": true, "MEDIUM": true, "LOW": true, "INFO": true}
+
+type Template struct {
+	Schema   string    `json:"$schema"`
+	Metadata Metadata  `json:"metadata"`
+	Controls []Control `json:"controls"`
+}
+
+type Metadata struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+	Type        string `json:"type"`
+	Author      string `json:"author"`
+	Source      string `json:"source"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+type Control struct {
+	ControlID       string           `json:"controlId"`
+	Title           string           `json:"title"`
+	Category        string           `json:"category"`
+	Severity        string           `json:"severity"`
+	Description     string           `json:"description"`
+	Rationale       string           `json:"rationale"`
+	AutomatedChecks []AutomatedCheck `json:"automatedChecks"`
+	ManualChecks    []ManualCheck    `json:"manualChecks"`
+}
+
+// AutomatedCheck e o verificare executata de agent; campurile au aceleasi
+// nume ca verificarile servite de backend (api.PendingCheck)
+type AutomatedCheck struct {
+	CheckID        string            `json:"checkId"`
+	Title          string            `json:"title"`
+	Description    string            `json:"description"`
+	Command        string            `json:"command"`
+	Script         string            `json:"script"`
+	Interpreter    string            `json:"interpreter"`
+	ScriptSHA256   string            `json:"scriptSha256"`
+	Args           []string          `json:"args"`
+	ExpectedResult string            `json:"expectedResult"`
+	WarnResult     string            `json:"warnResult"`
+	CheckType      string            `json:"checkType"`
+	Comparison     string            `json:"comparison"`
+	Parser         string            `json:"parser"`
+	Normalize      []string          `json:"normalize"`
+	OnFailMessage  string            `json:"onFailMessage"`
+	PlatformScope  []string          `json:"platformScope"`
+	Requires       []string          `json:"requires"`
+	TimeoutSeconds int               `json:"timeoutSeconds"`
+	Serial         bool              `json:"serial"`
+	RunAs          string            `json:"runAs"`
+	Env            map[string]string `json:"env"`
+}
+
+// ManualCheck e o sarcina pentru auditor; nu e evaluata offline
+type ManualCheck struct {
+	CheckID      string          `json:"checkId"`
+	Title        string          `json:"title"`
+	Instructions string          `json:"instructions"`
+	EvidenceSpec json.RawMessage `json:"evidenceSpec"`
+}
+
+// Load citeste si valideaza sablonul
+func Load(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tpl, nil
+}
+
+// Parse decodeaza si valideaza sablonul
+func Parse(data []byte) (*Template, error) {
+	var tpl Template
+	if err := json.Unmarshal(data, &tpl); err != nil {
+		return nil, fmt.Errorf("JSON invalid: %w", err)
+	}
+	if errs := tpl.Validate(); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &tpl, nil
+}
+
+// Validate verifica structura sablonului (regulile validarii din backend,
+// plus identificatori unici si o comanda pentru fiecare verificare)
+func (t *Template) Validate() []error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if !strings.HasPrefix(t.Schema, SchemaPrefix) {
+		add("$schema %q nesuportat (asteptat %s0)", t.Schema, SchemaPrefix)
+	}
+	if t.Metadata.Name == "" {
+		add("metadata.name obligatoriu")
+	}
+	if t.Metadata.Version == "" {
+		add("metadata.version obligatoriu")
+	}
+	if len(t.Controls) == 0 {
+		add("controls lipseste sau e gol")
+	}
+
+	controlIDs := make(map[string]bool)
+	checkIDs := make(map[string]bool)
+	for i, control := range t.Controls {
+		prefix := fmt.Sprintf("controls[%d]", i)
+		if control.ControlID == "" {
+			add("%s.controlId obligatoriu", prefix)
+		} else if controlIDs[control.ControlID] {
+			add("%s.controlId %q duplicat", prefix, control.ControlID)
+		}
+		controlIDs[control.ControlID] = true
+		if control.Title == "" {
+			add("%s.title obligatoriu", prefix)
+		}
+		if control.Category == "" {
+			add("%s.category obligatoriu", prefix)
+		}
+		if control.Severity == "" {
+			add("%s.severity obligatoriu", prefix)
+		} else if !severities[control.Severity] {
+			add("%s.severity %q invalid", prefix, control.Severity)
+		}
+
+		for j, check := range control.AutomatedChecks {
+			checkPrefix := fmt.Sprintf("%s.automatedChecks[%d]", prefix, j)
+			if check.CheckID == "" {
+				add("%s.checkId obligatoriu", checkPrefix)
+			} else if checkIDs[check.CheckID] {
+				add("%s.checkId %q duplicat", checkPrefix, check.CheckID)
+			}
+			checkIDs[check.CheckID] = true
+			if check.Command == "" && check.Script == "" {
+				add("%s: command sau script obligatoriu", checkPrefix)
+			}
+		}
+		// Rezultatele manuale folosesc acelasi spatiu de identificatori
+		for j, check := range control.ManualChecks {
+			checkPrefix := fmt.Sprintf("%s.manualChecks[%d]", prefix, j)
+			if check.CheckID == "" {
+				add("%s.checkId obligatoriu", checkPrefix)
+			} else if checkIDs[check.CheckID] {
+				add("%s.checkId %q duplicat", checkPrefix, check.CheckID)
+			}
+			checkIDs[check.CheckID] = true
+		}
+	}
+	return errs
+}
+
+// PendingCheck construieste verificarea in forma servita de backend, cu
+// aceleasi valori implicite (checkType COMMAND, comparison EQUALS, parser RAW)
+func (c AutomatedCheck) PendingCheck(auditRunID string) api.PendingCheck {
+	check := api.PendingCheck{
+		AuditRunID:       auditRunID,
+		AutomatedCheckID: c.CheckID,
+		CheckID:          c.CheckID,
+		Title:            c.Title,
+		Command:          c.Command,
+		Script:           c.Script,
+		Interpreter:      c.Interpreter,
+		ScriptSHA256:     c.ScriptSHA256,
+		Args:             c.Args,
+		ExpectedResult:   c.ExpectedResult,
+		WarnResult:       c.WarnResult,
+		CheckType:        c.CheckType,
+		Comparison:       c.Comparison,
+		Parser:           c.Parser,
+		Normalize:        c.Normalize,
+		OnFailMessage:    c.OnFailMessage,
+		PlatformScope:    c.PlatformScope,
+		Requires:         c.Requires,
+		TimeoutSeconds:   c.TimeoutSeconds,
+		Serial:           c.Serial,
+		RunAs:            c.RunAs,
+		Env:              c.Env,
+	}
+	if check.CheckType == "" {
+		check.CheckType = "COMMAND"
+	}
+	if check.Comparison == "" {
+		check.Comparison = "EQUALS"
+	}
+	if check.Parser == "" {
+		check.Parser = "RAW"
+	}
+	return check
+}
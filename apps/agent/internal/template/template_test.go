@@ -0,0 +1,84 @@
+package template
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShippedTemplates(t *testing.T) {
+	paths, err := filepath.Glob("../../../../templates/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Skip("sabloanele repo-ului lipsesc")
+	}
+	for _, path := range paths {
+		tpl, err := Load(path)
+		if err != nil {
+			t.Errorf("%v", err)
+			continue
+		}
+		for _, control := range tpl.Controls {
+			for _, check := range control.AutomatedChecks {
+				pc := check.PendingCheck("LOCAL")
+				if pc.CheckType == "" || pc.Comparison == "" || pc.Parser == "" {
+					t.Errorf("%s: %s fara valori implicite: %+v", path, check.CheckID, pc)
+				}
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		errs []string
+	}{
+		{"valid", `{"$schema":"bittrail-template@1.0","metadata":{"name":"t","version":"1"},
+			"controls":[{"controlId":"C1","title":"c","category":"x","severity":"HIGH",
+			"automatedChecks":[{"checkId":"C1.a","command":"true"}]}]}`, nil},
+		{"schema", `{"$schema":"bittrail-template@2.0","metadata":{"name":"t","version":"1"},
+			"controls":[{"controlId":"C1","title":"c","category":"x","severity":"HIGH"}]}`,
+			[]string{"$schema"}},
+		{"fara controale", `{"$schema":"bittrail-template@1.0","metadata":{}}`,
+			[]string{"metadata.name", "metadata.version", "controls"}},
+		{"severitate", `{"$schema":"bittrail-template@1.0","metadata":{"name":"t","version":"1"},
+			"controls":[{"controlId":"C1","title":"c","category":"x","severity":"URGENT"}]}`,
+			[]string{"controls[0].severity"}},
+		{"duplicate", `{"$schema":"bittrail-template@1.0","metadata":{"name":"t","version":"1"},
+			"controls":[
+				{"controlId":"C1","title":"c","category":"x","severity":"LOW","automatedChecks":[{"checkId":"a","command":"true"}]},
+				{"controlId":"C1","title":"c","category":"x","severity":"LOW","automatedChecks":[{"checkId":"a","script":"true"}]}]}`,
+			[]string{"controls[1].controlId", "controls[1].automatedChecks[0].checkId"}},
+		{"fara comanda", `{"$schema":"bittrail-template@1.0","metadata":{"name":"t","version":"1"},
+			"controls":[{"controlId":"C1","title":"c","category":"x","severity":"LOW","automatedChecks":[{"checkId":"a"}]}]}`,
+			[]string{"command sau script"}},
+		{"manual duplicat", `{"$schema":"bittrail-template@1.0","metadata":{"name":"t","version":"1"},
+			"controls":[{"controlId":"C1","title":"c","category":"x","severity":"LOW",
+				"automatedChecks":[{"checkId":"a","command":"true"}],"manualChecks":[{"checkId":"a"},{"title":"m"}]}]}`,
+			[]string{"manualChecks[0].checkId \"a\" duplicat", "manualChecks[1].checkId obligatoriu"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.json))
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Fatalf("eroare neasteptata: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("sablon invalid acceptat, asteptat %v", tt.errs)
+			}
+			for _, want := range tt.errs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("lipseste %q din %v", want, err)
+				}
+			}
+		})
+	}
+}
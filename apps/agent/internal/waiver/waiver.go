@@ -0,0 +1,200 @@
+// Package waiver citeste fisierul local de exceptii (waivers) al gazdei:
+// verificari sau controale acceptate temporar ca neconforme, cu justificare,
+// aprobator si data expirarii, semnate de aprobator.
+package waiver
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/crypto"
+)
+
+// dateLayout e forma scurta a expirarii: exceptia e valabila toata ziua (UTC)
+const dateLayout = "2006-01-02"
+
+// File e fisierul de pe disc: JSON-ul setului (base64) si semnatura
+// aprobatorului (RSA PKCS1v15 + SHA256) pe octetii lui
+type File struct {
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// Set sunt exceptiile aprobate pentru un server
+type Set struct {
+	ServerID string   `json:"serverId"`
+	IssuedAt int64    `json:"issuedAt"` // secunde unix
+	Waivers  []Waiver `json:"waivers"`
+}
+
+// Waiver acopera verificarile dupa checkId sau toate verificarile unui control
+type Waiver struct {
+	ID            string   `json:"id"`
+	CheckIDs      []string `json:"checkIds,omitempty"`
+	ControlIDs    []string `json:"controlIds,omitempty"`
+	Justification string   `json:"justification"`
+	Approver      string   `json:"approver"`
+	ExpiresAt     string   `json:"expiresAt"` // RFC3339 sau AAAA-LL-ZZ (inclusiv)
+}
+
+// Expiry intoarce momentul expirarii; o data simpla expira la sfarsitul zilei
+func (w Waiver) Expiry() (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, w.ExpiresAt); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(dateLayout, w.ExpiresAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expiresAt %q: asteptat RFC3339 sau AAAA-LL-ZZ", w.ExpiresAt)
+	}
+	return day.AddDate(0, 0, 1), nil
+}
+
+// Expired e adevarat dupa momentul expirarii
+func (w Waiver) Expired(now time.Time) bool {
+	expiry, err := w.Expiry()
+	return err != nil || !now.Before(expiry)
+}
+
+// Covers e adevarat daca exceptia acopera verificarea
+func (w Waiver) Covers(check api.PendingCheck) bool {
+	for _, id := range w.CheckIDs {
+		if id == check.CheckID {
+			return true
+		}
+	}
+	if check.ControlID == "" {
+		return false
+	}
+	for _, id := range w.ControlIDs {
+		if id == check.ControlID {
+			return true
+		}
+	}
+	return false
+}
+
+// Info intoarce metadatele raportate cu rezultatul verificarii acoperite
+func (w Waiver) Info(evaluatedStatus string, now time.Time) *api.WaiverInfo {
+	return &api.WaiverInfo{
+		ID:              w.ID,
+		Justification:   w.Justification,
+		Approver:        w.Approver,
+		ExpiresAt:       w.ExpiresAt,
+		EvaluatedStatus: evaluatedStatus,
+		Expired:         w.Expired(now),
+	}
+}
+
+// Validate intoarce erorile setului; o exceptie fara justificare, aprobator
+// sau expirare nu e acceptata
+func (s *Set) Validate() error {
+	var errs []string
+	ids := make(map[string]bool)
+	for i, w := range s.Waivers {
+		prefix := fmt.Sprintf("waivers[%d]", i)
+		if w.ID == "" {
+			errs = append(errs, prefix+": id lipsa")
+		} else if ids[w.ID] {
+			errs = append(errs, fmt.Sprintf("%s: id %q duplicat", prefix, w.ID))
+		}
+		ids[w.ID] = true
+		if len(w.CheckIDs) == 0 && len(w.ControlIDs) == 0 {
+			errs = append(errs, prefix+": checkIds sau controlIds obligatoriu")
+		}
+		if strings.TrimSpace(w.Justification) == "" {
+			errs = append(errs, prefix+": justification lipsa")
+		}
+		if strings.TrimSpace(w.Approver) == "" {
+			errs = append(errs, prefix+": approver lipsa")
+		}
+		if _, err := w.Expiry(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", prefix, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Match intoarce exceptia care acopera verificarea: prima activa sau, daca
+// toate au expirat, prima expirata (semnalata in rezultat); nil daca nu exista
+func (s *Set) Match(check api.PendingCheck, now time.Time) *Waiver {
+	var expired *Waiver
+	for i := range s.Waivers {
+		w := &s.Waivers[i]
+		if !w.Covers(check) {
+			continue
+		}
+		if !w.Expired(now) {
+			return w
+		}
+		if expired == nil {
+			expired = w
+		}
+	}
+	return expired
+}
+
+// Sign valideaza setul si il semneaza cu cheia aprobatorului
+func Sign(key *rsa.PrivateKey, set *Set) (*File, error) {
+	if err := set.Validate(); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.SignData(key, payload)
+	if err != nil {
+		return nil, err
+	}
+	return &File{Payload: base64.StdEncoding.EncodeToString(payload), Signature: sig}, nil
+}
+
+// Open verifica semnatura fisierului cu cheia publica a aprobatorului (PEM)
+// si intoarce setul, legat de serverul agentului
+func Open(f *File, pubKeyPEM []byte, serverID string) (*Set, error) {
+	if f.Signature == "" {
+		return nil, errors.New("fisier exceptii nesemnat")
+	}
+	payload, err := base64.StdEncoding.DecodeString(f.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("payload invalid: %w", err)
+	}
+	if err := crypto.VerifySignature(pubKeyPEM, payload, f.Signature); err != nil {
+		return nil, fmt.Errorf("semnatura invalida: %w", err)
+	}
+
+	var set Set
+	if err := json.Unmarshal(payload, &set); err != nil {
+		return nil, fmt.Errorf("payload invalid: %w", err)
+	}
+	if set.ServerID != serverID {
+		return nil, fmt.Errorf("exceptii emise pentru alt server (%s)", set.ServerID)
+	}
+	if err := set.Validate(); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// Load citeste si verifica fisierul de exceptii
+func Load(path string, pubKeyPEM []byte, serverID string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: JSON invalid: %w", path, err)
+	}
+	return Open(&f, pubKeyPEM, serverID)
+}
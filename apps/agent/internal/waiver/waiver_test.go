@@ -0,0 +1,115 @@
+package waiver
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"bittrail-agent/internal/api"
+	"bittrail-agent/internal/crypto"
+)
+
+func testSet() *Set {
+	return &Set{
+		ServerID: "srv-1",
+		IssuedAt: 1760000000,
+		Waivers: []Waiver{
+			{ID: "W-1", CheckIDs: []string{"C1.a"}, Justification: "sistem legacy", Approver: "secops", ExpiresAt: "2026-12-31"},
+			{ID: "W-2", ControlIDs: []string{"C2"}, Justification: "migrare in curs", Approver: "secops", ExpiresAt: "2026-10-01T00:00:00Z"},
+		},
+	}
+}
+
+func TestOpen(t *testing.T) {
+	key, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	f, err := Sign(key, testSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := Open(f, pub, "srv-1")
+	if err != nil || len(set.Waivers) != 2 {
+		t.Fatalf("set valid: %v", err)
+	}
+
+	other, _ := Sign(key, &Set{ServerID: "srv-2"})
+	tampered := *f
+	tampered.Payload = other.Payload
+	for name, tt := range map[string]struct {
+		file *File
+		want string
+	}{
+		"alt server":       {other, "alt server"},
+		"payload schimbat": {&tampered, "semnatura invalida"},
+		"nesemnat":         {&File{Payload: f.Payload}, "nesemnat"},
+	} {
+		if _, err := Open(tt.file, pub, "srv-1"); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: %v, asteptat %q", name, err, tt.want)
+		}
+	}
+
+	invalid := testSet()
+	invalid.Waivers[0].Approver = ""
+	invalid.Waivers[1].ID = "W-1"
+	invalid.Waivers[1].ExpiresAt = "31.12.2026"
+	if _, err := Sign(key, invalid); err == nil || !strings.Contains(err.Error(), "approver lipsa") ||
+		!strings.Contains(err.Error(), "duplicat") || !strings.Contains(err.Error(), "AAAA-LL-ZZ") {
+		t.Errorf("set invalid semnat: %v", err)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	tests := []struct {
+		expiresAt string
+		now       string
+		expired   bool
+	}{
+		{"2026-12-31", "2026-12-31T23:59:59Z", false},
+		{"2026-12-31", "2027-01-01T00:00:00Z", true},
+		{"2026-10-01T12:00:00+03:00", "2026-10-01T08:59:00Z", false},
+		{"2026-10-01T12:00:00+03:00", "2026-10-01T09:00:00Z", true},
+		{"maine", "2026-01-01T00:00:00Z", true},
+	}
+	for _, tt := range tests {
+		now, _ := time.Parse(time.RFC3339, tt.now)
+		if got := (Waiver{ExpiresAt: tt.expiresAt}).Expired(now); got != tt.expired {
+			t.Errorf("%s la %s: expirata %t, asteptat %t", tt.expiresAt, tt.now, got, tt.expired)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	set := testSet()
+	set.Waivers = append(set.Waivers, Waiver{ID: "W-3", CheckIDs: []string{"C2.b"}, ExpiresAt: "2027-06-30"})
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		check api.PendingCheck
+		want  string
+	}{
+		{api.PendingCheck{CheckID: "C1.a", ControlID: "C1"}, "W-1"},
+		{api.PendingCheck{CheckID: "C1.b", ControlID: "C1"}, ""},
+		{api.PendingCheck{CheckID: "C2.a", ControlID: "C2"}, "W-2"}, // expirata, dar semnalata
+		{api.PendingCheck{CheckID: "C2.b", ControlID: "C2"}, "W-3"}, // activa inaintea celei expirate
+		{api.PendingCheck{CheckID: "C2.a"}, ""},                     // fara controlId
+	}
+	for _, tt := range tests {
+		got := ""
+		if w := set.Match(tt.check, now); w != nil {
+			got = w.ID
+		}
+		if got != tt.want {
+			t.Errorf("%s/%s: %q, asteptat %q", tt.check.ControlID, tt.check.CheckID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Validare expresii cron pentru evaluarea continua (sincronizat cu
+// pachetul schedule din agentul Go): 5 campuri, liste, intervale, pasi,
+// nume de luni si zile si prescurtarile @daily, @hourly ...
+
+const MACROS = {
+    '@yearly': '0 0 1 1 *',
+    '@annually': '0 0 1 1 *',
+    '@monthly': '0 0 1 * *',
+    '@weekly': '0 0 * * 0',
+    '@daily': '0 0 * * *',
+    '@midnight': '0 0 * * *',
+    '@hourly': '0 * * * *',
+};
+
+const MONTHS = ['jan', 'feb', 'mar', 'apr', 'may', 'jun', 'jul', 'aug', 'sep', 'oct', 'nov', 'dec'];
+const DAYS = ['sun', 'mon', 'tue', 'wed', 'thu', 'fri', 'sat'];
+
+const FIELDS = [
+    { name: 'minut', min: 0, max: 59 },
+    { name: 'ora', min: 0, max: 23 },
+    { name: 'zi din luna', min: 1, max: 31 },
+    { name: 'luna', min: 1, max: 12, names: MONTHS, offset: 1 },
+    { name: 'zi din saptamana', min: 0, max: 7, names: DAYS, offset: 0 },
+];
+
+function parseValue(field, text) {
+    const index = field.names?.indexOf(text.toLowerCase()) ?? -1;
+    if (index >= 0) return index + field.offset;
+    if (!/^\d+$/.test(text)) {
+        throw new Error(`${field.name}: valoare invalida "${text}"`);
+    }
+    const value = Number(text);
+    if (value < field.min || value > field.max) {
+        throw new Error(`${field.name}: ${value} in afara intervalului ${field.min}-${field.max}`);
+    }
+    return value;
+}
+
+function validateField(field, spec) {
+    for (const part of spec.split(',')) {
+        const [range, step, ...extra] = part.split('/');
+        if (extra.length > 0 || (step !== undefined && !/^[1-9]\d*$/.test(step))) {
+            throw new Error(`${field.name}: pas invalid "${part}"`);
+        }
+        if (range === '*') continue;
+        const [lo, hi, ...rest] = range.split('-');
+        if (rest.length > 0) {
+            throw new Error(`${field.name}: interval invalid "${range}"`);
+        }
+        const from = parseValue(field, lo);
+        if (hi !== undefined && parseValue(field, hi) < from) {
+            throw new Error(`${field.name}: interval invers "${range}"`);
+        }
+    }
+}
+
+/**
+ * Intoarce mesajul de eroare al expresiei sau null daca e valida
+ */
+export function cronError(expr) {
+    if (typeof expr !== 'string' || !expr.trim()) {
+        return 'expresie cron lipsa';
+    }
+    const spec = MACROS[expr.trim().toLowerCase()] || expr.trim();
+    const fields = spec.split(/\s+/);
+    if (fields.length !== 5) {
+        return `expresie cron "${expr}": asteptat 5 campuri, gasit ${fields.length}`;
+    }
+    try {
+        fields.forEach((text, i) => validateField(FIELDS[i], text));
+    } catch (err) {
+        return `expresie cron "${expr}": ${err.message}`;
+    }
+    return null;
+}
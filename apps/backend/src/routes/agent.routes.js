@@ -1,5 +1,6 @@
 import express from 'express';
 import * as agentService from '../services/agent.service.js';
+import * as complianceService from '../services/compliance.service.js';
 import { agentLimiter } from '../middleware/rate-limit.middleware.js';
 import { body, validationResult } from 'express-validator';
 
@@ -101,6 +102,71 @@ router.post('/:serverId/audit/:auditRunId/results',
     }
 );
 
+/**
+ * @swagger
+ * /agent/{serverId}/audit/{auditRunId}/progress:
+ *   post:
+ *     tags: [Agent]
+ *     summary: Heartbeat progres rulare audit (raspunsul semnaleaza anularea)
+ */
+router.post('/:serverId/audit/:auditRunId/progress',
+    agentLimiter,
+    async (req, res, next) => {
+        try {
+            const agentToken = req.headers['x-agent-token'];
+            const result = await agentService.submitAuditProgress(
+                req.params.serverId,
+                req.params.auditRunId,
+                req.body,
+                agentToken
+            );
+            res.json(result);
+        } catch (error) {
+            next(error);
+        }
+    }
+);
+
+/**
+ * @swagger
+ * /agent/{serverId}/journal/anchor:
+ *   post:
+ *     tags: [Agent]
+ *     summary: Ancorare cap jurnal executii al agentului
+ */
+router.post('/:serverId/journal/anchor',
+    agentLimiter,
+    async (req, res, next) => {
+        try {
+            const agentToken = req.headers['x-agent-token'];
+            const result = await agentService.submitJournalAnchor(req.params.serverId, req.body, agentToken);
+            res.json(result);
+        } catch (error) {
+            next(error);
+        }
+    }
+);
+
+/**
+ * @swagger
+ * /agent/{serverId}/journal/alert:
+ *   post:
+ *     tags: [Agent]
+ *     summary: Semnalare jurnal executii invalid pe agent
+ */
+router.post('/:serverId/journal/alert',
+    agentLimiter,
+    async (req, res, next) => {
+        try {
+            const agentToken = req.headers['x-agent-token'];
+            const result = await agentService.submitJournalAlert(req.params.serverId, req.body, agentToken);
+            res.json(result);
+        } catch (error) {
+            next(error);
+        }
+    }
+);
+
 /**
  * @swagger
  * /agent/{serverId}/audit/pending:
@@ -120,4 +186,49 @@ router.get('/:serverId/audit/pending',
     }
 );
 
+/**
+ * @swagger
+ * /agent/{serverId}/assignments:
+ *   get:
+ *     tags: [Agent]
+ *     summary: Seturi semnate de verificari pentru evaluare continua
+ */
+router.get('/:serverId/assignments',
+    agentLimiter,
+    async (req, res, next) => {
+        try {
+            const agentToken = req.headers['x-agent-token'];
+            const assignments = await complianceService.getAgentAssignments(req.params.serverId, agentToken);
+            res.json(assignments);
+        } catch (error) {
+            next(error);
+        }
+    }
+);
+
+/**
+ * @swagger
+ * /agent/{serverId}/assignments/{assignmentId}/drift:
+ *   post:
+ *     tags: [Agent]
+ *     summary: Raportare verificari cu status schimbat la evaluarea continua
+ */
+router.post('/:serverId/assignments/:assignmentId/drift',
+    agentLimiter,
+    async (req, res, next) => {
+        try {
+            const agentToken = req.headers['x-agent-token'];
+            const result = await complianceService.submitDriftEvents(
+                req.params.serverId,
+                req.params.assignmentId,
+                req.body,
+                agentToken
+            );
+            res.json(result);
+        } catch (error) {
+            next(error);
+        }
+    }
+);
+
 export default router;
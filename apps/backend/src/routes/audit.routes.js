@@ -115,6 +115,28 @@ router.post('/:id/complete',
     }
 );
 
+/**
+ * @swagger
+ * /audit/{id}/cancel:
+ *   post:
+ *     tags: [Audit]
+ *     summary: Anulare audit (agentul opreste verificarile ramase)
+ *     security: [{ bearerAuth: [] }]
+ */
+router.post('/:id/cancel',
+    authenticate,
+    authorize('ADMIN', 'AUDITOR'),
+    auditLog('CANCEL_AUDIT', 'AUDIT'),
+    async (req, res, next) => {
+        try {
+            const result = await auditService.cancelAudit(req.params.id, req.user.id);
+            res.json(result);
+        } catch (error) {
+            next(error);
+        }
+    }
+);
+
 /**
  * @swagger
  * /audit/{runId}/manual/{taskId}/evidence:
@@ -1,5 +1,6 @@
 import express from 'express';
 import * as serversService from '../services/servers.service.js';
+import * as complianceService from '../services/compliance.service.js';
 import { authenticate, authorize } from '../middleware/auth.middleware.js';
 import { auditLog } from '../middleware/audit.middleware.js';
 import { body, validationResult } from 'express-validator';
@@ -338,4 +339,99 @@ router.post('/:id/run-check',
     }
 );
 
+/**
+ * @swagger
+ * /servers/{id}/assignments:
+ *   get:
+ *     tags: [Servers]
+ *     summary: Sabloane atribuite pentru evaluare continua, starea curenta si drift recent
+ *     security: [{ bearerAuth: [] }]
+ */
+router.get('/:id/assignments',
+    authenticate,
+    async (req, res, next) => {
+        try {
+            const posture = await complianceService.getPosture(req.params.id, req.query.limit);
+            res.json(posture);
+        } catch (error) {
+            next(error);
+        }
+    }
+);
+
+/**
+ * @swagger
+ * /servers/{id}/assignments:
+ *   post:
+ *     tags: [Servers]
+ *     summary: Atribuire sablon pentru evaluare continua (program cron)
+ *     security: [{ bearerAuth: [] }]
+ */
+router.post('/:id/assignments',
+    authenticate,
+    authorize('ADMIN', 'AUDITOR'),
+    auditLog('ASSIGN_TEMPLATE', 'SERVER'),
+    [
+        body('templateId').notEmpty().withMessage('Sablon obligatoriu'),
+        body('schedule').notEmpty().withMessage('Program obligatoriu'),
+        body('excludedControlIds').optional().isArray(),
+    ],
+    async (req, res, next) => {
+        try {
+            const errors = validationResult(req);
+            if (!errors.isEmpty()) {
+                return res.status(400).json({ errors: errors.array() });
+            }
+            const assignment = await complianceService.createAssignment(req.params.id, req.body, req.user.id);
+            res.status(201).json(assignment);
+        } catch (error) {
+            next(error);
+        }
+    }
+);
+
+/**
+ * @swagger
+ * /servers/{id}/assignments/{assignmentId}:
+ *   patch:
+ *     tags: [Servers]
+ *     summary: Modificare program sau controale excluse ale unei atribuiri
+ *     security: [{ bearerAuth: [] }]
+ */
+router.patch('/:id/assignments/:assignmentId',
+    authenticate,
+    authorize('ADMIN', 'AUDITOR'),
+    auditLog('UPDATE_ASSIGNMENT', 'SERVER'),
+    async (req, res, next) => {
+        try {
+            const assignment = await complianceService.updateAssignment(req.params.id, req.params.assignmentId, req.body);
+            res.json(assignment);
+        } catch (error) {
+            next(error);
+        }
+    }
+);
+
+/**
+ * @swagger
+ * /servers/{id}/assignments/{assignmentId}:
+ *   delete:
+ *     tags: [Servers]
+ *     summary: Oprire evaluare continua pentru un sablon atribuit
+ *     security: [{ bearerAuth: [] }]
+ */
+router.delete('/:id/assignments/:assignmentId',
+    authenticate,
+    authorize('ADMIN', 'AUDITOR'),
+    auditLog('UNASSIGN_TEMPLATE', 'SERVER'),
+    async (req, res, next) => {
+        try {
+            const result = await complianceService.deleteAssignment(req.params.id, req.params.assignmentId);
+            res.json(result);
+        } catch (error) {
+            next(error);
+        }
+    }
+);
+
 export default router;
@@ -296,6 +296,129 @@ async function submitInventory(serverId, data, agentToken) {
     return { message: 'Inventory salvat' };
 }
 
+/**
+ * Ancora jurnal executii: capul lantului hash-chained de pe agent.
+ * O ancora cu seq mai mic decat precedenta indica un jurnal resetat sau
+ * inlocuit pe agent; e pastrata, dar semnalata.
+ */
+async function submitJournalAnchor(serverId, data, agentToken) {
+    const agentIdentity = await verifyAgentToken(serverId, agentToken);
+
+    if (!Number.isInteger(data.seq) || data.seq < 1 || !data.hash || !data.signature || !data.time) {
+        throw new BadRequestError('Ancora jurnal invalida');
+    }
+    if (data.signatureAlg !== pkiService.RESULT_SIGNATURE_ALG) {
+        throw new BadRequestError(`Algoritm semnatura nesuportat: ${data.signatureAlg}`);
+    }
+
+    const verified = !!agentIdentity.publicKey && pkiService.verifyAgentSignature(
+        pkiService.anchorSignaturePayload({ serverId, anchor: data }),
+        data.signature,
+        agentIdentity.publicKey
+    );
+    if (!verified) {
+        console.warn(`[SECURITY] Journal anchor signature verification failed for server ${serverId}`);
+    }
+
+    const previous = await prisma.journalAnchor.findFirst({
+        where: { serverId },
+        orderBy: { receivedAt: 'desc' },
+    });
+    if (previous && data.seq < previous.seq) {
+        console.warn(`[SECURITY] Journal anchor for server ${serverId} went back from #${previous.seq} to #${data.seq}`);
+    }
+
+    await prisma.journalAnchor.create({
+        data: {
+            serverId,
+            seq: data.seq,
+            hash: data.hash,
+            agentTime: new Date(data.time),
+            signature: data.signature,
+            verified,
+        },
+    });
+
+    log.agent(serverId, 'journal', `anchor #${data.seq}`);
+    return { message: 'Ancora salvata', verified };
+}
+
+/**
+ * Alerta jurnal executii: jurnalul local al agentului nu mai poate fi
+ * deschis (lant hash invalid, fisier ilizibil). Agentul refuza verificarile
+ * pana la interventia unui operator; alerta e pastrata in auditLog.
+ */
+async function submitJournalAlert(serverId, data, agentToken) {
+    await verifyAgentToken(serverId, agentToken);
+
+    if (!data.reason || !data.detail) {
+        throw new BadRequestError('Alerta jurnal invalida');
+    }
+
+    console.warn(`[SECURITY] Execution journal on server ${serverId} is invalid: ${data.detail}`);
+    await prisma.auditLog.create({
+        data: {
+            action: 'JOURNAL_INVALID',
+            resource: 'SERVER',
+            resourceId: serverId,
+            newValue: {
+                reason: String(data.reason),
+                detail: String(data.detail),
+                agentTime: data.time || null,
+            },
+        },
+    });
+
+    log.agent(serverId, 'journal', `alert ${data.reason}`);
+    return { message: 'Alerta salvata' };
+}
+
+const PROGRESS_STATES = ['started', 'running', 'finished'];
+
+/**
+ * Heartbeat progres rulare audit. Raspunsul poarta semnalul de anulare:
+ * agentul opreste verificarile ramase daca rularea nu mai e activa.
+ */
+async function submitAuditProgress(serverId, auditRunId, data, agentToken) {
+    await verifyAgentToken(serverId, agentToken);
+
+    if (!PROGRESS_STATES.includes(data.state)) {
+        throw new BadRequestError(`Stare progres invalida: ${data.state}`);
+    }
+
+    const auditRun = await prisma.auditRun.findUnique({
+        where: { id: auditRunId },
+        select: { id: true, serverId: true, status: true },
+    });
+    if (!auditRun || auditRun.serverId !== serverId) {
+        throw new BadRequestError('Audit run invalid');
+    }
+    if (auditRun.status !== 'RUNNING') {
+        return { cancel: true, status: auditRun.status };
+    }
+
+    const agentProgress = {
+        state: data.state,
+        total: Number(data.total) || 0,
+        completed: Number(data.completed) || 0,
+        running: Array.isArray(data.running) ? data.running.slice(0, 50) : [],
+        time: data.time,
+    };
+    await prisma.auditRun.update({
+        where: { id: auditRunId },
+        data: { lastHeartbeatAt: new Date(), agentProgress },
+    });
+
+    if (io) {
+        io.of('/ws/audit').to(`audit:${auditRunId}`).emit('agentProgress', {
+            auditRunId,
+            ...agentProgress,
+        });
+    }
+
+    return { cancel: false, status: auditRun.status };
+}
+
 /**
  * Procesare rezultate verificari trimise de agent.
  */
@@ -332,24 +455,54 @@ async function submitCheckResults(serverId, auditRunId, data, agentToken) {
             throw new BadRequestError('Audit run invalid');
         }
 
+        // Rulare inchisa: rezultatele sunt finale, confirmam retrimiterea
+        // agentului fara modificari ca registrul lui sa se goleasca
+        if (auditRun.status !== 'RUNNING') {
+            return {
+                message: 'Audit inchis, rezultate ignorate',
+                accepted: data.results.map(result => result.automatedCheckId),
+            };
+        }
+
         const auditService = await import('./audit.service.js');
+        const automatedChecksById = new Map(
+            auditRun.templateVersion.controls
+                .flatMap(control => control.automatedChecks)
+                .map(check => [check.id, check])
+        );
+
+        // Confirmare per rezultat: agentul retrimite din registru doar ce lipseste
+        const accepted = [];
 
         // Procesam fiecare rezultat primit
         for (const result of data.results) {
             let status = result.status;
-            if (status === 'SKIPPED') status = 'NA';
+            if (status === 'SKIPPED' || status === 'NOT_APPLICABLE') status = 'NA';
 
             // verificare semnatura
             let verified = false;
-            if (result.signature && agentIdentity.publicKey) {
-                // verificam semnatura pe payload (outputHash + exitCode + etc)
-                // protocol sincronizat cu agentul
-                const payloadToVerify = `${result.outputHash}${result.status}${result.execTimestamp}`;
-                verified = pkiService.verifyAgentSignature(payloadToVerify, result.signature, agentIdentity.publicKey);
+            const automatedCheck = automatedChecksById.get(result.automatedCheckId);
+            if (result.signature && agentIdentity.publicKey && (
+                result.signatureAlg !== pkiService.RESULT_SIGNATURE_ALG ||
+                result.signatureVersion !== pkiService.RESULT_SIGNATURE_VERSION
+            )) {
+                // agent vechi (PKCS1v15 pe outputHash + status + timestamp): neverificat
+                console.warn(`[SECURITY] Unsupported result signature ${result.signatureAlg || 'legacy'} for check ${result.automatedCheckId} on server ${serverId}`);
+            } else if (result.signature && agentIdentity.publicKey) {
+                // structura canonica legata de server, rulare si verificare
+                const payloadToVerify = pkiService.resultSignaturePayload({
+                    serverId,
+                    auditRunId,
+                    checkId: automatedCheck?.checkId,
+                    result,
+                });
+                verified = !!automatedCheck &&
+                    pkiService.verifyAgentSignature(payloadToVerify, result.signature, agentIdentity.publicKey);
                 if (!verified) {
                     console.warn(`[SECURITY] Signature verification failed for check ${result.automatedCheckId} on server ${serverId}`);
                     status = 'ERROR';
                     result.errorMessage = 'Semnatura invalida - rezultat neacceptat';
+                    result.reasonCode = 'SIGNATURE_INVALID';
                 }
             }
 
@@ -368,26 +521,32 @@ async function submitCheckResults(serverId, auditRunId, data, agentToken) {
                         output: result.output,
                         outputHash: result.outputHash,
                         errorMessage: result.errorMessage,
+                        reasonCode: result.reasonCode,
                         // lant de custodie
                         execTimestamp: result.execTimestamp ? new Date(result.execTimestamp) : new Date(),
                         execHostname: result.execHostname,
                         execUser: result.execUser,
                         exitCode: result.exitCode,
                         signature: result.signature,
-                        verified: verified
+                        signatureAlg: result.signatureAlg,
+                        verified: verified,
+                        waiver: waiverMetadata(result.waiver),
                     },
                     update: {
                         status: status,
                         output: result.output,
                         outputHash: result.outputHash,
                         errorMessage: result.errorMessage,
+                        reasonCode: result.reasonCode,
                         // Chain of Custody
                         execTimestamp: result.execTimestamp ? new Date(result.execTimestamp) : new Date(),
                         execHostname: result.execHostname,
                         execUser: result.execUser,
                         exitCode: result.exitCode,
                         signature: result.signature,
-                        verified: verified
+                        signatureAlg: result.signatureAlg,
+                        verified: verified,
+                        waiver: waiverMetadata(result.waiver),
                     },
                 });
 
@@ -401,6 +560,7 @@ async function submitCheckResults(serverId, auditRunId, data, agentToken) {
                         verified
                     });
                 }
+                accepted.push(result.automatedCheckId);
             } catch (err) {
                 console.error(`Error processing result for check ${result.automatedCheckId}:`, err.message);
             }
@@ -426,7 +586,7 @@ async function submitCheckResults(serverId, auditRunId, data, agentToken) {
             console.error(`Failed to trigger completion for audit ${auditRunId}:`, err);
         }
 
-        return { message: 'rezultate salvate' };
+        return { message: 'rezultate salvate', accepted };
     } catch (error) {
         console.error('Critical error in submitCheckResults:', error);
         throw error;
@@ -465,45 +625,27 @@ async function getPendingAuditChecks(serverId, agentToken) {
                 control.automatedChecks
                     .filter(check => !completedCheckIds.has(check.id))
                     .map(check => {
-                        // Semnare: command + checkId (sincronizat cu agentul)
-                        const signature = pkiService.signCommand((check.command || '') + check.checkId);
-                        return {
+                        // Semnare: payload canonic complet (sincronizat cu agentul)
+                        return pkiService.signCheckPayload({
                             auditRunId: run.id,
                             automatedCheckId: check.id,
                             checkId: check.checkId,
-                            title: check.title,
-                            command: check.command,
-                            signature: signature, // Send signature
-                            script: check.script,
-                            expectedResult: check.expectedResult,
-                            checkType: check.checkType || 'COMMAND',
-                            comparison: check.comparison,
-                            parser: check.parser,
-                            normalize: check.normalize,
-                            onFailMessage: check.onFailMessage,
-                            platformScope: check.platformScope
-                        };
+                            controlId: control.controlId,
+                            ...pkiService.checkExecutionFields(check),
+                        }, serverId);
                     })
             );
     });
 
     // 2. injectie verificari ad-hoc
     const adhocChecks = adhocQueue.get(serverId) || [];
-    const mappedAdhoc = adhocChecks.map(c => ({
+    const mappedAdhoc = adhocChecks.map(c => pkiService.signCheckPayload({
         auditRunId: 'ADHOC',
         automatedCheckId: c.id,
         checkId: c.id,
+        ...pkiService.checkExecutionFields(c),
         title: 'Adhoc Check',
-        command: c.command,
-        script: c.script,
-        expectedResult: c.expectedResult,
-        checkType: c.checkType || 'COMMAND',
-        comparison: c.comparison,
-        parser: c.parser,
-        normalize: c.normalize,
-        onFailMessage: c.onFailMessage,
-        platformScope: c.platformScope
-    }));
+    }, serverId));
 
     if (mappedAdhoc.length > 0) {
         console.log(`Injecting ${mappedAdhoc.length} adhoc checks for server ${serverId}`);
@@ -540,12 +682,11 @@ async function runAdhocCheck(serverId, checkData) {
             resolve(result);
         });
 
-        // adaugare in coada
+        // adaugare in coada (semnata la preluarea de catre agent)
         const queue = adhocQueue.get(serverId) || [];
         queue.push({
             id: checkId,
             ...checkData,
-            signature: pkiService.signCommand((checkData.command || '') + checkId) // sincronizat cu agentul
         });
         adhocQueue.set(serverId, queue);
 
@@ -553,6 +694,24 @@ async function runAdhocCheck(serverId, checkData) {
     });
 }
 
+/**
+ * Metadatele exceptiei (waiver) raportate de agent cu rezultatul. Sunt
+ * acoperite de semnatura rezultatului (v3); se pastreaza doar campurile
+ * semnate.
+ */
+function waiverMetadata(waiver) {
+    if (!waiver || typeof waiver !== 'object') return undefined;
+    const text = (value) => String(value ?? '').substring(0, 1000);
+    return {
+        id: text(waiver.id),
+        justification: text(waiver.justification),
+        approver: text(waiver.approver),
+        expiresAt: text(waiver.expiresAt),
+        evaluatedStatus: text(waiver.evaluatedStatus),
+        expired: waiver.expired === true,
+    };
+}
+
 async function verifyAgentToken(serverId, agentToken) {
     if (!agentToken) {
         throw new UnauthorizedError('Agent token lipsa');
@@ -578,7 +737,11 @@ export {
     submitMetrics,
     submitInventory,
     submitCheckResults,
+    submitAuditProgress,
+    submitJournalAnchor,
+    submitJournalAlert,
     getPendingAuditChecks,
     runAdhocCheck,
     verifyAgentToken,
+    waiverMetadata,
 };
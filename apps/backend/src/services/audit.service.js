@@ -232,9 +232,49 @@ async function getProgress(id) {
         overallStatus: auditRun.overallStatus,
         automatedCompliancePercent: auditRun.automatedCompliancePercent,
         manualCompletionPercent: auditRun.manualCompletionPercent,
+        // progres raportat de agent (heartbeat)
+        agentProgress: auditRun.agentProgress,
+        lastHeartbeatAt: auditRun.lastHeartbeatAt,
     };
 }
 
+/**
+ * Anulare rulare audit. Agentul afla la urmatorul heartbeat, opreste
+ * verificarile ramase si pe cele in executie.
+ */
+async function cancelAudit(id, userId) {
+    const auditRun = await prisma.auditRun.findUnique({ where: { id } });
+
+    if (!auditRun) {
+        throw new NotFoundError('Audit run nu exista');
+    }
+    if (auditRun.status !== 'RUNNING' && auditRun.status !== 'PENDING') {
+        throw new BadRequestError(`Auditul nu poate fi anulat (status ${auditRun.status})`);
+    }
+
+    const now = new Date();
+    const updated = await prisma.auditRun.update({
+        where: { id },
+        data: {
+            status: 'CANCELLED',
+            completedAt: now,
+            cancelledAt: now,
+            cancelledBy: userId,
+        },
+    });
+
+    if (io) {
+        io.of('/ws/audit').to(`audit:${id}`).emit('progress', {
+            auditRunId: id,
+            status: 'CANCELLED',
+            message: 'Audit anulat, agentul opreste verificarile',
+        });
+    }
+    notificationService.broadcastAuditStatus(id, 'CANCELLED', auditRun.serverId);
+
+    return { auditRun: updated, message: 'Audit anulat' };
+}
+
 async function submitEvidence(auditRunId, taskId, data, file) {
     const task = await prisma.manualTaskResult.findUnique({
         where: { id: taskId },
@@ -422,7 +462,14 @@ async function cleanupStaleAudits() {
         where: {
             status: 'RUNNING',
             OR: [
-                { startedAt: { lt: timeoutThreshold } }, // Rulat de prea mult timp
+                {
+                    // Rulat de prea mult timp, fara heartbeat recent de la agent
+                    startedAt: { lt: timeoutThreshold },
+                    OR: [
+                        { lastHeartbeatAt: null },
+                        { lastHeartbeatAt: { lt: timeoutThreshold } },
+                    ],
+                },
                 { server: { agentIdentity: { lastSeen: { lt: offlineThreshold } } } } // Server offline
             ]
         },
@@ -454,7 +501,7 @@ async function cleanupStaleAudits() {
         console.log(`[CLEANUP] Audit ${audit.id.substring(0, 8)} marcat FAILED (agent offline/timeout)`);
     }
 
-    return staleAudits.length;
+    return stuckAudits.length;
 }
 
 export {
@@ -467,6 +514,7 @@ export {
     approveTask,
     resetTask,
     completeAudit,
+    cancelAudit,
     updateAuditScoring,
     cleanupStaleAudits,
 };
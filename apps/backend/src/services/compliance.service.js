@@ -0,0 +1,311 @@
+import { prisma } from '../lib/prisma.js';
+import { NotFoundError, BadRequestError, ConflictError } from '../middleware/error.middleware.js';
+import { log } from '../lib/logger.js';
+import { cronError } from '../lib/cron.js';
+import * as notificationService from './notification.service.js';
+import * as pkiService from './pki.service.js';
+import * as templatesService from './templates.service.js';
+import { verifyAgentToken, waiverMetadata } from './agent.service.js';
+
+// Rularea sub care agentul semneaza rezultatele unei atribuiri (sincronizat
+// cu collector.ContinuousRunID din agentul Go)
+const continuousRunId = (assignmentId) => `continuous:${assignmentId}`;
+
+// Evenimente pastrate per raport; agentul trimite doar schimbarile, deci
+// un raport mai mare indica o evaluare de baza a unui sablon foarte mare
+const MAX_DRIFT_EVENTS = 2000;
+
+const mapStatus = (status) => (status === 'SKIPPED' || status === 'NOT_APPLICABLE' ? 'NA' : status);
+
+/**
+ * Atribuirile de evaluare continua ale serverului, cu sablonul atribuit
+ */
+async function findAssignments(serverId) {
+    return prisma.complianceAssignment.findMany({
+        where: { serverId },
+        include: {
+            templateVersion: {
+                select: { id: true, version: true, template: { select: { id: true, name: true } } },
+            },
+        },
+        orderBy: { createdAt: 'asc' },
+    });
+}
+
+/**
+ * Atribuire sablon (ultima versiune activa) pentru evaluare continua.
+ * Agentul preia setul semnat la urmatoarea sincronizare.
+ */
+async function createAssignment(serverId, data, userId) {
+    const { templateId, schedule, excludedControlIds } = data;
+
+    const scheduleError = cronError(schedule);
+    if (scheduleError) {
+        throw new BadRequestError(scheduleError);
+    }
+    const server = await prisma.server.findUnique({ where: { id: serverId } });
+    if (!server) {
+        throw new NotFoundError('Server nu exista');
+    }
+
+    const templateVersion = await templatesService.getActiveVersion(templateId);
+    const existing = await prisma.complianceAssignment.findUnique({
+        where: { serverId_templateVersionId: { serverId, templateVersionId: templateVersion.id } },
+    });
+    if (existing) {
+        throw new ConflictError('Sablonul e deja atribuit serverului');
+    }
+
+    const assignment = await prisma.complianceAssignment.create({
+        data: {
+            serverId,
+            templateVersionId: templateVersion.id,
+            schedule: schedule.trim(),
+            excludedControlIds: excludedControlIds || [],
+            createdBy: userId,
+        },
+    });
+    log.info(`Continuous assignment ${assignment.id}: template v${templateVersion.version} on server ${serverId} (${assignment.schedule})`);
+    return assignment;
+}
+
+/**
+ * Modificare program sau controale excluse; setul semnat primeste un
+ * issuedAt nou si agentul il reevalueaza imediat
+ */
+async function updateAssignment(serverId, assignmentId, data) {
+    const assignment = await prisma.complianceAssignment.findUnique({ where: { id: assignmentId } });
+    if (!assignment || assignment.serverId !== serverId) {
+        throw new NotFoundError('Atribuire inexistenta');
+    }
+
+    const update = {};
+    if (data.schedule !== undefined) {
+        const scheduleError = cronError(data.schedule);
+        if (scheduleError) {
+            throw new BadRequestError(scheduleError);
+        }
+        update.schedule = data.schedule.trim();
+    }
+    if (data.excludedControlIds !== undefined) {
+        if (!Array.isArray(data.excludedControlIds)) {
+            throw new BadRequestError('excludedControlIds trebuie sa fie o lista');
+        }
+        update.excludedControlIds = data.excludedControlIds;
+    }
+
+    return prisma.complianceAssignment.update({ where: { id: assignmentId }, data: update });
+}
+
+/**
+ * Stergere atribuire; agentul renunta la set la urmatoarea sincronizare
+ */
+async function deleteAssignment(serverId, assignmentId) {
+    const assignment = await prisma.complianceAssignment.findUnique({ where: { id: assignmentId } });
+    if (!assignment || assignment.serverId !== serverId) {
+        throw new NotFoundError('Atribuire inexistenta');
+    }
+    await prisma.complianceAssignment.delete({ where: { id: assignmentId } });
+    return { message: 'Atribuire stearsa' };
+}
+
+/**
+ * Starea curenta a fiecarei verificari atribuite (ultimul eveniment de
+ * drift) si evenimentele recente ale serverului
+ */
+async function getPosture(serverId, limit = 100) {
+    const assignments = await findAssignments(serverId);
+
+    const posture = [];
+    for (const assignment of assignments) {
+        const latest = await prisma.driftEvent.findMany({
+            where: { assignmentId: assignment.id },
+            distinct: ['automatedCheckId'],
+            orderBy: [{ automatedCheckId: 'asc' }, { evaluatedAt: 'desc' }, { receivedAt: 'desc' }],
+            include: { automatedCheck: { select: { checkId: true, title: true } } },
+        });
+        const counts = {};
+        for (const event of latest) {
+            counts[event.status] = (counts[event.status] || 0) + 1;
+        }
+        posture.push({ ...assignment, counts, checks: latest });
+    }
+
+    const events = await prisma.driftEvent.findMany({
+        where: { assignment: { serverId } },
+        orderBy: [{ evaluatedAt: 'desc' }, { receivedAt: 'desc' }],
+        take: Math.min(Number(limit) || 100, 500),
+        include: { automatedCheck: { select: { checkId: true, title: true } } },
+    });
+
+    return { assignments: posture, events };
+}
+
+/**
+ * Seturile semnate atribuite serverului, preluate periodic de agent.
+ * issuedAt = ultima modificare a atribuirii: acelasi set are aceeasi
+ * semnatura la fiecare interogare, iar agentul il reevalueaza doar dupa
+ * program.
+ */
+async function getAgentAssignments(serverId, agentToken) {
+    await verifyAgentToken(serverId, agentToken);
+
+    const assignments = await prisma.complianceAssignment.findMany({
+        where: { serverId },
+        include: {
+            templateVersion: {
+                include: {
+                    template: { select: { name: true } },
+                    controls: { include: { automatedChecks: true } },
+                },
+            },
+        },
+    });
+
+    return assignments.map(assignment => {
+        const { templateVersion } = assignment;
+        const checks = templateVersion.controls
+            .filter(c => !assignment.excludedControlIds.includes(c.controlId))
+            .flatMap(control => control.automatedChecks.map(check => ({
+                automatedCheckId: check.id,
+                checkId: check.checkId,
+                controlId: control.controlId,
+                ...pkiService.checkExecutionFields(check),
+            })));
+
+        return pkiService.signAssignmentPayload({
+            assignmentId: assignment.id,
+            template: `${templateVersion.template.name} v${templateVersion.version}`,
+            schedule: assignment.schedule,
+            checks,
+        }, serverId, Math.floor(assignment.updatedAt.getTime() / 1000));
+    });
+}
+
+/**
+ * Raport de drift de la agent: verificarile al caror status s-a schimbat
+ * fata de evaluarea precedenta (toate, la prima evaluare). Semnatura
+ * fiecarui rezultat e legata de rularea continuous:<assignmentId>.
+ */
+async function submitDriftEvents(serverId, assignmentId, data, agentToken) {
+    const agentIdentity = await verifyAgentToken(serverId, agentToken);
+
+    const assignment = await prisma.complianceAssignment.findUnique({
+        where: { id: assignmentId },
+        include: {
+            templateVersion: {
+                include: { controls: { include: { automatedChecks: true } } },
+            },
+        },
+    });
+    if (!assignment || assignment.serverId !== serverId) {
+        throw new NotFoundError('Atribuire inexistenta');
+    }
+
+    const events = Array.isArray(data.events) ? data.events : [];
+    const evaluatedAt = new Date(data.evaluatedAt);
+    if (Number.isNaN(evaluatedAt.getTime()) || events.length > MAX_DRIFT_EVENTS) {
+        throw new BadRequestError('Raport drift invalid');
+    }
+
+    const automatedChecksById = new Map(
+        assignment.templateVersion.controls
+            .flatMap(control => control.automatedChecks)
+            .map(check => [check.id, check])
+    );
+    const auditRunId = continuousRunId(assignmentId);
+
+    const saved = [];
+    for (const event of events) {
+        const result = event.result || {};
+        const automatedCheck = automatedChecksById.get(result.automatedCheckId);
+        if (!automatedCheck) {
+            console.warn(`[SECURITY] Drift event for unknown check ${result.automatedCheckId} on server ${serverId}`);
+            continue;
+        }
+
+        let status = mapStatus(result.status);
+        let verified = false;
+        if (result.signature && agentIdentity.publicKey) {
+            verified = result.signatureAlg === pkiService.RESULT_SIGNATURE_ALG &&
+                result.signatureVersion === pkiService.RESULT_SIGNATURE_VERSION &&
+                pkiService.verifyAgentSignature(
+                    pkiService.resultSignaturePayload({ serverId, auditRunId, checkId: automatedCheck.checkId, result }),
+                    result.signature,
+                    agentIdentity.publicKey
+                );
+            if (!verified) {
+                console.warn(`[SECURITY] Signature verification failed for drift of check ${result.automatedCheckId} on server ${serverId}`);
+                status = 'ERROR';
+                result.errorMessage = 'Semnatura invalida - rezultat neacceptat';
+                result.reasonCode = 'SIGNATURE_INVALID';
+            }
+        }
+
+        await prisma.driftEvent.create({
+            data: {
+                assignmentId,
+                automatedCheckId: automatedCheck.id,
+                previousStatus: event.previousStatus ? mapStatus(event.previousStatus) : null,
+                status,
+                output: result.output,
+                errorMessage: result.errorMessage,
+                reasonCode: result.reasonCode,
+                evaluatedAt,
+                // lant de custodie
+                outputHash: result.outputHash,
+                execTimestamp: result.execTimestamp ? new Date(result.execTimestamp) : null,
+                execHostname: result.execHostname,
+                execUser: result.execUser,
+                exitCode: result.exitCode,
+                signature: result.signature,
+                signatureAlg: result.signatureAlg,
+                verified,
+                waiver: waiverMetadata(result.waiver),
+            },
+        });
+        saved.push({
+            checkId: automatedCheck.checkId,
+            previousStatus: event.previousStatus ? mapStatus(event.previousStatus) : null,
+            status,
+        });
+    }
+
+    // Rapoartele amanate (agent offline) pot sosi dupa unele mai noi
+    if (!assignment.lastEvaluatedAt || evaluatedAt > assignment.lastEvaluatedAt) {
+        await prisma.complianceAssignment.update({
+            where: { id: assignmentId },
+            data: { lastEvaluatedAt: evaluatedAt, lastEvaluatedCount: data.evaluated ?? null },
+        });
+    }
+
+    if (saved.length > 0) {
+        notificationService.broadcastDrift(serverId, assignmentId, saved);
+        // O exceptie (WAIVED) e risc acceptat; expirarea ei cu verificarea
+        // neconforma e tot o regresie
+        const accepted = ['PASS', 'WAIVED'];
+        const regressions = saved.filter(e => accepted.includes(e.previousStatus) && !accepted.includes(e.status));
+        if (regressions.length > 0) {
+            notificationService.notify({
+                scope: 'org',
+                type: notificationService.NotificationType.COMPLIANCE_DRIFT,
+                title: 'Compliance drift',
+                body: `${regressions.length} verificari nu mai trec: ${regressions.map(e => e.checkId).join(', ')}`,
+                link: `/servers/${serverId}`,
+            });
+        }
+    }
+
+    log.agent(serverId, 'drift', `${saved.length}/${data.evaluated ?? '?'} changed (${assignmentId.substring(0, 8)})`);
+    return { message: 'Drift salvat', saved: saved.length };
+}
+
+export {
+    findAssignments,
+    createAssignment,
+    updateAssignment,
+    deleteAssignment,
+    getPosture,
+    getAgentAssignments,
+    submitDriftEvents,
+};
@@ -17,6 +17,7 @@ const NotificationType = {
     EVIDENCE_APPROVED: 'evidence:approved',
     EVIDENCE_REJECTED: 'evidence:rejected',
     SERVER_ALERT: 'server:alert',
+    COMPLIANCE_DRIFT: 'compliance:drift',
 };
 
 // Difuzare notificare catre toti utilizatorii conectati
@@ -100,6 +101,18 @@ function broadcastAuditStatus(auditRunId, status, serverId) {
     log.ws('/audit', 'status', `${auditRunId.substring(0, 8)} -> ${status}`);
 }
 
+// Difuzare schimbari de status din evaluarea continua a unui server
+function broadcastDrift(serverId, assignmentId, events) {
+    if (!io) return;
+
+    io.of('/ws/live').to(`server:${serverId}`).emit('server:drift', {
+        serverId,
+        assignmentId,
+        events,
+        timestamp: new Date().toISOString(),
+    });
+}
+
 export {
     setIO,
     notify,
@@ -108,5 +121,6 @@ export {
     broadcastServerAlert,
     broadcastHeartbeat,
     broadcastAuditStatus,
+    broadcastDrift,
     NotificationType,
 };
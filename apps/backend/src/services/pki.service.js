@@ -145,16 +145,155 @@ export function signCommand(data) {
     return sign.sign(keyPem, 'base64');
 }
 
+// Valabilitatea unui payload de verificare; agentul il preia din nou la
+// fiecare interogare, deci fereastra poate fi scurta
+const CHECK_PAYLOAD_TTL_SECONDS = 15 * 60;
+
+// Serializare canonica JSON: chei sortate recursiv, campurile undefined omise;
+// U+2028/U+2029 sunt escapate ca in encoding/json din Go (agentul)
+function canonicalJson(value) {
+    if (Array.isArray(value)) {
+        return `[${value.map(v => canonicalJson(v === undefined ? null : v)).join(',')}]`;
+    }
+    if (value && typeof value === 'object') {
+        const keys = Object.keys(value).filter(k => value[k] !== undefined).sort();
+        return `{${keys.map(k => `${JSON.stringify(k)}:${canonicalJson(value[k])}`).join(',')}}`;
+    }
+    return JSON.stringify(value ?? null)
+        .replace(/\u2028/g, '\\u2028')
+        .replace(/\u2029/g, '\\u2029');
+}
+
+/**
+ * Campurile de executie ale unei verificari (rand AutomatedCheck sau
+ * verificare ad-hoc) trimise agentului. Agentul executa doar campurile din
+ * payload-ul semnat, deci un camp care lipseste aici nu ajunge la agent.
+ */
+export function checkExecutionFields(check) {
+    return {
+        title: check.title,
+        command: check.command,
+        script: check.script,
+        expectedResult: check.expectedResult,
+        warnResult: check.warnResult,
+        checkType: check.checkType || 'COMMAND',
+        comparison: check.comparison,
+        parser: check.parser,
+        normalize: check.normalize,
+        onFailMessage: check.onFailMessage,
+        platformScope: check.platformScope,
+        timeoutSeconds: check.timeoutSeconds,
+        serial: check.serial,
+        runAs: check.runAs,
+        env: check.env,
+        interpreter: check.interpreter,
+        scriptSha256: check.scriptSha256,
+        args: check.args,
+        requires: check.requires,
+    };
+}
+
+/**
+ * Semneaza verificarea completa pentru agent: payload = JSON canonic al
+ * tuturor campurilor + serverId, issuedAt/expiresAt (secunde unix) si nonce.
+ * Agentul verifica semnatura pe octetii payload-ului si executa doar
+ * campurile din payload; campurile din afara lui sunt informative.
+ */
+export function signCheckPayload(check, serverId) {
+    const issuedAt = Math.floor(Date.now() / 1000);
+    const body = {
+        ...check,
+        serverId,
+        issuedAt,
+        expiresAt: issuedAt + CHECK_PAYLOAD_TTL_SECONDS,
+        nonce: crypto.randomUUID(),
+    };
+    const payload = canonicalJson(body);
+    return {
+        ...body,
+        payload: Buffer.from(payload, 'utf8').toString('base64'),
+        signature: signCommand(payload),
+    };
+}
+
+/**
+ * Semneaza setul de verificari al unei atribuiri de evaluare continua.
+ * Agentul il pastreaza si il reevalueaza repetat, deci nu are expiresAt
+ * sau nonce; issuedAt (ultima modificare a atribuirii) e stabil intre
+ * interogari, iar agentul refuza un set mai vechi decat cel din cache.
+ */
+export function signAssignmentPayload(set, serverId, issuedAt) {
+    const payload = canonicalJson({ ...set, serverId, issuedAt });
+    return {
+        assignmentId: set.assignmentId,
+        payload: Buffer.from(payload, 'utf8').toString('base64'),
+        signature: signCommand(payload),
+    };
+}
+
+// Semnaturile de rezultat acceptate (sincronizat cu agentul Go)
+export const RESULT_SIGNATURE_ALG = 'RSA-PSS-SHA256';
+export const RESULT_SIGNATURE_VERSION = 3;
+
+/**
+ * Structura canonica semnata de agent pentru un rezultat. Contextul
+ * (serverId, auditRunId, checkId) vine din backend, nu din rezultat, ca o
+ * semnatura sa nu poata fi mutata pe alt server, rulare sau verificare.
+ * Exceptia (waiver) aplicata e semnata cu rezultatul, ca aprobatorul,
+ * expirarea sau statusul evaluat sa nu poata fi schimbate pe drum.
+ */
+export function resultSignaturePayload({ serverId, auditRunId, checkId, result }) {
+    const waiver = result.waiver && typeof result.waiver === 'object' ? result.waiver : null;
+    return canonicalJson({
+        alg: RESULT_SIGNATURE_ALG,
+        auditRunId,
+        automatedCheckId: result.automatedCheckId,
+        checkId,
+        exitCode: result.exitCode,
+        hostname: result.execHostname,
+        outputHash: result.outputHash,
+        reasonCode: result.reasonCode || '',
+        serverId,
+        status: result.status,
+        timestamp: result.execTimestamp,
+        user: result.execUser,
+        v: RESULT_SIGNATURE_VERSION,
+        waiver: waiver ? {
+            approver: waiver.approver ?? '',
+            evaluatedStatus: waiver.evaluatedStatus ?? '',
+            expired: waiver.expired === true,
+            expiresAt: waiver.expiresAt ?? '',
+            id: waiver.id ?? '',
+            justification: waiver.justification ?? '',
+        } : undefined,
+    });
+}
+
+/**
+ * Structura canonica semnata de agent pentru ancora jurnalului de executii
+ */
+export function anchorSignaturePayload({ serverId, anchor }) {
+    return canonicalJson({
+        alg: anchor.signatureAlg,
+        hash: anchor.hash,
+        seq: anchor.seq,
+        serverId,
+        time: anchor.time,
+        v: 1,
+    });
+}
+
 /**
- * Verifica semnatura de la agent (PKCS1v15 + SHA256)
- * Sincronizat cu agentul Go: rsa.SignPKCS1v15
+ * Verifica semnatura de la agent (RSA-PSS + SHA256, salt 32)
+ * Sincronizat cu agentul Go: rsa.SignPSS cu PSSSaltLengthEqualsHash
  */
 export function verifyAgentSignature(data, signatureBase64, publicKeyPem) {
     try {
-        const verify = crypto.createVerify('SHA256');
-        verify.update(data, 'utf8');
-        verify.end();
-        return verify.verify(publicKeyPem, signatureBase64, 'base64');
+        return crypto.verify('sha256', Buffer.from(data, 'utf8'), {
+            key: publicKeyPem,
+            padding: crypto.constants.RSA_PKCS1_PSS_PADDING,
+            saltLength: 32,
+        }, Buffer.from(signatureBase64, 'base64'));
     } catch (err) {
         console.error('Signature verification failed:', err);
         return false;
@@ -0,0 +1,131 @@
+import crypto from 'crypto';
+import fs from 'fs';
+import { checkExecutionFields, resultSignaturePayload, signCheckPayload } from './pki.service.js';
+
+// Rand AutomatedCheck cu toate campurile de executie setate
+const automatedCheck = {
+    id: 'ac-1',
+    checkId: 'SSH-1',
+    title: 'PermitRootLogin',
+    command: 'sshd -T | grep -i permitrootlogin',
+    script: null,
+    expectedResult: 'permitrootlogin no',
+    warnResult: null,
+    checkType: 'COMMAND',
+    comparison: 'CONTAINS',
+    parser: 'RAW',
+    normalize: ['TRIM'],
+    onFailMessage: 'Root login permis',
+    platformScope: ['ubuntu>=22.04'],
+    timeoutSeconds: 45,
+    serial: true,
+    runAs: 'root',
+    env: { CHECK_HOST: '{{hostname}}' },
+};
+
+function openPayload(signed) {
+    const payload = Buffer.from(signed.payload, 'base64').toString('utf8');
+    const publicKey = fs.readFileSync('certs/backend_pub.key', 'utf8');
+    const valid = crypto.verify('sha256', Buffer.from(payload, 'utf8'), publicKey,
+        Buffer.from(signed.signature, 'base64'));
+    return { valid, check: JSON.parse(payload) };
+}
+
+// Verificare SCRIPT cu amprenta fixata (scriptSha256)
+const scriptCheck = {
+    ...automatedCheck,
+    command: null,
+    script: 'print(open("/etc/hostname").read())',
+    checkType: 'SCRIPT',
+    interpreter: 'python3',
+    scriptSha256: crypto.createHash('sha256').update('print(open("/etc/hostname").read())').digest('hex'),
+    args: ['--quiet'],
+};
+
+describe('signCheckPayload', () => {
+    test('payload-ul semnat contine campurile de executie ale verificarii', () => {
+        const signed = signCheckPayload({
+            auditRunId: 'run-1',
+            automatedCheckId: automatedCheck.id,
+            checkId: automatedCheck.checkId,
+            ...checkExecutionFields(automatedCheck),
+        }, 'server-1');
+
+        const { valid, check } = openPayload(signed);
+        expect(valid).toBe(true);
+        expect(check.serverId).toBe('server-1');
+        expect(check.timeoutSeconds).toBe(45);
+        expect(check.serial).toBe(true);
+        expect(check.runAs).toBe('root');
+        expect(check.env).toEqual({ CHECK_HOST: '{{hostname}}' });
+        expect(check.platformScope).toEqual(['ubuntu>=22.04']);
+        expect(check.normalize).toEqual(['TRIM']);
+    });
+
+    test('scriptul, interpretorul, argumentele si amprenta sunt semnate', () => {
+        const signed = signCheckPayload(checkExecutionFields(scriptCheck), 'server-1');
+
+        const { valid, check } = openPayload(signed);
+        expect(valid).toBe(true);
+        expect(check.checkType).toBe('SCRIPT');
+        expect(check.script).toBe(scriptCheck.script);
+        expect(check.interpreter).toBe('python3');
+        expect(check.scriptSha256).toBe(scriptCheck.scriptSha256);
+        expect(check.args).toEqual(['--quiet']);
+    });
+
+    test('payload-ul modificat nu mai verifica semnatura', () => {
+        const signed = signCheckPayload(checkExecutionFields(automatedCheck), 'server-1');
+        const tampered = JSON.parse(Buffer.from(signed.payload, 'base64').toString('utf8'));
+        tampered.scriptSha256 = crypto.createHash('sha256').update('id').digest('hex');
+
+        const { valid } = openPayload({
+            ...signed,
+            payload: Buffer.from(JSON.stringify(tampered), 'utf8').toString('base64'),
+        });
+        expect(valid).toBe(false);
+    });
+});
+
+describe('resultSignaturePayload', () => {
+    const result = {
+        automatedCheckId: 'ac-1',
+        status: 'WAIVED',
+        outputHash: 'ab',
+        exitCode: 0,
+        execTimestamp: '2026-10-18T12:00:00Z',
+        execHostname: 'web1',
+        execUser: 'nobody',
+        waiver: {
+            id: 'w-1',
+            justification: 'sistem legacy',
+            approver: 'secops',
+            expiresAt: '2026-12-31T00:00:00Z',
+            evaluatedStatus: 'FAIL',
+        },
+    };
+
+    test('structura canonica e identica cu cea semnata de agent', () => {
+        // acelasi sir ca in resultsig_test.go (TestResultSignatureDataWaiver)
+        expect(resultSignaturePayload({ serverId: 'srv-1', auditRunId: 'run-1', checkId: 'c1', result })).toBe(
+            '{"alg":"RSA-PSS-SHA256","auditRunId":"run-1","automatedCheckId":"ac-1","checkId":"c1","exitCode":0,' +
+            '"hostname":"web1","outputHash":"ab","reasonCode":"","serverId":"srv-1","status":"WAIVED",' +
+            '"timestamp":"2026-10-18T12:00:00Z","user":"nobody","v":3,' +
+            '"waiver":{"approver":"secops","evaluatedStatus":"FAIL","expired":false,"expiresAt":"2026-12-31T00:00:00Z",' +
+            '"id":"w-1","justification":"sistem legacy"}}'
+        );
+    });
+
+    test('metadatele exceptiei sunt acoperite de semnatura', () => {
+        const base = resultSignaturePayload({ serverId: 'srv-1', auditRunId: 'run-1', checkId: 'c1', result });
+        for (const [field, value] of [['approver', 'intrus'], ['expiresAt', '2030-01-01T00:00:00Z'],
+            ['evaluatedStatus', 'PASS'], ['expired', true], ['id', 'w-2'], ['justification', 'altceva']]) {
+            const changed = { ...result, waiver: { ...result.waiver, [field]: value } };
+            expect(resultSignaturePayload({ serverId: 'srv-1', auditRunId: 'run-1', checkId: 'c1', result: changed }))
+                .not.toBe(base);
+        }
+        const { waiver, ...withoutWaiver } = result;
+        expect(resultSignaturePayload({ serverId: 'srv-1', auditRunId: 'run-1', checkId: 'c1', result: withoutWaiver }))
+            .not.toBe(base);
+    });
+});
@@ -26,9 +26,10 @@ async function calculateScoring(auditRunId) {
 
     const excludedIds = auditRun.excludedControlIds || [];
 
-    // Filtrare pentru controale active
+    // Filtrare pentru controale active (verificarile NA nu se aplica serverului,
+    // cele WAIVED sunt acoperite de o exceptie aprobata pe agent)
     const activeCheckResults = auditRun.checkResults.filter(
-        r => !excludedIds.includes(r.automatedCheck.control.controlId)
+        r => !excludedIds.includes(r.automatedCheck.control.controlId) && r.status !== 'NA' && r.status !== 'WAIVED'
     );
     const activeManualTasks = auditRun.manualTaskResults.filter(
         t => !excludedIds.includes(t.manualCheck.control.controlId)
@@ -36,8 +37,13 @@ async function calculateScoring(auditRunId) {
 
     // Calcul automat
     const totalAutomated = activeCheckResults.length;
-    const passedAutomated = activeCheckResults.filter(r => r.status === 'PASS').length;
+    // WARN = prag soft atins: conform, dar semnalat
+    const passedAutomated = activeCheckResults.filter(r => r.status === 'PASS' || r.status === 'WARN').length;
+    const warnedAutomated = activeCheckResults.filter(r => r.status === 'WARN').length;
     const failedAutomated = activeCheckResults.filter(r => r.status === 'FAIL').length;
+    const waivedAutomated = auditRun.checkResults.filter(
+        r => !excludedIds.includes(r.automatedCheck.control.controlId) && r.status === 'WAIVED'
+    ).length;
     const criticalFails = activeCheckResults.filter(
         r => r.status === 'FAIL' && r.automatedCheck.control.severity === 'CRITICAL'
     ).length;
@@ -90,7 +96,9 @@ async function calculateScoring(auditRunId) {
         details: {
             totalAutomated,
             passedAutomated,
+            warnedAutomated,
             failedAutomated,
+            waivedAutomated,
             criticalFails,
             totalManual,
             completedManual,
@@ -109,12 +109,21 @@ async function importJson(jsonData, userId, isBuiltIn = false) {
                                     command: check.command,
                                     script: check.script,
                                     expectedResult: check.expectedResult,
+                                    warnResult: check.warnResult,
                                     checkType: check.checkType,
                                     comparison: check.comparison || 'EQUALS',
                                     parser: check.parser || 'RAW',
                                     normalize: check.normalize || [],
                                     onFailMessage: check.onFailMessage,
                                     platformScope: check.platformScope || [],
+                                    timeoutSeconds: check.timeoutSeconds,
+                                    serial: check.serial === true,
+                                    runAs: check.runAs,
+                                    env: check.env,
+                                    interpreter: check.interpreter,
+                                    scriptSha256: check.scriptSha256,
+                                    args: check.args,
+                                    requires: check.requires || [],
                                 })),
                             },
                             manualChecks: {
@@ -216,12 +225,21 @@ async function importOrUpdatePredefinedTemplate(jsonData) {
                             command: check.command,
                             script: check.script,
                             expectedResult: check.expectedResult,
+                            warnResult: check.warnResult,
                             checkType: check.checkType,
                             comparison: check.comparison || 'EQUALS',
                             parser: check.parser || 'RAW',
                             normalize: check.normalize || [],
                             onFailMessage: check.onFailMessage,
                             platformScope: check.platformScope || [],
+                            timeoutSeconds: check.timeoutSeconds,
+                            serial: check.serial === true,
+                            runAs: check.runAs,
+                            env: check.env,
+                            interpreter: check.interpreter,
+                            scriptSha256: check.scriptSha256,
+                            args: check.args,
+                            requires: check.requires || [],
                         })),
                     },
                     manualChecks: {
@@ -328,12 +346,21 @@ async function exportJson(id) {
                 command: check.command,
                 script: check.script,
                 expectedResult: check.expectedResult,
+                warnResult: check.warnResult,
                 checkType: check.checkType,
                 comparison: check.comparison,
                 parser: check.parser,
                 normalize: check.normalize,
                 onFailMessage: check.onFailMessage,
                 platformScope: check.platformScope,
+                timeoutSeconds: check.timeoutSeconds,
+                serial: check.serial === true,
+                runAs: check.runAs,
+                env: check.env,
+                interpreter: check.interpreter,
+                scriptSha256: check.scriptSha256,
+                args: check.args,
+                requires: check.requires,
             })),
             manualChecks: control.manualChecks.map(check => ({
                 checkId: check.checkId,
@@ -426,13 +453,23 @@ async function updateControls(id, controls, userRole) {
                             checkId: check.checkId || `check-${checkIdx}`,
                             title: check.title || 'Check',
                             command: check.command || '',
+                            script: check.script,
                             expectedResult: check.expectedResult || '',
+                            warnResult: check.warnResult,
                             checkType: check.checkType,
                             comparison: check.comparison || 'EQUALS',
                             parser: check.parser || 'RAW',
                             normalize: check.normalize || [],
                             onFailMessage: check.onFailMessage || null,
                             platformScope: check.platformScope || [],
+                            timeoutSeconds: check.timeoutSeconds,
+                            serial: check.serial === true,
+                            runAs: check.runAs,
+                            env: check.env,
+                            interpreter: check.interpreter,
+                            scriptSha256: check.scriptSha256,
+                            args: check.args,
+                            requires: check.requires || [],
                         })),
                     },
                     manualChecks: {
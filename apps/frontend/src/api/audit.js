@@ -27,6 +27,11 @@ export const auditApi = {
         return response.data;
     },
 
+    cancel: async (id) => {
+        const response = await client.post(`/audit/${id}/cancel`);
+        return response.data;
+    },
+
     submitEvidence: async (runId, taskId, formData) => {
         const response = await client.post(
             `/audit/${runId}/manual/${taskId}/evidence`,
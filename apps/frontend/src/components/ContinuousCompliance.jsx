@@ -0,0 +1,196 @@
+import { useState, useEffect } from 'react';
+import api from '../api/client';
+import { useAuth } from '../context/AuthContext';
+
+const STATUS_BADGE = {
+    PASS: 'success',
+    WARN: 'warning',
+    FAIL: 'danger',
+    ERROR: 'danger',
+    BLOCKED: 'danger',
+    WAIVED: 'neutral',
+};
+
+const formatDate = (date) => {
+    if (!date) return '-';
+    return new Date(date).toLocaleString('ro-RO', {
+        day: '2-digit',
+        month: '2-digit',
+        year: 'numeric',
+        hour: '2-digit',
+        minute: '2-digit'
+    });
+};
+
+function StatusBadge({ status }) {
+    if (!status) return <span style={{ color: 'var(--text-muted)' }}>-</span>;
+    return <span className={`badge badge-${STATUS_BADGE[status] || 'neutral'}`}>{status}</span>;
+}
+
+// Sabloane atribuite serverului pentru evaluare continua si schimbarile de
+// status raportate de agent; refreshKey se schimba la fiecare drift primit live
+function ContinuousCompliance({ serverId, templates, refreshKey }) {
+    const { isAuditor: canManage } = useAuth();
+    const [posture, setPosture] = useState({ assignments: [], events: [] });
+    const [templateId, setTemplateId] = useState('');
+    const [schedule, setSchedule] = useState('@hourly');
+    const [saving, setSaving] = useState(false);
+    const [error, setError] = useState(null);
+
+    useEffect(() => {
+        loadPosture();
+    }, [serverId, refreshKey]);
+
+    const loadPosture = async () => {
+        try {
+            const response = await api.get(`/servers/${serverId}/assignments`);
+            setPosture(response.data || { assignments: [], events: [] });
+        } catch (err) {
+            console.error('Error loading assignments:', err);
+        }
+    };
+
+    const handleAssign = async () => {
+        if (!templateId || !schedule) return;
+        setSaving(true);
+        setError(null);
+        try {
+            await api.post(`/servers/${serverId}/assignments`, { templateId, schedule });
+            setTemplateId('');
+            await loadPosture();
+        } catch (err) {
+            setError(err.response?.data?.message || 'Atribuirea a esuat');
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const handleRemove = async (assignmentId) => {
+        if (!window.confirm('Opresti evaluarea continua pentru acest sablon?')) return;
+        try {
+            await api.delete(`/servers/${serverId}/assignments/${assignmentId}`);
+            await loadPosture();
+        } catch (err) {
+            console.error('Error removing assignment:', err);
+        }
+    };
+
+    return (
+        <div className="audits-section">
+            {canManage && (
+                <div style={{ display: 'flex', gap: '0.75rem', alignItems: 'center', marginBottom: '1.5rem', flexWrap: 'wrap' }}>
+                    <select value={templateId} onChange={(e) => setTemplateId(e.target.value)} className="input" style={{ maxWidth: '320px' }}>
+                        <option value="">Alege sablon...</option>
+                        {templates.map(t => (
+                            <option key={t.id} value={t.id}>{t.name} (v{t.versions?.[0]?.version || '?'})</option>
+                        ))}
+                    </select>
+                    <input
+                        className="input"
+                        style={{ maxWidth: '180px', fontFamily: 'monospace' }}
+                        value={schedule}
+                        onChange={(e) => setSchedule(e.target.value)}
+                        placeholder="*/30 * * * *"
+                        title="Expresie cron: minut ora zi luna zi-saptamana (sau @hourly, @daily)"
+                    />
+                    <button className="btn btn-primary" disabled={!templateId || !schedule || saving} onClick={handleAssign}>
+                        {saving ? 'Se salveaza...' : 'Atribuie'}
+                    </button>
+                    {error && <span style={{ color: 'var(--danger)', fontSize: '0.875rem' }}>{error}</span>}
+                </div>
+            )}
+
+            {posture.assignments.length === 0 ? (
+                <div className="empty-state">
+                    <span className="material-symbols-outlined">update</span>
+                    <p>Niciun sablon atribuit pentru evaluare continua.</p>
+                </div>
+            ) : (
+                <table className="data-table">
+                    <thead>
+                        <tr>
+                            <th>Template</th>
+                            <th>Program</th>
+                            <th>Ultima evaluare</th>
+                            <th>Stare curenta</th>
+                            {canManage && <th style={{ textAlign: 'right' }}>Actiuni</th>}
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {posture.assignments.map(a => (
+                            <tr key={a.id}>
+                                <td>
+                                    <div style={{ fontWeight: 500 }}>{a.templateVersion?.template?.name || 'Unknown Template'}</div>
+                                    <div style={{ fontSize: '0.75rem', color: 'var(--text-muted)' }}>v{a.templateVersion?.version}</div>
+                                </td>
+                                <td><code>{a.schedule}</code></td>
+                                <td style={{ color: 'var(--text-muted)', fontSize: '0.875rem' }}>
+                                    {a.lastEvaluatedAt ? `${formatDate(a.lastEvaluatedAt)} (${a.lastEvaluatedCount ?? '?'} verificari)` : 'In asteptarea agentului'}
+                                </td>
+                                <td>
+                                    <div style={{ display: 'flex', gap: '0.375rem', flexWrap: 'wrap' }}>
+                                        {Object.entries(a.counts || {}).map(([status, count]) => (
+                                            <span key={status} className={`badge badge-${STATUS_BADGE[status] || 'neutral'}`}>{status} {count}</span>
+                                        ))}
+                                    </div>
+                                </td>
+                                {canManage && (
+                                    <td style={{ textAlign: 'right' }}>
+                                        <button className="btn-icon" onClick={() => handleRemove(a.id)} title="Opreste evaluarea continua">
+                                            <span className="material-symbols-outlined">delete</span>
+                                        </button>
+                                    </td>
+                                )}
+                            </tr>
+                        ))}
+                    </tbody>
+                </table>
+            )}
+
+            {posture.events.length > 0 && (
+                <>
+                    <h3 style={{ margin: '2rem 0 1rem' }}>Drift recent</h3>
+                    <table className="data-table">
+                        <thead>
+                            <tr>
+                                <th>Verificare</th>
+                                <th>Inainte</th>
+                                <th>Acum</th>
+                                <th>Evaluata</th>
+                                <th>Semnatura</th>
+                            </tr>
+                        </thead>
+                        <tbody>
+                            {posture.events.map(e => (
+                                <tr key={e.id}>
+                                    <td>
+                                        <div style={{ fontWeight: 500 }}>{e.automatedCheck?.checkId}</div>
+                                        <div style={{ fontSize: '0.75rem', color: 'var(--text-muted)' }}>{e.automatedCheck?.title}</div>
+                                    </td>
+                                    <td><StatusBadge status={e.previousStatus} /></td>
+                                    <td>
+                                        <StatusBadge status={e.status} />
+                                        {e.reasonCode && <div style={{ fontSize: '0.75rem', color: 'var(--text-muted)' }}>{e.reasonCode}</div>}
+                                        {e.waiver && (
+                                            <div style={{ fontSize: '0.75rem', color: e.waiver.expired ? 'var(--danger)' : 'var(--text-muted)' }} title={e.waiver.justification}>
+                                                {e.waiver.id}: {e.waiver.expired ? 'exceptie expirata' : `evaluat ${e.waiver.evaluatedStatus}`}
+                                            </div>
+                                        )}
+                                    </td>
+                                    <td style={{ color: 'var(--text-muted)', fontSize: '0.875rem' }}>{formatDate(e.evaluatedAt)}</td>
+                                    <td>
+                                        <span className="material-symbols-outlined" style={{ color: e.verified ? 'var(--success)' : 'var(--text-muted)' }} title={e.verified ? 'Semnatura agent verificata' : 'Neverificat'}>
+                                            {e.verified ? 'verified' : 'help'}
+                                        </span>
+                                    </td>
+                                </tr>
+                            ))}
+                        </tbody>
+                    </table>
+                </>
+            )}
+        </div>
+    );
+}
+
+export default ContinuousCompliance;
@@ -11,6 +11,8 @@ function AuditDetail() {
     const [error, setError] = useState('');
     const [activeTab, setActiveTab] = useState('automated');
     const [expandedCheck, setExpandedCheck] = useState(null);
+    const [agentProgress, setAgentProgress] = useState(null);
+    const [cancelling, setCancelling] = useState(false);
     const reportRef = useRef(null);
 
     useEffect(() => {
@@ -33,8 +35,13 @@ function AuditDetail() {
             console.log('New result:', data);
         });
 
+        // heartbeat agent: verificari terminate / in executie
+        socket.on('agentProgress', (data) => {
+            setAgentProgress(data);
+        });
+
         socket.on('progress', (data) => {
-            if (data.status === 'COMPLETED' || data.status === 'FAILED') {
+            if (data.status === 'COMPLETED' || data.status === 'FAILED' || data.status === 'CANCELLED') {
                 console.log('Audit finished, reloading...', data.status);
                 loadAudit();
             }
@@ -62,7 +69,7 @@ function AuditDetail() {
                     if (data.status !== audit.status) {
                         setAudit(data);
                     }
-                    if (data.status === 'COMPLETED' || data.status === 'FAILED') {
+                    if (data.status === 'COMPLETED' || data.status === 'FAILED' || data.status === 'CANCELLED') {
                         clearInterval(interval);
                     }
                 }).catch(console.error);
@@ -88,6 +95,19 @@ function AuditDetail() {
         }
     };
 
+    const handleCancel = async () => {
+        if (!window.confirm('Anulati auditul? Agentul opreste verificarile ramase si pe cele in executie.')) return;
+        setCancelling(true);
+        try {
+            await auditApi.cancel(id);
+            await loadAudit();
+        } catch (err) {
+            alert(err.response?.data?.message || 'Eroare la anularea auditului');
+        } finally {
+            setCancelling(false);
+        }
+    };
+
     const handleExportPDF = async () => {
         if (!audit) return;
 
@@ -446,6 +466,12 @@ function AuditDetail() {
                             </span>
                         </div>
                     )}
+                    {isRunning && (
+                        <button className="btn btn-secondary" onClick={handleCancel} disabled={cancelling}>
+                            <span className="material-symbols-outlined">cancel</span>
+                            {cancelling ? 'Se anuleaza...' : 'Anuleaza'}
+                        </button>
+                    )}
                     <button
                         className="btn btn-primary"
                         onClick={handleExportPDF}
@@ -464,6 +490,12 @@ function AuditDetail() {
                     <div className="running-spinner"></div>
                     <h2>Audit in desfasurare</h2>
                     <p>Agentul ruleaza verificarile de securitate. Acest proces poate dura cateva minute.</p>
+                    {agentProgress && (
+                        <p>
+                            <strong>{agentProgress.completed}/{agentProgress.total}</strong> verificari finalizate
+                            {agentProgress.running?.length > 0 && <> • in executie: {agentProgress.running.join(', ')}</>}
+                        </p>
+                    )}
                     <div className="progress-bar-container">
                         <div className="progress-bar-animated"></div>
                     </div>
@@ -809,7 +841,10 @@ function CheckResultCard({ result, expanded, onToggle }) {
     const automatedCheck = result.automatedCheck || {};
     const control = automatedCheck.control || {};
 
-    const statusClass = result.status === 'PASS' ? 'pass' : result.status === 'FAIL' ? 'fail' : 'error';
+    const statusClass = result.status === 'PASS' ? 'pass' :
+        result.status === 'FAIL' ? 'fail' :
+            result.status === 'WAIVED' ? 'neutral' : 'error';
+    const waiver = result.waiver;
 
     return (
         <div className={`check-result-card ${statusClass}`} onClick={onToggle}>
@@ -822,7 +857,7 @@ function CheckResultCard({ result, expanded, onToggle }) {
                 <div className="check-result-status">
                     <span className={`badge badge-${statusClass}`}>
                         <span className="material-symbols-outlined" style={{ fontSize: '14px' }}>
-                            {result.status === 'PASS' ? 'check' : result.status === 'FAIL' ? 'close' : 'warning'}
+                            {result.status === 'PASS' ? 'check' : result.status === 'FAIL' ? 'close' : result.status === 'WAIVED' ? 'verified_user' : 'warning'}
                         </span>
                         {result.status}
                     </span>
@@ -852,6 +887,25 @@ function CheckResultCard({ result, expanded, onToggle }) {
                             <code className="detail-code">{automatedCheck.expectedResult}</code>
                         </div>
                     )}
+                    {result.reasonCode && (
+                        <div className="detail-row">
+                            <span className="detail-label">Cod Motiv:</span>
+                            <code className="detail-code">{result.reasonCode}</code>
+                        </div>
+                    )}
+                    {waiver && (
+                        <div className="detail-row">
+                            <span className="detail-label">Exceptie:</span>
+                            <span className="detail-value">
+                                <code>{waiver.id}</code>{' '}
+                                {waiver.expired
+                                    ? <span className="badge badge-danger">EXPIRATA</span>
+                                    : <>status evaluat <strong>{waiver.evaluatedStatus}</strong></>}
+                                <br />
+                                {waiver.justification} (aprobat de {waiver.approver}, expira {waiver.expiresAt})
+                            </span>
+                        </div>
+                    )}
                     {result.output && (
                         <div className="detail-row">
                             <span className="detail-label">Output Agent:</span>
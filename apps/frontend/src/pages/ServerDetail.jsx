@@ -4,6 +4,7 @@ import { io } from 'socket.io-client';
 import api from '../api/client';
 import './ServerDetail.css';
 import ShareServerModal from '../components/ShareServerModal';
+import ContinuousCompliance from '../components/ContinuousCompliance';
 
 function ServerDetail() {
     const { id } = useParams();
@@ -25,6 +26,9 @@ function ServerDetail() {
     // Stare versiune agent
     const [latestAgentVersion, setLatestAgentVersion] = useState(null);
 
+    // Schimbari raportate de evaluarea continua (reincarcare tab)
+    const [driftVersion, setDriftVersion] = useState(0);
+
     useEffect(() => {
         loadServer();
         loadAudits();
@@ -73,6 +77,10 @@ function ServerDetail() {
             });
         });
 
+        liveSocket.on('server:drift', () => {
+            setDriftVersion(v => v + 1);
+        });
+
         // Socket pentru status server (detectare offline)
         const serversSocket = io(`${wsUrl}/ws/servers`, {
             auth: { token },
@@ -400,6 +408,12 @@ bittrail-agent version`;
                 >
                     Istoric Audituri
                 </button>
+                <button
+                    className={`tab ${activeTab === 'continuous' ? 'active' : ''}`}
+                    onClick={() => setActiveTab('continuous')}
+                >
+                    Conformitate Continua
+                </button>
                 <button
                     className={`tab ${activeTab === 'inventory' ? 'active' : ''}`}
                     onClick={() => setActiveTab('inventory')}
@@ -628,6 +642,10 @@ bittrail-agent version`;
                     </div>
                 )}
 
+                {activeTab === 'continuous' && (
+                    <ContinuousCompliance serverId={id} templates={templates} refreshKey={driftVersion} />
+                )}
+
                 {activeTab === 'inventory' && (
                     <div className="inventory-section">
                         {/* Inventar simplificat */}
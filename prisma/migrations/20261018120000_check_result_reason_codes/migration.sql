@@ -0,0 +1,16 @@
+-- AlterEnum
+-- This migration adds more than one value to an enum.
+-- With PostgreSQL versions 11 and earlier, this is not possible
+-- in a single migration. This can be worked around by creating
+-- multiple migrations, each migration adding only one value to
+-- the enum.
+
+
+ALTER TYPE "CheckStatus" ADD VALUE 'WARN';
+ALTER TYPE "CheckStatus" ADD VALUE 'BLOCKED';
+
+-- AlterTable
+ALTER TABLE "automated_checks" ADD COLUMN     "warnResult" TEXT;
+
+-- AlterTable
+ALTER TABLE "check_results" ADD COLUMN     "reasonCode" TEXT;
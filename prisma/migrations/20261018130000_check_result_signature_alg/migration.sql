@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "check_results" ADD COLUMN     "signatureAlg" TEXT;
@@ -0,0 +1,19 @@
+-- CreateTable
+CREATE TABLE "journal_anchors" (
+    "id" TEXT NOT NULL,
+    "serverId" TEXT NOT NULL,
+    "seq" INTEGER NOT NULL,
+    "hash" TEXT NOT NULL,
+    "agentTime" TIMESTAMP(3) NOT NULL,
+    "signature" TEXT NOT NULL,
+    "verified" BOOLEAN NOT NULL DEFAULT false,
+    "receivedAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "journal_anchors_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE INDEX "journal_anchors_serverId_seq_idx" ON "journal_anchors"("serverId", "seq");
+
+-- AddForeignKey
+ALTER TABLE "journal_anchors" ADD CONSTRAINT "journal_anchors_serverId_fkey" FOREIGN KEY ("serverId") REFERENCES "servers"("id") ON DELETE CASCADE ON UPDATE CASCADE;
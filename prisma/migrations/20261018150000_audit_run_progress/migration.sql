@@ -0,0 +1,5 @@
+-- AlterTable
+ALTER TABLE "audit_runs" ADD COLUMN     "agentProgress" JSONB,
+ADD COLUMN     "cancelledAt" TIMESTAMP(3),
+ADD COLUMN     "cancelledBy" TEXT,
+ADD COLUMN     "lastHeartbeatAt" TIMESTAMP(3);
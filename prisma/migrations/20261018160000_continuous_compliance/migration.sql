@@ -0,0 +1,57 @@
+-- CreateTable
+CREATE TABLE "compliance_assignments" (
+    "id" TEXT NOT NULL,
+    "serverId" TEXT NOT NULL,
+    "templateVersionId" TEXT NOT NULL,
+    "schedule" TEXT NOT NULL,
+    "excludedControlIds" TEXT[] DEFAULT ARRAY[]::TEXT[],
+    "createdBy" TEXT,
+    "lastEvaluatedAt" TIMESTAMP(3),
+    "lastEvaluatedCount" INTEGER,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "compliance_assignments_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "drift_events" (
+    "id" TEXT NOT NULL,
+    "assignmentId" TEXT NOT NULL,
+    "automatedCheckId" TEXT NOT NULL,
+    "previousStatus" "CheckStatus",
+    "status" "CheckStatus" NOT NULL,
+    "output" TEXT,
+    "errorMessage" TEXT,
+    "reasonCode" TEXT,
+    "evaluatedAt" TIMESTAMP(3) NOT NULL,
+    "outputHash" TEXT,
+    "execTimestamp" TIMESTAMP(3),
+    "execHostname" TEXT,
+    "execUser" TEXT,
+    "exitCode" INTEGER,
+    "signature" TEXT,
+    "signatureAlg" TEXT,
+    "verified" BOOLEAN NOT NULL DEFAULT false,
+    "receivedAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "drift_events_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "compliance_assignments_serverId_templateVersionId_key" ON "compliance_assignments"("serverId", "templateVersionId");
+
+-- CreateIndex
+CREATE INDEX "drift_events_assignmentId_evaluatedAt_idx" ON "drift_events"("assignmentId", "evaluatedAt");
+
+-- AddForeignKey
+ALTER TABLE "compliance_assignments" ADD CONSTRAINT "compliance_assignments_serverId_fkey" FOREIGN KEY ("serverId") REFERENCES "servers"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "compliance_assignments" ADD CONSTRAINT "compliance_assignments_templateVersionId_fkey" FOREIGN KEY ("templateVersionId") REFERENCES "template_versions"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "drift_events" ADD CONSTRAINT "drift_events_assignmentId_fkey" FOREIGN KEY ("assignmentId") REFERENCES "compliance_assignments"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "drift_events" ADD CONSTRAINT "drift_events_automatedCheckId_fkey" FOREIGN KEY ("automatedCheckId") REFERENCES "automated_checks"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
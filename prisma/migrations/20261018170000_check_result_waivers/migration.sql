@@ -0,0 +1,8 @@
+-- AlterEnum
+ALTER TYPE "CheckStatus" ADD VALUE 'WAIVED';
+
+-- AlterTable
+ALTER TABLE "check_results" ADD COLUMN     "waiver" JSONB;
+
+-- AlterTable
+ALTER TABLE "drift_events" ADD COLUMN     "waiver" JSONB;
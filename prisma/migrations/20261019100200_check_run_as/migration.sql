@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "automated_checks" ADD COLUMN     "runAs" TEXT;
@@ -0,0 +1,4 @@
+-- AlterTable
+ALTER TABLE "automated_checks" ADD COLUMN     "interpreter" TEXT;
+ALTER TABLE "automated_checks" ADD COLUMN     "scriptSha256" TEXT;
+ALTER TABLE "automated_checks" ADD COLUMN     "args" JSONB;